@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+var AdminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Gère l'accès à l'interface d'administration /admin.",
+}
+
+var AdminHashPasswordCmd = &cobra.Command{
+	Use:   "hash-password",
+	Short: "Calcule le hash bcrypt du mot de passe d'administration.",
+	Long: `Cette commande lit le mot de passe sur l'entrée standard (première ligne) et affiche
+son hash bcrypt, à copier dans 'admin.password_hash' du fichier de configuration.
+Le mot de passe n'apparaît ainsi ni dans les arguments ni dans l'historique du shell.
+
+Exemple:
+  url-shortener admin hash-password
+  echo "$ADMIN_PASSWORD" | url-shortener admin hash-password`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Fprint(os.Stderr, "Mot de passe : ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatalf("❌ Lecture du mot de passe impossible : %v", err)
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if len(password) < 8 || len(password) > 72 {
+			log.Fatalln("❌ Le mot de passe doit faire entre 8 et 72 caractères.")
+		}
+
+		hash, err := services.HashLinkPassword(password)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		fmt.Println(hash)
+	},
+}
+
+func init() {
+	AdminCmd.AddCommand(AdminHashPasswordCmd)
+	cmd2.RootCmd.AddCommand(AdminCmd)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/backup"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupDirFlag        string
+	backupCompressFlag   bool
+	backupKeepFlag       int
+	backupMaxAgeDaysFlag int
+)
+
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Sauvegarde la base SQLite, y compris pendant que le serveur tourne.",
+	Long: `Cette commande copie la base SQLite avec VACUUM INTO, qui produit une image cohérente
+sans interrompre le serveur, puis écrit sa somme de contrôle SHA-256 (fichier .sha256).
+Les sauvegardes au-delà de --keep ou plus anciennes que --max-age-days sont supprimées.
+Les valeurs par défaut viennent de la section backup de la configuration.
+
+Exemples:
+  url-shortener backup
+  url-shortener backup --dir /var/backups/urlshortener --compress=false --keep 30`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalln("❌ Configuration non initialisée.")
+		}
+
+		opts := backup.OptionsFromConfig(cfg)
+		if cmd.Flags().Changed("dir") {
+			opts.Dir = backupDirFlag
+		}
+		if cmd.Flags().Changed("compress") {
+			opts.Compress = backupCompressFlag
+		}
+		if cmd.Flags().Changed("keep") {
+			opts.Keep = backupKeepFlag
+		}
+		if cmd.Flags().Changed("max-age-days") {
+			opts.MaxAge = time.Duration(backupMaxAgeDaysFlag) * 24 * time.Hour
+		}
+
+		db, err := storage.OpenDB(cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec connexion DB : %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("❌ Échec récupération connexion SQL : %v", err)
+		}
+		defer sqlDB.Close()
+
+		result, err := backup.Create(db, opts)
+		if errors.Is(err, backup.ErrUnsupportedDriver) {
+			log.Fatalf("❌ %v.", err)
+		}
+		if err != nil {
+			log.Fatalf("❌ Échec de la sauvegarde : %v", err)
+		}
+
+		fmt.Printf("✅ Sauvegarde créée : %s (%d octets)\n", result.Path, result.Size)
+		fmt.Printf("   SHA-256 : %s\n", result.Checksum)
+		for _, path := range result.Removed {
+			fmt.Printf("♻️  Ancienne sauvegarde supprimée : %s\n", path)
+		}
+	},
+}
+
+func init() {
+	BackupCmd.Flags().StringVar(&backupDirFlag, "dir", "", "Dossier des sauvegardes (défaut : backup.dir)")
+	BackupCmd.Flags().BoolVar(&backupCompressFlag, "compress", true, "Compresse la sauvegarde avec gzip (défaut : backup.compress)")
+	BackupCmd.Flags().IntVar(&backupKeepFlag, "keep", 0, "Nombre de sauvegardes conservées, 0 = illimité (défaut : backup.keep)")
+	BackupCmd.Flags().IntVar(&backupMaxAgeDaysFlag, "max-age-days", 0, "Âge maximal des sauvegardes en jours, 0 = illimité (défaut : backup.max_age_days)")
+	cmd2.RootCmd.AddCommand(BackupCmd)
+}
@@ -5,16 +5,27 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
 	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
-	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
 	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
-var longURLFlag string // --url
+var (
+	longURLFlag       string // --url
+	forwardQueryFlag  string // --forward-query
+	forwardPathFlag   bool   // --forward-path
+	passwordFlag      string // --password
+	reuseExistingFlag bool   // --reuse-existing
+	strategyFlag      string // --strategy
+	aliasFlag         string // --alias
+	fileFlag          string // --file
+	outputFlag        string // --output
+)
 
 var CreateCmd = &cobra.Command{
 	Use:   "create",
@@ -22,17 +33,29 @@ var CreateCmd = &cobra.Command{
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
 Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://docs.example.com" --forward-path --forward-query=merge
+  url-shortener create --url="https://www.example.com" --alias=promo
+
+Avec --file, les liens sont lus depuis un fichier CSV (en-tête avec au moins
+une colonne url, et éventuellement alias, utm_source, utm_medium, utm_campaign,
+utm_term, utm_content, forward_query, forward_path, password, code_strategy)
+ou JSON (tableau d'objets ou un objet par ligne, mêmes champs). Le fichier est
+traité par lots, chaque lot dans une transaction, et le résultat de chaque
+ligne est écrit dans un fichier CSV :
+  url-shortener create --file=links.csv --output=links.results.csv`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if longURLFlag == "" {
-			fmt.Println("❌ Le flag --url est requis.")
+		if longURLFlag == "" && fileFlag == "" {
+			fmt.Println("❌ Le flag --url ou --file est requis.")
 			os.Exit(1)
 		}
 
 		// Validation format URL
-		if _, err := url.ParseRequestURI(longURLFlag); err != nil {
-			fmt.Printf("❌ L'URL fournie est invalide : %v\n", err)
-			os.Exit(1)
+		if fileFlag == "" {
+			if _, err := url.ParseRequestURI(longURLFlag); err != nil {
+				fmt.Printf("❌ L'URL fournie est invalide : %v\n", err)
+				os.Exit(1)
+			}
 		}
 
 		cfg := cmd2.Cfg
@@ -40,7 +63,9 @@ Exemple:
 			log.Fatalln("❌ Configuration non initialisée.")
 		}
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		defer lockDatabase(cfg)()
+
+		db, err := storage.OpenDB(cfg)
 		if err != nil {
 			log.Fatalf("❌ Échec connexion DB : %v", err)
 		}
@@ -51,17 +76,64 @@ Exemple:
 		}
 		defer sqlDB.Close()
 
-		linkRepo := repository.NewGormLinkRepository(db)
+		// Avec le cache redis, les liens créés invalident le cache des serveurs.
+		st, err := storage.Open(cfg, db, storage.Options{})
+		if err != nil {
+			log.Fatalf("❌ Échec initialisation du stockage : %v", err)
+		}
+		defer st.Close()
+		linkRepo := st.Links()
 		// clickRepo := repository.NewGormClickRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		campaignRepo := st.Campaigns()
+		destinationPolicy, err := policy.NewFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec chargement politique de destinations : %v", err)
+		}
+		serviceConfig, err := services.LinkServiceConfigFromConfig(cfg, destinationPolicy, st)
+		if err != nil {
+			log.Fatalf("❌ Configuration de génération des codes invalide : %v", err)
+		}
+		linkService := services.NewLinkService(linkRepo, campaignRepo, serviceConfig)
+
+		if fileFlag != "" {
+			output := outputFlag
+			if output == "" {
+				output = strings.TrimSuffix(fileFlag, filepath.Ext(fileFlag)) + ".results.csv"
+			}
+
+			summary, err := importLinksFile(linkService, cfg.Server.BaseURL, fileFlag, output, cfg.Server.BulkMaxItems)
+			linkService.WaitBackgroundTasks()
+			if err != nil {
+				log.Fatalf("❌ Import interrompu après %d lignes : %v", summary.Rows, err)
+			}
+			fmt.Printf("✅ Import terminé : %d créés, %d réutilisés, %d en erreur sur %d lignes.\n",
+				summary.Created, summary.Reused, summary.Failed, summary.Rows)
+			fmt.Printf("📄 Résultats : %s\n", output)
+			return
+		}
+
+		link, reused, err := linkService.CreateLinkWithOptions(longURLFlag, services.LinkOptions{
+			Alias:        aliasFlag,
+			ForwardQuery: forwardQueryFlag,
+			ForwardPath:  forwardPathFlag,
+			Password:     passwordFlag,
 
-		link, err := linkService.CreateLink(longURLFlag)
+			ReuseExisting: reuseExistingFlag,
+			CodeStrategy:  strategyFlag,
+		})
 		if err != nil {
 			log.Fatalf("❌ Erreur lors de la création du lien court : %v", err)
 		}
 
+		// Laisse le temps à la récupération des métadonnées de la destination.
+		linkService.WaitBackgroundTasks()
+
 		fullShortURL := fmt.Sprintf("%s/%s", cfg.Server.BaseURL, link.ShortCode)
-		fmt.Println("✅ URL courte créée avec succès :")
+		if reused {
+			fmt.Println("♻️  Lien existant réutilisé pour cette destination :")
+		} else {
+			fmt.Println("✅ URL courte créée avec succès :")
+		}
 		fmt.Printf("🔗 Code : %s\n", link.ShortCode)
 		fmt.Printf("🌐 URL complète : %s\n", fullShortURL)
 	},
@@ -69,6 +141,14 @@ Exemple:
 
 func init() {
 	CreateCmd.Flags().StringVar(&longURLFlag, "url", "", "URL longue à raccourcir")
-	CreateCmd.MarkFlagRequired("url")
+	CreateCmd.Flags().StringVar(&forwardQueryFlag, "forward-query", "", "Transfert des paramètres de requête : merge ou override")
+	CreateCmd.Flags().BoolVar(&forwardPathFlag, "forward-path", false, "Transfère les segments de chemin ajoutés après le code court")
+	CreateCmd.Flags().StringVar(&passwordFlag, "password", "", "Mot de passe demandé avant la redirection")
+	CreateCmd.Flags().BoolVar(&reuseExistingFlag, "reuse-existing", false, "Réutilise un lien existant vers la même destination")
+	CreateCmd.Flags().StringVar(&strategyFlag, "strategy", "", "Stratégie de code : random, sequential, words ou unambiguous")
+	CreateCmd.Flags().StringVar(&aliasFlag, "alias", "", "Code court personnalisé (3 à 10 caractères)")
+	CreateCmd.Flags().StringVar(&fileFlag, "file", "", "Fichier CSV ou JSON de liens à créer en lot")
+	CreateCmd.Flags().StringVar(&outputFlag, "output", "", "Fichier CSV des résultats (défaut : <fichier>.results.csv)")
+	CreateCmd.MarkFlagsMutuallyExclusive("url", "file")
 	cmd2.RootCmd.AddCommand(CreateCmd)
 }
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+)
+
+// fileLinkRow est une ligne du fichier d'import, en CSV (colonnes nommées par
+// l'en-tête) ou en JSON (objets d'un tableau ou un objet par ligne).
+type fileLinkRow struct {
+	URL          string `json:"url"`
+	Alias        string `json:"alias"`
+	ForwardQuery string `json:"forward_query"`
+	ForwardPath  bool   `json:"forward_path"`
+	UTMSource    string `json:"utm_source"`
+	UTMMedium    string `json:"utm_medium"`
+	UTMCampaign  string `json:"utm_campaign"`
+	UTMTerm      string `json:"utm_term"`
+	UTMContent   string `json:"utm_content"`
+	Password     string `json:"password"`
+	CodeStrategy string `json:"code_strategy"`
+}
+
+// options convertit la ligne en options de création, les drapeaux globaux
+// de la commande servant de valeurs par défaut.
+func (r *fileLinkRow) options() services.LinkOptions {
+	opts := services.LinkOptions{
+		Alias:        r.Alias,
+		ForwardQuery: r.ForwardQuery,
+		ForwardPath:  r.ForwardPath || forwardPathFlag,
+		UTM: services.UTMParams{
+			Source:   r.UTMSource,
+			Medium:   r.UTMMedium,
+			Campaign: r.UTMCampaign,
+			Term:     r.UTMTerm,
+			Content:  r.UTMContent,
+		},
+		Password:      r.Password,
+		ReuseExisting: reuseExistingFlag,
+		CodeStrategy:  r.CodeStrategy,
+	}
+	if opts.ForwardQuery == "" {
+		opts.ForwardQuery = forwardQueryFlag
+	}
+	if opts.CodeStrategy == "" {
+		opts.CodeStrategy = strategyFlag
+	}
+	return opts
+}
+
+// linkRowReader lit les lignes du fichier une à une, sans le charger en mémoire.
+type linkRowReader interface {
+	Next() (*fileLinkRow, error) // io.EOF en fin de fichier
+}
+
+// openLinkRowReader choisit le format d'après l'extension : .csv ou JSON.
+func openLinkRowReader(path string, r io.Reader) (linkRowReader, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return newCSVRowReader(r)
+	}
+	return newJSONRowReader(r)
+}
+
+type csvRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVRowReader(r io.Reader) (*csvRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("lecture de l'en-tête CSV : %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		if i, ok := columns["long_url"]; ok {
+			columns["url"] = i
+		} else {
+			return nil, errors.New("colonne 'url' absente de l'en-tête CSV")
+		}
+	}
+	return &csvRowReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvRowReader) Next() (*fileLinkRow, error) {
+	record, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	forwardPath, _ := strconv.ParseBool(field("forward_path"))
+	return &fileLinkRow{
+		URL:          field("url"),
+		Alias:        field("alias"),
+		ForwardQuery: field("forward_query"),
+		ForwardPath:  forwardPath,
+		UTMSource:    field("utm_source"),
+		UTMMedium:    field("utm_medium"),
+		UTMCampaign:  field("utm_campaign"),
+		UTMTerm:      field("utm_term"),
+		UTMContent:   field("utm_content"),
+		Password:     field("password"),
+		CodeStrategy: field("code_strategy"),
+	}, nil
+}
+
+type jsonRowReader struct {
+	decoder *json.Decoder
+}
+
+// newJSONRowReader accepte un tableau d'objets ou une suite d'objets (JSON Lines).
+func newJSONRowReader(r io.Reader) (*jsonRowReader, error) {
+	buffered := bufio.NewReader(r)
+	first, err := peekNonSpace(buffered)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	reader := &jsonRowReader{decoder: json.NewDecoder(buffered)}
+	if first == '[' {
+		if _, err := reader.decoder.Token(); err != nil {
+			return nil, fmt.Errorf("lecture du tableau JSON : %w", err)
+		}
+	}
+	return reader, nil
+}
+
+// peekNonSpace retourne le premier caractère significatif sans le consommer.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, r.UnreadByte()
+		}
+	}
+}
+
+func (j *jsonRowReader) Next() (*fileLinkRow, error) {
+	if !j.decoder.More() {
+		return nil, io.EOF
+	}
+	var row fileLinkRow
+	if err := j.decoder.Decode(&row); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// fileImportSummary résume un import de fichier.
+type fileImportSummary struct {
+	Rows    int
+	Created int
+	Reused  int
+	Failed  int
+}
+
+// importLinksFile lit le fichier par lots de batchSize lignes, crée chaque lot
+// dans une transaction et écrit une ligne de résultat par ligne d'entrée.
+func importLinksFile(linkService *services.LinkService, baseURL, inputPath, outputPath string, batchSize int) (fileImportSummary, error) {
+	var summary fileImportSummary
+
+	input, err := os.Open(inputPath)
+	if err != nil {
+		return summary, err
+	}
+	defer input.Close()
+
+	rows, err := openLinkRowReader(inputPath, input)
+	if err != nil {
+		return summary, err
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return summary, err
+	}
+	defer output.Close()
+	results := csv.NewWriter(output)
+	results.Write([]string{"row", "url", "short_code", "short_url", "error"})
+
+	// pendingRow conserve l'ordre du fichier : les lignes rejetées avant la
+	// transaction (batchIndex -1) sont écrites avec celles du lot.
+	type pendingRow struct {
+		number     int
+		url        string
+		batchIndex int
+		err        string
+	}
+	var batch []services.BulkLinkRequest
+	var pending []pendingRow
+
+	writeResult := func(number int, longURL, shortCode, errMessage string) {
+		shortURL := ""
+		if shortCode != "" {
+			shortURL = baseURL + "/" + shortCode
+		}
+		results.Write([]string{strconv.Itoa(number), longURL, shortCode, shortURL, errMessage})
+	}
+
+	flush := func() error {
+		var bulkResults []services.BulkLinkResult
+		if len(batch) > 0 {
+			var err error
+			if bulkResults, err = linkService.CreateLinksBulk(batch); err != nil {
+				return err
+			}
+		}
+		for _, row := range pending {
+			if row.batchIndex < 0 {
+				summary.Failed++
+				writeResult(row.number, row.url, "", row.err)
+				continue
+			}
+			result := bulkResults[row.batchIndex]
+			switch {
+			case result.Err != nil:
+				summary.Failed++
+				writeResult(row.number, row.url, "", result.Err.Error())
+			case result.Reused:
+				summary.Reused++
+				writeResult(row.number, row.url, result.Link.ShortCode, "")
+			default:
+				summary.Created++
+				writeResult(row.number, row.url, result.Link.ShortCode, "")
+			}
+		}
+		batch, pending = batch[:0], pending[:0]
+
+		results.Flush()
+		fmt.Printf("⏳ %d lignes traitées (%d créées, %d réutilisées, %d en erreur)\n",
+			summary.Rows, summary.Created, summary.Reused, summary.Failed)
+		return results.Error()
+	}
+
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Les lignes déjà lues sont traitées avant d'abandonner.
+			if flushErr := flush(); flushErr != nil {
+				return summary, flushErr
+			}
+			return summary, fmt.Errorf("ligne %d illisible : %w", summary.Rows+1, err)
+		}
+		summary.Rows++
+
+		if _, err := url.ParseRequestURI(row.URL); err != nil {
+			pending = append(pending, pendingRow{number: summary.Rows, url: row.URL, batchIndex: -1, err: "URL invalide"})
+			continue
+		}
+		pending = append(pending, pendingRow{number: summary.Rows, url: row.URL, batchIndex: len(batch)})
+		batch = append(batch, services.BulkLinkRequest{LongURL: row.URL, Options: row.options()})
+
+		if len(pending) >= batchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	return summary, flush()
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutputFlag   string // --output
+	exportNoClicksFlag bool   // --no-clicks
+)
+
+var ExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exporte les liens et leurs clics dans une archive JSON Lines.",
+	Long: `Cette commande écrit tous les liens (destination, options, métadonnées,
+date de création) puis tous les clics dans une archive versionnée, lisible par
+la commande import d'une autre instance.
+
+L'archive contient les hash des mots de passe ainsi que les adresses IP et
+User-Agents des clics : elle doit être stockée et transmise comme la base.
+
+Exemple:
+  url-shortener export --output=liens.jsonl
+  url-shortener export --no-clicks > liens.jsonl`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalln("❌ Configuration non initialisée.")
+		}
+
+		db, err := storage.OpenDB(cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec connexion DB : %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("❌ Échec récupération connexion SQL : %v", err)
+		}
+		defer sqlDB.Close()
+
+		var output io.Writer = os.Stdout
+		if exportOutputFlag != "" && exportOutputFlag != "-" {
+			file, err := os.Create(exportOutputFlag)
+			if err != nil {
+				log.Fatalf("❌ Impossible de créer le fichier d'archive : %v", err)
+			}
+			defer file.Close()
+			output = file
+		}
+
+		store := repository.NewGormStore(db)
+		archiveService := services.NewArchiveService(store,
+			services.NewLinkService(store.Links(), store.Campaigns(), services.LinkServiceConfig{Store: store}))
+
+		summary, err := archiveService.Export(output, !exportNoClicksFlag)
+		if err != nil {
+			log.Fatalf("❌ Erreur lors de l'export : %v", err)
+		}
+
+		// Le résumé va sur la sortie d'erreur pour ne pas se mêler à une archive écrite sur stdout.
+		fmt.Fprintf(os.Stderr, "✅ Export terminé : %d lien(s), %d clic(s).\n", summary.Links, summary.Clicks)
+	},
+}
+
+func init() {
+	ExportCmd.Flags().StringVar(&exportOutputFlag, "output", "", "Fichier d'archive à écrire (défaut : sortie standard)")
+	ExportCmd.Flags().BoolVar(&exportNoClicksFlag, "no-clicks", false, "N'exporte que les liens, sans les clics")
+	cmd2.RootCmd.AddCommand(ExportCmd)
+}
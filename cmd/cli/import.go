@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/archive"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFormatFlag   string // --format
+	importConflictFlag string // --on-conflict
+)
+
+// maxReportedFailures limite le détail des liens rejetés affiché en fin d'import.
+const maxReportedFailures = 20
+
+var ImportCmd = &cobra.Command{
+	Use:   "import <fichier>",
+	Short: "Importe des liens depuis une archive ou l'export d'un autre raccourcisseur.",
+	Long: `Cette commande importe une archive produite par export (liens et clics), ou
+l'export CSV d'un autre raccourcisseur : Bitly (colonnes Bitlink, Long URL,
+Title, Created) ou YOURLS (keyword, url, title, timestamp). Les codes courts et
+les dates de création d'origine sont conservés quand c'est possible ; un code
+incompatible (longueur, caractères) est remplacé par un code généré. Les
+exports tiers ne contiennent pas le détail des clics, qui n'est donc pas importé.
+
+L'import se fait dans une seule transaction. En cas de code déjà utilisé :
+  skip       conserve le lien existant et ignore le lien importé (défaut)
+  overwrite  remplace la destination et les options du lien existant
+  rename     importe le lien sous un nouveau code
+  fail       annule tout l'import
+
+Les liens contraires à la politique de destinations sont importés désactivés.
+
+Exemple:
+  url-shortener import liens.jsonl --on-conflict=rename
+  url-shortener import bitly_links.csv --format=bitly
+  cat liens.jsonl | url-shortener import -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !services.IsValidConflictMode(importConflictFlag) {
+			fmt.Printf("❌ Mode --on-conflict invalide : %q (skip, overwrite, rename ou fail).\n", importConflictFlag)
+			os.Exit(1)
+		}
+
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalln("❌ Configuration non initialisée.")
+		}
+
+		var input io.Reader = os.Stdin
+		if args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				log.Fatalf("❌ Impossible d'ouvrir le fichier : %v", err)
+			}
+			defer file.Close()
+			input = file
+		}
+
+		var source archive.Source
+		var err error
+		switch importFormatFlag {
+		case "archive":
+			source, err = archive.NewReader(input)
+		default:
+			source, err = archive.NewCSVReader(importFormatFlag, input)
+		}
+		if err != nil {
+			log.Fatalf("❌ Fichier illisible : %v", err)
+		}
+
+		defer lockDatabase(cfg)()
+
+		db, err := storage.OpenDB(cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec connexion DB : %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("❌ Échec récupération connexion SQL : %v", err)
+		}
+		defer sqlDB.Close()
+
+		destinationPolicy, err := policy.NewFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec chargement politique de destinations : %v", err)
+		}
+		// Avec le cache redis, les liens importés ou remplacés invalident le cache des serveurs.
+		store, err := storage.Open(cfg, db, storage.Options{})
+		if err != nil {
+			log.Fatalf("❌ Échec initialisation du stockage : %v", err)
+		}
+		defer store.Close()
+		serviceConfig, err := services.LinkServiceConfigFromConfig(cfg, destinationPolicy, store)
+		if err != nil {
+			log.Fatalf("❌ Configuration de génération des codes invalide : %v", err)
+		}
+		archiveService := services.NewArchiveService(store,
+			services.NewLinkService(store.Links(), store.Campaigns(), serviceConfig))
+
+		summary, err := archiveService.Import(source, importConflictFlag)
+		if err != nil {
+			log.Fatalf("❌ Import annulé, aucune donnée enregistrée : %v", err)
+		}
+
+		fmt.Printf("✅ Import terminé : %d lien(s) importé(s) dont %d renommé(s), %d remplacé(s), %d ignoré(s), %d rejeté(s).\n",
+			summary.Imported, summary.Renamed, summary.Overwritten, summary.Skipped, len(summary.Failures))
+		fmt.Printf("📊 Clics : %d importé(s), %d ignoré(s).\n", summary.Clicks, summary.SkippedClicks)
+		for i, failure := range summary.Failures {
+			if i == maxReportedFailures {
+				fmt.Printf("   … et %d autre(s).\n", len(summary.Failures)-maxReportedFailures)
+				break
+			}
+			fmt.Printf("⚠️  %s (%s) : %s\n", orDash(failure.ShortCode), failure.LongURL, failure.Reason)
+		}
+	},
+}
+
+func init() {
+	ImportCmd.Flags().StringVar(&importFormatFlag, "format", "archive", "Format du fichier : archive, bitly ou yourls")
+	ImportCmd.Flags().StringVar(&importConflictFlag, "on-conflict", services.ConflictSkip, "Code déjà utilisé : skip, overwrite, rename ou fail")
+	cmd2.RootCmd.AddCommand(ImportCmd)
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts avec le titre de leur page de destination.",
+	Long: `Cette commande affiche tous les liens enregistrés avec leur code, leur URL longue
+et les métadonnées de la page de destination (titre, description) récupérées
+à la création du lien puis rafraîchies par le moniteur.
+
+Exemple:
+  url-shortener list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalln("❌ Configuration non initialisée.")
+		}
+
+		db, err := storage.OpenDB(cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec connexion DB : %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("❌ Échec récupération connexion DB : %v", err)
+		}
+		defer sqlDB.Close()
+
+		linkRepo := repository.NewGormLinkRepository(db)
+		campaignRepo := repository.NewGormCampaignRepository(db)
+		linkService := services.NewLinkService(linkRepo, campaignRepo, services.LinkServiceConfig{})
+
+		links, err := linkService.ListLinks()
+		if err != nil {
+			log.Fatalf("❌ Erreur lors de la récupération des liens : %v", err)
+		}
+
+		if len(links) == 0 {
+			fmt.Println("Aucun lien enregistré.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tURL LONGUE\tTITRE\tDESCRIPTION")
+		for _, link := range links {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", link.ShortCode, link.LongURL,
+				orDash(link.Title), orDash(truncate(link.Description, 60)))
+		}
+		w.Flush()
+	},
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func init() {
+	cmd2.RootCmd.AddCommand(ListCmd)
+}
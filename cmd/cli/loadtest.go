@@ -0,0 +1,312 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/api"
+	"github.com/Julien-Somasundaram/urlshortener/internal/health"
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/monitor"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/Julien-Somasundaram/urlshortener/internal/workers"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	loadDurationFlag    time.Duration
+	loadConcurrencyFlag int
+	loadLinksFlag       int
+	loadCreateRatioFlag float64
+	loadDBFlag          string
+)
+
+var LoadTestCmd = &cobra.Command{
+	Use:   "loadtest",
+	Short: "Vérifie sous charge l'absence d'erreurs « database is locked » sur SQLite.",
+	Long: `Cette commande démarre en mémoire le serveur complet (API, caches, écrivain de clics)
+sur une base SQLite temporaire, avec les réglages database et analytics de la configuration,
+puis envoie pendant --duration des redirections et des créations de liens concurrentes.
+
+Elle échoue si une requête aboutit à une erreur serveur, si un clic n'a pas pu être
+enregistré ou si le nombre de clics en base diffère du nombre de redirections.
+
+Exemples:
+  url-shortener loadtest
+  url-shortener loadtest --duration 30s --concurrency 64 --create-ratio 0.3`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd2.Cfg == nil {
+			log.Fatalln("❌ Configuration non initialisée.")
+		}
+		if loadConcurrencyFlag < 1 || loadLinksFlag < 1 || loadCreateRatioFlag < 0 || loadCreateRatioFlag > 1 {
+			log.Fatalln("❌ --concurrency et --links doivent être positifs, --create-ratio compris entre 0 et 1.")
+		}
+
+		dbFile := loadDBFlag
+		if dbFile == "" {
+			dir, err := os.MkdirTemp("", "urlshortener-loadtest-")
+			if err != nil {
+				log.Fatalf("❌ %v", err)
+			}
+			defer os.RemoveAll(dir)
+			dbFile = filepath.Join(dir, "loadtest.db")
+		}
+
+		// Destinations locales : la récupération des métadonnées fait partie de la charge.
+		destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "<html><head><title>Page %s</title></head><body></body></html>", r.URL.Path)
+		}))
+		defer destination.Close()
+
+		// Configuration du serveur, limitée à SQLite, au cache local et sans filtrage des destinations
+		cfg := *cmd2.Cfg
+		cfg.Database.Driver, cfg.Database.DSN, cfg.Database.Name = storage.DriverSQLite, "", dbFile
+		cfg.Cache.Backend = "memory"
+		cfg.Logging.AccessLog = false
+		cfg.Policy.AllowDomains, cfg.Policy.DenyDomains, cfg.Policy.BlocklistFile = nil, nil, ""
+		cfg.Policy.BlockPrivateIPs = false
+
+		db, err := storage.OpenDB(&cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec connexion DB : %v", err)
+		}
+		// Les requêtes lentes sont attendues sous charge, seules les erreurs sont journalisées.
+		db.Logger = logger.Default.LogMode(logger.Error)
+		runner, err := migrations.NewRunner(db)
+		if err == nil {
+			_, err = runner.Up()
+		}
+		if err != nil {
+			log.Fatalf("❌ Erreur migration : %v", err)
+		}
+
+		st, err := storage.Open(&cfg, db, storage.Options{LocalCache: true})
+		if err != nil {
+			log.Fatalf("❌ Échec initialisation du stockage : %v", err)
+		}
+		defer st.Close()
+		destinationPolicy, err := policy.NewFromConfig(&cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec chargement politique de destinations : %v", err)
+		}
+		serviceConfig, err := services.LinkServiceConfigFromConfig(&cfg, destinationPolicy, st)
+		if err != nil {
+			log.Fatalf("❌ Configuration de génération des codes invalide : %v", err)
+		}
+		linkService := services.NewLinkService(st.Links(), st.Campaigns(), serviceConfig)
+		campaignService := services.NewCampaignService(st.Campaigns())
+
+		api.ClickEventsChannel = make(chan models.ClickEvent, cfg.Analytics.BufferSize)
+		flushInterval := time.Duration(cfg.Analytics.FlushIntervalMs) * time.Millisecond
+		clickWriter := workers.StartClickWriter(api.ClickEventsChannel, st.Clicks(), nil, cfg.Analytics.BatchSize, flushInterval)
+
+		gin.SetMode(gin.ReleaseMode)
+		router := gin.New()
+		api.SetupRoutes(router, linkService, campaignService, monitor.NewUrlMonitor(st.Links(), destinationPolicy, time.Hour),
+			health.NewChecker(time.Second), &cfg)
+		server := httptest.NewServer(router)
+
+		fmt.Printf("⏳ Base %s (journal %s, synchronous %s, busy timeout %d ms), %d client(s) pendant %v...\n",
+			dbFile, cfg.Database.SQLiteJournalMode, cfg.Database.SQLiteSynchronous, cfg.Database.SQLiteBusyTimeoutMs,
+			loadConcurrencyFlag, loadDurationFlag)
+
+		// Les journaux par requête sont masqués pendant la charge, les erreurs sont comptées.
+		log.SetOutput(io.Discard)
+		load := newLoadRun(server.URL, destination.URL, cfg.Redirect.StatusCode)
+		for i := 0; i < loadLinksFlag; i++ {
+			load.create()
+		}
+		load.run(loadConcurrencyFlag, loadDurationFlag, loadCreateRatioFlag)
+
+		// Arrêt dans l'ordre : plus de requêtes, plus de métadonnées, puis derniers clics.
+		server.Close()
+		linkService.WaitBackgroundTasks()
+		close(api.ClickEventsChannel)
+		clickWriter.Wait()
+		log.SetOutput(os.Stderr)
+
+		var storedClicks int64
+		if err := db.Model(&models.Click{}).Count(&storedClicks).Error; err != nil {
+			log.Fatalf("❌ Erreur comptage des clics : %v", err)
+		}
+		written, failed := clickWriter.Stats()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "OPÉRATION\tREQUÊTES\tERREURS\tREQ/S\tP50\tP99\tMAX")
+		load.redirects.report(w, "redirection", loadDurationFlag)
+		load.creations.report(w, "création", loadDurationFlag)
+		w.Flush()
+		fmt.Printf("📊 Clics : %d redirection(s) réussie(s), %d enregistré(s) en base, %d en échec.\n",
+			load.redirects.succeeded(), storedClicks, failed)
+
+		failures := load.redirects.errors + load.creations.errors + int(failed)
+		if failures > 0 || written != uint64(storedClicks) || storedClicks != int64(load.redirects.succeeded()) {
+			for _, msg := range load.errorSamples {
+				fmt.Printf("⚠️  %s\n", msg)
+			}
+			if storedClicks < int64(load.redirects.succeeded()) {
+				fmt.Println("⚠️  Clics manquants : buffer analytics.buffer_size saturé ou écriture en échec.")
+			}
+			fmt.Println("❌ Test de charge en échec.")
+			os.Exit(1)
+		}
+		fmt.Println("✅ Aucune erreur : pas de verrou bloquant ni de clic perdu.")
+	},
+}
+
+// loadRun est l'état partagé d'un test de charge.
+type loadRun struct {
+	baseURL        string
+	destinationURL string
+	redirectStatus int
+	client         *http.Client
+
+	mu           sync.Mutex
+	codes        []string
+	redirects    loadStats
+	creations    loadStats
+	errorSamples []string
+}
+
+func newLoadRun(baseURL, destinationURL string, redirectStatus int) *loadRun {
+	return &loadRun{
+		baseURL:        baseURL,
+		destinationURL: destinationURL,
+		redirectStatus: redirectStatus,
+		client: &http.Client{
+			Timeout:       30 * time.Second,
+			Transport:     &http.Transport{MaxIdleConnsPerHost: 256},
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+func (l *loadRun) run(concurrency int, duration time.Duration, createRatio float64) {
+	deadline := time.Now().Add(duration)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				if rand.Float64() < createRatio {
+					l.create()
+				} else {
+					l.redirect()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (l *loadRun) create() {
+	body, _ := json.Marshal(map[string]string{"long_url": fmt.Sprintf("%s/page/%d", l.destinationURL, rand.Int())})
+	start := time.Now()
+	resp, err := l.client.Post(l.baseURL+"/api/v1/links", "application/json", bytes.NewReader(body))
+	elapsed := time.Since(start)
+	if err != nil {
+		l.record(&l.creations, elapsed, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	var created struct {
+		ShortCode string `json:"short_code"`
+	}
+	if resp.StatusCode != http.StatusCreated || json.NewDecoder(resp.Body).Decode(&created) != nil {
+		l.record(&l.creations, elapsed, fmt.Sprintf("création : HTTP %d", resp.StatusCode))
+		return
+	}
+	l.record(&l.creations, elapsed, "")
+
+	l.mu.Lock()
+	l.codes = append(l.codes, created.ShortCode)
+	l.mu.Unlock()
+}
+
+func (l *loadRun) redirect() {
+	l.mu.Lock()
+	code := l.codes[rand.IntN(len(l.codes))]
+	l.mu.Unlock()
+
+	start := time.Now()
+	resp, err := l.client.Get(l.baseURL + "/" + code)
+	elapsed := time.Since(start)
+	if err != nil {
+		l.record(&l.redirects, elapsed, err.Error())
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != l.redirectStatus {
+		l.record(&l.redirects, elapsed, fmt.Sprintf("redirection %s : HTTP %d", code, resp.StatusCode))
+		return
+	}
+	l.record(&l.redirects, elapsed, "")
+}
+
+// record comptabilise une requête, en erreur si errMsg n'est pas vide.
+func (l *loadRun) record(stats *loadStats, elapsed time.Duration, errMsg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats.latencies = append(stats.latencies, elapsed)
+	if errMsg != "" {
+		stats.errors++
+		if len(l.errorSamples) < 10 {
+			l.errorSamples = append(l.errorSamples, errMsg)
+		}
+	}
+}
+
+// loadStats regroupe les mesures d'un type d'opération.
+type loadStats struct {
+	latencies []time.Duration
+	errors    int
+}
+
+func (s *loadStats) succeeded() int {
+	return len(s.latencies) - s.errors
+}
+
+func (s *loadStats) report(w io.Writer, name string, duration time.Duration) {
+	if len(s.latencies) == 0 {
+		fmt.Fprintf(w, "%s\t0\t0\t-\t-\t-\t-\n", name)
+		return
+	}
+	sorted := slices.Clone(s.latencies)
+	slices.Sort(sorted)
+	percentile := func(p float64) time.Duration {
+		return sorted[int(float64(len(sorted)-1)*p)].Round(time.Microsecond)
+	}
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.0f\t%v\t%v\t%v\n", name, len(sorted), s.errors,
+		float64(len(sorted))/duration.Seconds(), percentile(0.5), percentile(0.99), sorted[len(sorted)-1].Round(time.Microsecond))
+}
+
+func init() {
+	LoadTestCmd.Flags().DurationVar(&loadDurationFlag, "duration", 10*time.Second, "Durée de la charge")
+	LoadTestCmd.Flags().IntVar(&loadConcurrencyFlag, "concurrency", 32, "Nombre de clients simultanés")
+	LoadTestCmd.Flags().IntVar(&loadLinksFlag, "links", 50, "Nombre de liens créés avant la charge")
+	LoadTestCmd.Flags().Float64Var(&loadCreateRatioFlag, "create-ratio", 0.1, "Proportion de créations parmi les requêtes")
+	LoadTestCmd.Flags().StringVar(&loadDBFlag, "db", "", "Fichier SQLite à utiliser (défaut : fichier temporaire supprimé ensuite)")
+	cmd2.RootCmd.AddCommand(LoadTestCmd)
+}
@@ -1,47 +1,230 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
 
 	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
-	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+var (
+	migrateStepsFlag int  // --steps : nombre de migrations annulées par 'migrate down'
+	migrateForceFlag bool // --force : autorise l'annulation de migrations qui suppriment des données
+)
+
 var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
-	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
-basées sur les modèles Go.`,
+	Long: `Cette commande se connecte à la base de données configurée (SQLite, PostgreSQL ou MySQL)
+et applique les migrations versionnées embarquées dans le binaire. Les versions appliquées
+sont enregistrées dans la table 'schema_migrations'. Sans sous-commande, équivaut à 'migrate up'.
+
+Le serveur n'applique aucune migration : il refuse de démarrer tant que la base
+n'est pas exactement à la version attendue.
+
+Annuler une migration qui supprime des tables ou des colonnes (dont 'migrate to 0')
+exige --force : les données concernées sont perdues.
+
+Exemples:
+  url-shortener migrate up
+  url-shortener migrate status
+  url-shortener migrate down --steps 2 --force
+  url-shortener migrate to 1 --force`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMigrations(func(runner *migrations.Runner) ([]migrations.Migration, error) {
+			return runner.Up()
+		})
+	},
+}
+
+var MigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Applique toutes les migrations en attente.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMigrations(func(runner *migrations.Runner) ([]migrations.Migration, error) {
+			return runner.Up()
+		})
+	},
+}
+
+var MigrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Annule les dernières migrations appliquées (une par défaut).",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if migrateStepsFlag < 1 {
+			log.Fatalln("❌ --steps doit être au moins 1.")
+		}
+		runMigrations(func(runner *migrations.Runner) ([]migrations.Migration, error) {
+			target, err := runner.DownTarget(migrateStepsFlag)
+			if err != nil {
+				return nil, err
+			}
+			return migrateTo(runner, target)
+		})
+	},
+}
+
+var MigrateToCmd = &cobra.Command{
+	Use:   "to <version>",
+	Short: "Applique ou annule les migrations pour atteindre la version donnée (0 vide la base).",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < 0 {
+			log.Fatalf("❌ Version invalide : %q.", args[0])
+		}
+		runMigrations(func(runner *migrations.Runner) ([]migrations.Migration, error) {
+			return migrateTo(runner, version)
+		})
+	},
+}
+
+var MigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Affiche l'état de chaque migration.",
+	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		cfg := cmd2.Cfg
-		if cfg == nil {
-			log.Fatalln("❌ Configuration non initialisée.")
+		withMigrationRunner(func(_ *gorm.DB, runner *migrations.Runner) {
+			statuses, err := runner.Status()
+			if err != nil {
+				log.Fatalf("❌ Erreur lecture des migrations : %v", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNOM\tÉTAT\tAPPLIQUÉE LE")
+			for _, status := range statuses {
+				state, appliedAt := "en attente", "-"
+				if status.Applied {
+					state, appliedAt = "appliquée", status.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				if status.Unknown {
+					state = "inconnue de ce binaire"
+				}
+				fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+			}
+			w.Flush()
+
+			if err := runner.Check(); err != nil {
+				fmt.Printf("⚠️  %v\n", err)
+				return
+			}
+			fmt.Printf("✅ Schéma à jour (version %d).\n", migrations.Latest())
+		})
+	},
+}
+
+// migrateTo amène la base à version, en refusant sans --force d'annuler une
+// migration qui supprime des données.
+func migrateTo(runner *migrations.Runner, version int) ([]migrations.Migration, error) {
+	done, err := runner.Migrate(version, migrateForceFlag)
+	var dataLoss *migrations.DataLossError
+	if errors.As(err, &dataLoss) {
+		fmt.Println("⚠️  Ces migrations suppriment des tables ou des colonnes, et leurs données :")
+		for _, m := range dataLoss.Migrations {
+			fmt.Printf("   %04d_%s\n", m.Version, m.Name)
 		}
+		log.Fatalln("❌ Annulation refusée : sauvegardez la base (commande backup) puis relancez avec --force.")
+	}
+	return done, err
+}
+
+// withMigrationRunner ouvre la base configurée et passe son Runner à fn.
+func withMigrationRunner(fn func(db *gorm.DB, runner *migrations.Runner)) {
+	cfg := cmd2.Cfg
+	if cfg == nil {
+		log.Fatalln("❌ Configuration non initialisée.")
+	}
+
+	defer lockDatabase(cfg)()
+
+	db, err := storage.OpenDB(cfg)
+	if err != nil {
+		log.Fatalf("❌ Échec connexion DB : %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("❌ Échec récupération connexion SQL : %v", err)
+	}
+	defer sqlDB.Close()
+
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		log.Fatalf("❌ Erreur migration : %v", err)
+	}
+	fn(db, runner)
+}
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+// runMigrations exécute apply puis, si la base est à jour, calcule les données
+// dérivées que les migrations ne peuvent pas produire seules.
+func runMigrations(apply func(runner *migrations.Runner) ([]migrations.Migration, error)) {
+	withMigrationRunner(func(db *gorm.DB, runner *migrations.Runner) {
+		done, err := apply(runner)
+		for _, m := range done {
+			fmt.Printf("   %04d_%s\n", m.Version, m.Name)
+		}
 		if err != nil {
-			log.Fatalf("❌ Échec connexion DB : %v", err)
+			log.Fatalf("❌ Erreur migration : %v", err)
 		}
 
-		sqlDB, err := db.DB()
+		current, err := runner.Current()
 		if err != nil {
-			log.Fatalf("❌ Échec récupération connexion SQL : %v", err)
+			log.Fatalf("❌ Erreur lecture de la version du schéma : %v", err)
+		}
+		if len(done) == 0 {
+			fmt.Printf("✅ Aucune migration à exécuter (version %d).\n", current)
+		} else {
+			fmt.Printf("✅ %d migration(s) exécutée(s), schéma en version %d.\n", len(done), current)
 		}
-		defer sqlDB.Close()
 
-		if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
-			log.Fatalf("❌ Erreur migration : %v", err)
+		if runner.Check() != nil {
+			return
+		}
+		// Empreintes canoniques : elles dépendent de la configuration (paramètres de suivi ignorés)
+		linkService := services.NewLinkService(repository.NewGormLinkRepository(db),
+			repository.NewGormCampaignRepository(db), services.LinkServiceConfig{Canonical: services.CanonicalOptionsFromConfig(cmd2.Cfg)})
+		updated, err := linkService.BackfillCanonicalHashes()
+		if err != nil {
+			log.Fatalf("❌ Erreur calcul des empreintes canoniques : %v", err)
+		}
+		if updated > 0 {
+			fmt.Printf("✅ Empreinte canonique calculée pour %d lien(s) existant(s).\n", updated)
 		}
 
-		fmt.Println("✅ Migrations de la base de données exécutées avec succès.")
-	},
+		// Agrégats des clics enregistrés avant leur introduction
+		rollups := repository.NewGormRollupRepository(db)
+		needsRebuild, err := rollups.NeedsRebuild()
+		if err != nil {
+			log.Fatalf("❌ Erreur lecture des agrégats de clics : %v", err)
+		}
+		if needsRebuild {
+			clicks, err := rollups.Rebuild()
+			if err != nil {
+				log.Fatalf("❌ Erreur calcul des agrégats de clics : %v", err)
+			}
+			fmt.Printf("✅ Agrégats calculés pour %d clic(s) existant(s).\n", clicks)
+		}
+	})
 }
 
 func init() {
+	MigrateDownCmd.Flags().IntVar(&migrateStepsFlag, "steps", 1, "Nombre de migrations à annuler")
+	for _, cmd := range []*cobra.Command{MigrateDownCmd, MigrateToCmd} {
+		cmd.Flags().BoolVar(&migrateForceFlag, "force", false, "Autorise l'annulation de migrations qui suppriment des données")
+	}
+	MigrateCmd.AddCommand(MigrateUpCmd, MigrateDownCmd, MigrateToCmd, MigrateStatusCmd)
 	cmd2.RootCmd.AddCommand(MigrateCmd)
 }
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/backup"
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var restoreVerifyOnlyFlag bool
+
+var RestoreCmd = &cobra.Command{
+	Use:   "restore <fichier>",
+	Short: "Restaure la base SQLite depuis une sauvegarde vérifiée.",
+	Long: `Cette commande vérifie la sauvegarde (somme de contrôle SHA-256 si le fichier .sha256
+est présent, intégrité SQLite, version du schéma connue de ce binaire), puis remplace la base
+configurée. La base remplacée est conservée sous le nom <base>.before-restore-<date>.
+
+La restauration est refusée tant qu'un serveur ou une commande qui écrit (create, import,
+migrate, rollup) utilise la base. Si la sauvegarde précède des
+migrations plus récentes, exécutez ensuite 'migrate up'.
+
+Exemples:
+  url-shortener restore backups/urlshortener-20260101T030000Z.db.gz
+  url-shortener restore --verify-only backups/urlshortener-20260101T030000Z.db.gz`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalln("❌ Configuration non initialisée.")
+		}
+		path := args[0]
+
+		if restoreVerifyOnlyFlag {
+			v, err := backup.Verify(path)
+			if err != nil {
+				log.Fatalf("❌ Sauvegarde invalide : %v", err)
+			}
+			printVerification(v)
+			fmt.Println("✅ Sauvegarde valide.")
+			return
+		}
+
+		dbFile, err := storage.SQLiteFile(cfg)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+
+		// Verrou exclusif, incompatible avec celui que tient chaque serveur ou commande d'écriture
+		lock, err := storage.LockSQLite(dbFile, true)
+		if errors.Is(err, storage.ErrDatabaseInUse) {
+			log.Fatalf("❌ Restauration refusée : %s est utilisée par un serveur ou une commande en cours, arrêtez-les d'abord.", dbFile)
+		}
+		if err != nil {
+			log.Fatalf("❌ Verrouillage de la base impossible : %v", err)
+		}
+		defer lock.Close()
+
+		previous, v, err := backup.Restore(path, dbFile)
+		if err != nil {
+			log.Fatalf("❌ Échec de la restauration : %v", err)
+		}
+		printVerification(v)
+		fmt.Printf("✅ Base %s restaurée depuis %s.\n", dbFile, path)
+		if previous != "" {
+			fmt.Printf("   Ancienne base conservée : %s\n", previous)
+		}
+		if v.SchemaVersion < migrations.Latest() {
+			fmt.Printf("⚠️  Schéma en version %d : exécutez 'migrate up' avant de relancer le serveur.\n", v.SchemaVersion)
+		}
+	},
+}
+
+// lockDatabase prend, comme le serveur, le verrou partagé de la base SQLite
+// configurée : 'restore' refuse de la remplacer tant que la commande écrit.
+// Sans effet pour les autres pilotes. Le verrou est libéré par unlock.
+func lockDatabase(cfg *config.Config) (unlock func()) {
+	dbFile, err := storage.SQLiteFile(cfg)
+	if err != nil {
+		return func() {}
+	}
+	lock, err := storage.LockSQLite(dbFile, false)
+	if errors.Is(err, storage.ErrDatabaseInUse) {
+		log.Fatalf("❌ %s est en cours de restauration, réessayez ensuite.", dbFile)
+	}
+	if err != nil {
+		log.Fatalf("❌ Verrouillage de la base impossible : %v", err)
+	}
+	return func() { lock.Close() }
+}
+
+func printVerification(v *backup.Verification) {
+	if v.ChecksumVerified {
+		fmt.Println("✅ Somme de contrôle vérifiée.")
+	} else {
+		fmt.Println("⚠️  Aucun fichier .sha256 : somme de contrôle non vérifiée.")
+	}
+	fmt.Printf("✅ Intégrité SQLite vérifiée, schéma en version %d.\n", v.SchemaVersion)
+}
+
+func init() {
+	RestoreCmd.Flags().BoolVar(&restoreVerifyOnlyFlag, "verify-only", false, "Vérifie la sauvegarde sans restaurer")
+	cmd2.RootCmd.AddCommand(RestoreCmd)
+}
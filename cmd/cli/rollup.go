@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var RollupCmd = &cobra.Command{
+	Use:   "rollup",
+	Short: "Gère les agrégats horaires et journaliers des clics.",
+	Long: `Les statistiques des liens sont servies depuis des agrégats de clics par heure et par jour,
+par domaine d'origine, pays et type d'appareil, tenus à jour à chaque enregistrement de clics.`,
+}
+
+var RollupRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Recalcule tous les agrégats depuis les clics enregistrés.",
+	Long: `Cette commande efface les agrégats de clics et les recalcule depuis la table 'clicks',
+par exemple après une correction manuelle des clics ou une modification de la détection
+des types d'appareil. Le calcul s'exécute lien par lien, chacun dans une courte
+transaction : les statistiques de chaque lien restent cohérentes, et un serveur en
+cours d'exécution continue d'enregistrer les clics pendant l'opération.
+
+Exemple:
+  url-shortener rollup rebuild`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := cmd2.Cfg
+		if cfg == nil {
+			log.Fatalln("❌ Configuration non initialisée.")
+		}
+
+		defer lockDatabase(cfg)()
+
+		db, err := storage.OpenDB(cfg)
+		if err != nil {
+			log.Fatalf("❌ Échec connexion DB : %v", err)
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("❌ Échec récupération connexion SQL : %v", err)
+		}
+		defer sqlDB.Close()
+
+		start := time.Now()
+		clicks, err := repository.NewGormRollupRepository(db).Rebuild()
+		if err != nil {
+			log.Fatalf("❌ Erreur calcul des agrégats : %v", err)
+		}
+		fmt.Printf("✅ Agrégats recalculés depuis %d clic(s) en %v.\n", clicks, time.Since(start).Round(time.Millisecond))
+	},
+}
+
+func init() {
+	RollupCmd.AddCommand(RollupRebuildCmd)
+	cmd2.RootCmd.AddCommand(RollupCmd)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -8,8 +9,8 @@ import (
 	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
 	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
 	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -34,7 +35,7 @@ Exemple:
 			log.Fatalln("❌ Configuration non initialisée.")
 		}
 
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		db, err := storage.OpenDB(cfg)
 		if err != nil {
 			log.Fatalf("❌ Échec connexion DB : %v", err)
 		}
@@ -47,9 +48,10 @@ Exemple:
 
 		linkRepo := repository.NewGormLinkRepository(db)
 		// clickRepo := repository.NewGormClickRepository(db)
-		linkService := services.NewLinkService(linkRepo)
+		campaignRepo := repository.NewGormCampaignRepository(db)
+		linkService := services.NewLinkService(linkRepo, campaignRepo, services.LinkServiceConfig{})
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		link, totalClicks, err := linkService.GetLinkStats(context.Background(), shortCodeFlag)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				fmt.Printf("❌ Aucun lien trouvé pour le code : %s\n", shortCodeFlag)
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"github.com/Julien-Somasundaram/urlshortener/internal/config"
@@ -43,6 +43,6 @@ func initConfig() {
 	var err error
 	Cfg, err = config.LoadConfig()
 	if err != nil {
-		log.Printf("⚠️  Attention: problème lors du chargement de la configuration: %v. Utilisation des valeurs par défaut.", err)
+		slog.Warn("Problème lors du chargement de la configuration, utilisation des valeurs par défaut", "error", err)
 	}
 }
@@ -1,9 +1,10 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,15 +13,19 @@ import (
 
 	cmd2 "github.com/Julien-Somasundaram/urlshortener/cmd"
 	"github.com/Julien-Somasundaram/urlshortener/internal/api"
+	"github.com/Julien-Somasundaram/urlshortener/internal/backup"
+	"github.com/Julien-Somasundaram/urlshortener/internal/health"
+	"github.com/Julien-Somasundaram/urlshortener/internal/logging"
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
 	"github.com/Julien-Somasundaram/urlshortener/internal/models"
 	"github.com/Julien-Somasundaram/urlshortener/internal/monitor"
-	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
 	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/Julien-Somasundaram/urlshortener/internal/tracing"
 	"github.com/Julien-Somasundaram/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 var RunServerCmd = &cobra.Command{
@@ -32,45 +37,142 @@ puis lance le serveur HTTP.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg := cmd2.Cfg
 		if cfg == nil {
-			log.Fatalln("❌ Configuration non initialisée.")
+			logging.Fatal("Configuration non initialisée")
+		}
+		logging.Setup(cfg)
+
+		// Traces OpenTelemetry (exportées à l'arrêt pour les derniers spans)
+		shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+		if err != nil {
+			logging.Fatal("Échec initialisation des traces", "error", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				slog.Warn("Export des derniers spans impossible", "error", err)
+			}
+		}()
+		if cfg.Tracing.Exporter != tracing.ExporterNone {
+			slog.Info("Traces activées", "exporter", cfg.Tracing.Exporter, "sample_ratio", cfg.Tracing.SampleRatio)
+		}
+
+		// Verrou partagé sur la base SQLite : 'restore' refuse de la remplacer tant qu'il est tenu
+		if dbFile, err := storage.SQLiteFile(cfg); err == nil {
+			lock, err := storage.LockSQLite(dbFile, false)
+			if err != nil {
+				logging.Fatal("Verrouillage de la base impossible (restauration en cours ?)", "error", err)
+			}
+			defer lock.Close()
 		}
 
 		// Connexion DB
-		db, err := gorm.Open(sqlite.Open(cfg.Database.Name), &gorm.Config{})
+		db, err := storage.OpenDB(cfg)
 		if err != nil {
-			log.Fatalf("❌ Échec connexion DB : %v", err)
+			logging.Fatal("Échec connexion DB", "error", err)
 		}
 
-		// Migration
-		if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
-			log.Fatalf("❌ Échec migration DB : %v", err)
+		// Schéma : les migrations sont appliquées par la commande 'migrate', jamais au démarrage
+		runner, err := migrations.NewRunner(db)
+		if err != nil {
+			logging.Fatal("Échec lecture de la version du schéma", "error", err)
 		}
+		if err := runner.Check(); err != nil {
+			logging.Fatal("Démarrage refusé", "error", err)
+		}
+		slog.Info("Schéma de la base à jour", "version", migrations.Latest())
 
-		// Repositories
-		linkRepo := repository.NewGormLinkRepository(db)
-		clickRepo := repository.NewGormClickRepository(db)
-		log.Println("✅ Repositories initialisés.")
+		// Repositories (résolution des codes courts via les caches configurés)
+		st, err := storage.Open(cfg, db, storage.Options{LocalCache: true})
+		if err != nil {
+			logging.Fatal("Échec initialisation du stockage", "error", err)
+		}
+		defer st.Close()
+		slog.Info("Cache des liens initialisé", "cache", st.Describe())
+		linkRepo := st.Links()
+		clickRepo := st.Clicks()
+		campaignRepo := st.Campaigns()
+		slog.Info("Repositories initialisés")
+
+		// Politique de destinations (liste de blocage rechargée sur SIGHUP)
+		destinationPolicy, err := policy.NewFromConfig(cfg)
+		if err != nil {
+			logging.Fatal("Échec chargement politique de destinations", "error", err)
+		}
+		go func() {
+			reload := make(chan os.Signal, 1)
+			signal.Notify(reload, syscall.SIGHUP)
+			for range reload {
+				if err := destinationPolicy.Reload(); err != nil {
+					slog.Warn("Rechargement de la liste de blocage impossible", "error", err)
+				}
+			}
+		}()
 
 		// Services
-		linkService := services.NewLinkService(linkRepo)
-		log.Println("✅ Services métiers initialisés.")
+		serviceConfig, err := services.LinkServiceConfigFromConfig(cfg, destinationPolicy, st)
+		if err != nil {
+			logging.Fatal("Configuration de génération des codes invalide", "error", err)
+		}
+		serviceConfig.Counter = st.Counter
+		linkService := services.NewLinkService(linkRepo, campaignRepo, serviceConfig)
+		campaignService := services.NewCampaignService(campaignRepo)
+		if updated, err := linkService.BackfillCanonicalHashes(); err != nil {
+			slog.Warn("Calcul des empreintes canoniques incomplet", "error", err)
+		} else if updated > 0 {
+			slog.Info("Empreintes canoniques calculées", "links", updated)
+		}
+		if codes, err := linkService.ReservedCodeLinks(context.Background()); err != nil {
+			slog.Warn("Vérification des codes réservés impossible", "error", err)
+		} else if len(codes) > 0 {
+			slog.Warn("Liens masqués par une route du serveur, à renommer", "short_codes", codes)
+		}
+		slog.Info("Services métiers initialisés")
 
 		// Channel + Workers
 		api.ClickEventsChannel = make(chan models.ClickEvent, cfg.Analytics.BufferSize)
-		workers.StartClickWorkers(1, api.ClickEventsChannel, clickRepo)
-		log.Printf("✅ Channel d'événements de clic initialisé avec un buffer de %d. %d worker(s) de clics démarré(s).",
-			cfg.Analytics.BufferSize, 1)
+		flushInterval := time.Duration(cfg.Analytics.FlushIntervalMs) * time.Millisecond
+		clickWriter := workers.StartClickWriter(api.ClickEventsChannel, clickRepo, st.Counter, cfg.Analytics.BatchSize, flushInterval)
+		slog.Info("Channel d'événements de clic initialisé", "buffer_size", cfg.Analytics.BufferSize)
 
 		// Moniteur
 		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval)
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, destinationPolicy, monitorInterval)
 		go urlMonitor.Start()
-		log.Printf("🛰️  Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
+
+		// Vérifications de santé : un moniteur est en retard après deux périodes sans vérification
+		checker := health.NewChecker(time.Duration(cfg.Health.TimeoutMs) * time.Millisecond)
+		checker.Live("click_writer", health.Heartbeat(clickWriter.LastHeartbeat, time.Duration(cfg.Health.WorkerHeartbeatSeconds)*time.Second))
+		// Un moniteur en retard ne gêne pas les redirections : signalé sans rendre le service indisponible.
+		checker.Info("monitor", health.Heartbeat(urlMonitor.LastRun, 2*urlMonitor.Interval()))
+		checker.Ready("database", health.Database(db))
+		checker.Ready("schema", health.Schema(runner))
+		checker.Ready("click_queue", health.Queue(func() (int, int) {
+			return len(api.ClickEventsChannel), cap(api.ClickEventsChannel)
+		}, cfg.Health.QueueSaturation))
+
+		// Sauvegardes planifiées (SQLite uniquement)
+		if cfg.Backup.IntervalMinutes > 0 {
+			if db.Dialector.Name() == storage.DriverSQLite {
+				backupInterval := time.Duration(cfg.Backup.IntervalMinutes) * time.Minute
+				go backup.NewScheduler(db, backup.OptionsFromConfig(cfg), backupInterval).Start()
+			} else {
+				slog.Warn("Sauvegardes planifiées ignorées", "error", backup.ErrUnsupportedDriver)
+			}
+		}
 
 		// Routes
-		router := gin.Default()
-		api.SetupRoutes(router, linkService, cfg)
-		log.Println("✅ Routes API configurées.")
+		// Le journal d'accès de Gin est remplacé par celui des redirections (logging.access_log).
+		if cfg.Logging.Level != "debug" {
+			gin.SetMode(gin.ReleaseMode)
+		}
+		router := gin.New()
+		router.Use(gin.Recovery())
+		if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+			logging.Fatal("Proxys de confiance invalides", "error", err)
+		}
+		api.SetupRoutes(router, linkService, campaignService, urlMonitor, checker, cfg)
+		slog.Info("Routes API configurées")
 
 		serverAddr := fmt.Sprintf(":%d", cfg.Server.Port)
 		srv := &http.Server{
@@ -80,9 +182,9 @@ puis lance le serveur HTTP.`,
 
 		// Serveur Gin dans une goroutine
 		go func() {
-			log.Printf("🚀 Serveur lancé sur %s ...", serverAddr)
+			slog.Info("Serveur lancé", "addr", serverAddr)
 			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-				log.Fatalf("❌ Erreur serveur HTTP : %v", err)
+				logging.Fatal("Erreur serveur HTTP", "error", err)
 			}
 		}()
 
@@ -90,9 +192,13 @@ puis lance le serveur HTTP.`,
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		<-quit
-		log.Println("🛑 Signal d'arrêt reçu. Arrêt du serveur...")
+		slog.Info("Signal d'arrêt reçu, arrêt du serveur")
+		if st.LocalCache != nil {
+			stats := st.LocalCache.Stats()
+			slog.Info("Statistiques du cache des liens", "hits", stats.Hits, "misses", stats.Misses, "entries", stats.Entries)
+		}
 		time.Sleep(5 * time.Second)
-		log.Println("✅ Serveur arrêté proprement.")
+		slog.Info("Serveur arrêté proprement")
 	},
 }
 
@@ -0,0 +1,88 @@
+// Package analytics déduit des requêtes de redirection les dimensions des
+// statistiques de clics : domaine d'origine, pays et type d'appareil.
+package analytics
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Types d'appareil.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// ReferrerHost retourne le domaine de l'en-tête Referer, sans le chemin ni la
+// requête qui peuvent contenir des données personnelles.
+func ReferrerHost(referer string) string {
+	u, err := url.Parse(referer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if len(host) > 255 {
+		return ""
+	}
+	return host
+}
+
+// maxUserAgentLength est la taille de la colonne clicks.user_agent, en caractères.
+const maxUserAgentLength = 255
+
+// UserAgent tronque l'en-tête User-Agent à la taille de sa colonne : MySQL et
+// PostgreSQL refusent une valeur trop longue là où SQLite l'accepte.
+func UserAgent(value string) string {
+	if len(value) <= maxUserAgentLength {
+		return value
+	}
+	runes := []rune(value)
+	if len(runes) <= maxUserAgentLength {
+		return value
+	}
+	return string(runes[:maxUserAgentLength])
+}
+
+// Country normalise le code pays transmis par le proxy (ex : en-tête
+// CF-IPCountry). Seuls les codes de deux lettres sont retenus, hors XX (inconnu).
+func Country(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) != 2 || value == "XX" {
+		return ""
+	}
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+	}
+	return value
+}
+
+// Device classe l'appareil d'après son User-Agent.
+func Device(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case ua == "":
+		return DeviceUnknown
+	case containsAny(ua, "bot", "crawler", "spider", "slurp", "curl/", "wget/", "python-requests", "go-http-client", "headless"):
+		return DeviceBot
+	case containsAny(ua, "ipad", "tablet", "kindle", "silk/", "playbook") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return DeviceTablet
+	case containsAny(ua, "mobi", "iphone", "ipod", "android", "windows phone", "blackberry", "opera mini"):
+		return DeviceMobile
+	}
+	return DeviceDesktop
+}
+
+func containsAny(s string, substrings ...string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
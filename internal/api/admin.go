@@ -0,0 +1,359 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/logging"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/monitor"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// adminFiles contient l'interface d'administration, servie sous /admin.
+//
+//go:embed admin
+var adminFiles embed.FS
+
+const adminSessionCookie = "admin_session"
+
+// adminContentSecurityPolicy n'autorise que les ressources embarquées, plus
+// les favicons des destinations.
+const adminContentSecurityPolicy = "default-src 'self'; img-src 'self' data: https:; frame-ancestors 'none'; base-uri 'none'; form-action 'self'"
+
+// AdminAuth authentifie l'interface d'administration : identifiant et mot de
+// passe de la configuration, puis cookie de session signé.
+type AdminAuth struct {
+	username     string
+	passwordHash string
+	secret       []byte
+	ttl          time.Duration
+	secure       bool
+}
+
+// NewAdminAuth crée l'authentification de l'administration à partir de la configuration.
+func NewAdminAuth(cfg *config.Config) *AdminAuth {
+	secret := []byte(cfg.Admin.SessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			logging.Fatal("Échec génération de la clé de session d'administration", "error", err)
+		}
+	}
+
+	return &AdminAuth{
+		username:     cfg.Admin.Username,
+		passwordHash: cfg.Admin.PasswordHash,
+		secret:       secret,
+		ttl:          time.Duration(cfg.Admin.SessionTTLMinutes) * time.Minute,
+		secure:       strings.HasPrefix(cfg.Server.BaseURL, "https://"),
+	}
+}
+
+// Enabled indique si un mot de passe d'administration est configuré.
+func (a *AdminAuth) Enabled() bool {
+	return a.passwordHash != ""
+}
+
+// checkCredentials vérifie l'identifiant et le mot de passe soumis.
+func (a *AdminAuth) checkCredentials(username, password string) bool {
+	// Le hash est toujours vérifié : la durée ne révèle pas si l'identifiant est correct.
+	passwordOK := bcrypt.CompareHashAndPassword([]byte(a.passwordHash), []byte(password)) == nil
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(a.username)) == 1
+	return usernameOK && passwordOK
+}
+
+// sign lie la session à l'identifiant, au mot de passe actuel et à l'expiration :
+// changer le mot de passe ferme toutes les sessions.
+func (a *AdminAuth) sign(expires string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(a.username + "|" + a.passwordHash + "|" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isLoggedIn indique si la requête porte un cookie de session valide.
+func (a *AdminAuth) isLoggedIn(c *gin.Context) bool {
+	value, err := c.Cookie(adminSessionCookie)
+	if err != nil {
+		return false
+	}
+
+	expires, signature, found := strings.Cut(value, ".")
+	if !found {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(a.sign(expires)))
+}
+
+// setSession pose le cookie de session, limité à /admin. SameSite=Strict : il
+// n'accompagne aucune requête émise depuis un autre site.
+func (a *AdminAuth) setSession(c *gin.Context) {
+	expires := strconv.FormatInt(time.Now().Add(a.ttl).Unix(), 10)
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(adminSessionCookie, expires+"."+a.sign(expires), int(a.ttl.Seconds()), "/admin", "", a.secure, true)
+}
+
+func (a *AdminAuth) clearSession(c *gin.Context) {
+	c.SetSameSite(http.SameSiteStrictMode)
+	c.SetCookie(adminSessionCookie, "", -1, "/admin", "", a.secure, true)
+}
+
+// RequireAdmin refuse les requêtes sans session valide. Les modifications
+// doivent être envoyées en JSON, ce qu'un formulaire d'un autre site ne peut pas faire.
+func (a *AdminAuth) RequireAdmin(c *gin.Context) {
+	c.Header("Cache-Control", "private, no-store")
+	if !a.isLoggedIn(c) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentification requise"})
+		return
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+	default:
+		if mediaType, _, _ := mime.ParseMediaType(c.ContentType()); mediaType != "application/json" && c.Request.ContentLength != 0 {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Corps JSON attendu"})
+			return
+		}
+	}
+	c.Next()
+}
+
+// setupAdminRoutes sert l'interface d'administration et son API sous /admin.
+func setupAdminRoutes(router *gin.Engine, linkService *services.LinkService, unlocker *Unlocker, urlMonitor *monitor.UrlMonitor, cfg *config.Config) {
+	auth := NewAdminAuth(cfg)
+	if !auth.Enabled() {
+		slog.Info("Interface d'administration désactivée (admin.password_hash vide)")
+		return
+	}
+
+	assets, err := fs.Sub(adminFiles, "admin/assets")
+	if err != nil {
+		logging.Fatal("Fichiers de l'interface d'administration introuvables", "error", err)
+	}
+	router.GET("/admin", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/admin/")
+	})
+	router.GET("/admin/", AdminIndexHandler)
+	router.StaticFS("/admin/assets", http.FS(assets))
+
+	session := router.Group("/admin/api/session")
+	{
+		session.GET("", auth.RequireAdmin, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"username": auth.username})
+		})
+		session.POST("", AdminLoginHandler(auth, unlocker))
+		session.DELETE("", func(c *gin.Context) {
+			auth.clearSession(c)
+			c.Status(http.StatusNoContent)
+		})
+	}
+
+	admin := router.Group("/admin/api", auth.RequireAdmin)
+	{
+		admin.GET("/links", AdminListLinksHandler(linkService, urlMonitor, cfg))
+		admin.POST("/links", CreateShortLinkHandler(linkService, cfg))
+		admin.GET("/links/:shortCode", AdminGetLinkHandler(linkService, urlMonitor, cfg))
+		admin.PATCH("/links/:shortCode", AdminUpdateLinkHandler(linkService, urlMonitor, cfg))
+		admin.DELETE("/links/:shortCode", AdminDeleteLinkHandler(linkService))
+		admin.GET("/links/:shortCode/stats/timeseries", GetLinkTimeSeriesHandler(linkService))
+		admin.GET("/links/:shortCode/stats/breakdown/:dimension", GetLinkBreakdownHandler(linkService))
+	}
+}
+
+// AdminIndexHandler sert la page de l'interface d'administration.
+func AdminIndexHandler(c *gin.Context) {
+	page, err := adminFiles.ReadFile("admin/index.html")
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Page d'administration introuvable", "error", err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Header("Content-Security-Policy", adminContentSecurityPolicy)
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
+
+// AdminLoginRequest est le corps de POST /admin/api/session.
+type AdminLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+// AdminLoginHandler ouvre une session d'administration. Les essais sont
+// limités par IP comme les déverrouillages de liens.
+func AdminLoginHandler(auth *AdminAuth, unlocker *Unlocker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req AdminLoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Identifiant et mot de passe requis"})
+			return
+		}
+
+		// « admin » est un alias réservé : la clé ne peut pas désigner un lien.
+		key := c.ClientIP() + "|admin"
+		if wait := unlocker.blockedFor(key); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Trop d'essais. Réessayez plus tard."})
+			return
+		}
+
+		if !auth.checkCredentials(req.Username, req.Password) {
+			unlocker.recordFailure(key)
+			slog.WarnContext(c.Request.Context(), "Connexion à l'administration refusée", "username", req.Username, "client_ip", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Identifiant ou mot de passe incorrect"})
+			return
+		}
+
+		unlocker.recordSuccess(key)
+		auth.setSession(c)
+		slog.InfoContext(c.Request.Context(), "Connexion à l'administration", "username", req.Username, "client_ip", c.ClientIP())
+		c.JSON(http.StatusOK, gin.H{"username": auth.username})
+	}
+}
+
+// adminLinkResponse complète la description d'un lien pour l'administration.
+func adminLinkResponse(link *models.Link, urlMonitor *monitor.UrlMonitor, cfg *config.Config) gin.H {
+	response := linkResponse(link, cfg)
+	response["created_at"] = link.CreatedAt
+	response["disabled"] = link.Disabled
+	response["disabled_reason"] = link.DisabledReason
+	response["health"] = nil
+	if urlMonitor != nil {
+		if health, ok := urlMonitor.Health(link.ID); ok {
+			response["health"] = gin.H{"accessible": health.Accessible, "checked_at": health.CheckedAt}
+		}
+	}
+	return response
+}
+
+// AdminListLinksHandler recherche les liens (paramètres q, page et per_page).
+func AdminListLinksHandler(linkService *services.LinkService, urlMonitor *monitor.UrlMonitor, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètre page invalide"})
+			return
+		}
+		perPage, err := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètre per_page invalide"})
+			return
+		}
+
+		result, err := linkService.SearchLinks(strings.TrimSpace(c.Query("q")), page, perPage)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "Erreur recherche des liens", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			return
+		}
+
+		links := make([]gin.H, 0, len(result.Links))
+		for i := range result.Links {
+			links = append(links, adminLinkResponse(&result.Links[i], urlMonitor, cfg))
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"links":    links,
+			"total":    result.Total,
+			"page":     result.Page,
+			"per_page": result.PerPage,
+		})
+	}
+}
+
+// AdminGetLinkHandler retourne un lien et son nombre total de clics.
+func AdminGetLinkHandler(linkService *services.LinkService, urlMonitor *monitor.UrlMonitor, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, totalClicks, err := linkService.GetLinkStats(c.Request.Context(), c.Param("shortCode"))
+		if err != nil {
+			adminLinkError(c, err)
+			return
+		}
+		response := adminLinkResponse(link, urlMonitor, cfg)
+		response["total_clicks"] = totalClicks
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UpdateLinkRequest est le corps de PATCH /admin/api/links/:shortCode. Les
+// champs absents sont laissés inchangés.
+type UpdateLinkRequest struct {
+	LongURL        *string `json:"long_url" binding:"omitempty,url"`
+	ForwardQuery   *string `json:"forward_query"`
+	ForwardPath    *bool   `json:"forward_path"`
+	RedirectStatus *int    `json:"redirect_status"` // 0 = valeur globale
+	Disabled       *bool   `json:"disabled"`
+}
+
+// AdminUpdateLinkHandler modifie la destination ou le comportement d'un lien.
+func AdminUpdateLinkHandler(linkService *services.LinkService, urlMonitor *monitor.UrlMonitor, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Requête invalide : URL ou paramètre incorrect"})
+			return
+		}
+
+		link, err := linkService.UpdateLink(c.Request.Context(), c.Param("shortCode"), services.LinkUpdate{
+			LongURL:        req.LongURL,
+			ForwardQuery:   req.ForwardQuery,
+			ForwardPath:    req.ForwardPath,
+			RedirectStatus: req.RedirectStatus,
+			Disabled:       req.Disabled,
+		})
+		if err != nil {
+			adminLinkError(c, err)
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "Lien modifié depuis l'administration", "short_code", link.ShortCode)
+		c.JSON(http.StatusOK, adminLinkResponse(link, urlMonitor, cfg))
+	}
+}
+
+// AdminDeleteLinkHandler supprime un lien et ses clics.
+func AdminDeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := linkService.DeleteLink(c.Request.Context(), c.Param("shortCode")); err != nil {
+			adminLinkError(c, err)
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "Lien supprimé depuis l'administration", "short_code", c.Param("shortCode"))
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// adminLinkError répond à une erreur de lecture ou de modification d'un lien.
+func adminLinkError(c *gin.Context, err error) {
+	var violation *policy.Violation
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+	case errors.Is(err, services.ErrInvalidLinkUpdate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &violation):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Destination refusée : " + violation.Reason})
+	default:
+		slog.ErrorContext(c.Request.Context(), "Erreur administration du lien", "short_code", c.Param("shortCode"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+	}
+}
@@ -0,0 +1,313 @@
+// Interface d'administration : appels JSON à /admin/api, rendu sans dépendance.
+"use strict";
+
+const state = { page: 1, perPage: 20, query: "", selected: null };
+
+const $ = (selector) => document.querySelector(selector);
+
+// api appelle l'API d'administration et retourne le corps JSON. Une erreur
+// porte le message renvoyé par le serveur ; une session expirée ramène à la connexion.
+async function api(method, path, body) {
+  const options = { method, headers: {}, credentials: "same-origin" };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const response = await fetch(path, options);
+  const data = response.status === 204 ? null : await response.json().catch(() => null);
+  if (response.status === 401 && path !== "/admin/api/session") {
+    showLogin();
+  }
+  if (!response.ok) {
+    const error = new Error((data && data.error) || `Erreur ${response.status}`);
+    error.status = response.status;
+    throw error;
+  }
+  return data;
+}
+
+function el(tag, attributes = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attributes)) {
+    if (name === "class") node.className = value;
+    else node.setAttribute(name, value);
+  }
+  node.append(...children);
+  return node;
+}
+
+function svg(tag, attributes = {}) {
+  const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  for (const [name, value] of Object.entries(attributes)) node.setAttribute(name, value);
+  return node;
+}
+
+const dateFormat = new Intl.DateTimeFormat("fr-FR", { dateStyle: "short", timeStyle: "short" });
+const formatDate = (value) => dateFormat.format(new Date(value));
+
+// healthBadge résume l'état d'un lien : désactivé, ou dernier résultat du moniteur.
+function healthBadge(link) {
+  if (link.disabled) return el("span", { class: "badge fail", title: link.disabled_reason }, "Désactivé");
+  if (!link.health) return el("span", { class: "badge unknown" }, "Non vérifié");
+  const title = "Vérifié le " + formatDate(link.health.checked_at);
+  return link.health.accessible
+    ? el("span", { class: "badge ok", title }, "Accessible")
+    : el("span", { class: "badge fail", title }, "Inaccessible");
+}
+
+// ───── Session ─────
+
+function showLogin() {
+  $("#app").hidden = true;
+  $("#logout").hidden = true;
+  $("#current-user").textContent = "";
+  $("#login").hidden = false;
+}
+
+function showApp(username) {
+  $("#login").hidden = true;
+  $("#app").hidden = false;
+  $("#logout").hidden = false;
+  $("#current-user").textContent = username;
+  loadLinks();
+}
+
+$("#login-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = event.target;
+  const error = form.querySelector(".error");
+  error.textContent = "";
+  try {
+    const session = await api("POST", "/admin/api/session", {
+      username: form.username.value,
+      password: form.password.value,
+    });
+    form.reset();
+    showApp(session.username);
+  } catch (err) {
+    error.textContent = err.message;
+  }
+});
+
+$("#logout").addEventListener("click", async () => {
+  await api("DELETE", "/admin/api/session").catch(() => {});
+  showLogin();
+});
+
+// ───── État du service ─────
+
+async function loadServiceStatus() {
+  const badge = $("#service-status");
+  try {
+    const response = await fetch("/health/ready");
+    const report = await response.json();
+    const failing = Object.entries(report.components || {})
+      .filter(([, component]) => component.status !== "ok")
+      .map(([name, component]) => `${name} : ${component.error}`);
+    badge.className = "badge " + (report.status === "ok" ? "ok" : "fail");
+    badge.textContent = report.status === "ok" ? "Service opérationnel" : "Service dégradé";
+    badge.title = failing.join("\n");
+  } catch {
+    badge.className = "badge fail";
+    badge.textContent = "Service injoignable";
+  }
+  badge.hidden = false;
+}
+
+// ───── Liste des liens ─────
+
+async function loadLinks() {
+  const params = new URLSearchParams({ q: state.query, page: state.page, per_page: state.perPage });
+  const tbody = $("#links");
+  try {
+    const result = await api("GET", "/admin/api/links?" + params);
+    tbody.replaceChildren(...result.links.map(linkRow));
+    if (result.links.length === 0) {
+      tbody.append(el("tr", {}, el("td", { colspan: 4 }, "Aucun lien.")));
+    }
+    const pages = Math.max(1, Math.ceil(result.total / result.per_page));
+    $("#page-info").textContent = `Page ${result.page} sur ${pages} (${result.total} lien(s))`;
+    $("#prev-page").disabled = result.page <= 1;
+    $("#next-page").disabled = result.page >= pages;
+  } catch (err) {
+    tbody.replaceChildren(el("tr", {}, el("td", { colspan: 4, class: "error" }, err.message)));
+  }
+}
+
+function linkRow(link) {
+  const destination = el("td", { class: "url" }, link.long_url);
+  if (link.title) destination.append(el("div", { class: "title" }, link.title));
+  const row = el("tr", {}, el("td", {}, link.short_code), destination, el("td", {}, healthBadge(link)),
+    el("td", {}, formatDate(link.created_at)));
+  if (link.short_code === state.selected) row.classList.add("selected");
+  row.addEventListener("click", () => selectLink(link.short_code));
+  return row;
+}
+
+$("#search-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  state.query = event.target.q.value.trim();
+  state.page = 1;
+  loadLinks();
+});
+$("#prev-page").addEventListener("click", () => { state.page--; loadLinks(); });
+$("#next-page").addEventListener("click", () => { state.page++; loadLinks(); });
+
+// ───── Création ─────
+
+$("#create-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = event.target;
+  const error = form.parentElement.querySelector(".error");
+  const result = $("#create-result");
+  error.textContent = "";
+  result.textContent = "";
+  const body = { long_url: form.long_url.value };
+  if (form.alias.value) body.alias = form.alias.value;
+  if (form.redirect_status.value !== "0") body.redirect_status = Number(form.redirect_status.value);
+  try {
+    const link = await api("POST", "/admin/api/links", body);
+    result.textContent = (link.reused ? "Lien existant : " : "Lien créé : ") + link.full_short_url;
+    form.reset();
+    state.page = 1;
+    await loadLinks();
+    selectLink(link.short_code);
+  } catch (err) {
+    error.textContent = err.message;
+  }
+});
+
+// ───── Détail d'un lien ─────
+
+async function selectLink(shortCode) {
+  state.selected = shortCode;
+  for (const row of $("#links").rows) {
+    row.classList.toggle("selected", row.cells[0].textContent === shortCode);
+  }
+  try {
+    const link = await api("GET", "/admin/api/links/" + encodeURIComponent(shortCode));
+    renderDetail(link);
+    await loadStats();
+  } catch (err) {
+    if (err.status === 404) {
+      $("#detail").hidden = true;
+      loadLinks();
+    }
+  }
+}
+
+function renderDetail(link) {
+  $("#detail").hidden = false;
+  const anchor = $("#detail-link");
+  anchor.textContent = link.short_code;
+  anchor.href = link.full_short_url;
+
+  const summary = $("#detail-summary");
+  summary.replaceChildren(`${link.total_clicks} clic(s) au total · `, healthBadge(link));
+  if (link.protected) summary.append(" · protégé par mot de passe");
+
+  const form = $("#edit-form");
+  form.long_url.value = link.long_url;
+  form.redirect_status.value = String(link.redirect_status);
+  form.forward_query.value = link.forward_query;
+  form.forward_path.checked = link.forward_path;
+  form.disabled.checked = link.disabled;
+  form.querySelector(".error").textContent = "";
+}
+
+async function loadStats() {
+  const code = encodeURIComponent(state.selected);
+  const granularity = $("#granularity").value;
+  const query = "?granularity=" + granularity;
+  const series = await api("GET", `/admin/api/links/${code}/stats/timeseries${query}`);
+  renderChart(series.points, granularity);
+  for (const dimension of ["referrer", "country", "device"]) {
+    const breakdown = await api("GET", `/admin/api/links/${code}/stats/breakdown/${dimension}${query}&limit=5`);
+    const list = $("#breakdown-" + dimension);
+    list.replaceChildren(...breakdown.values.map((value) =>
+      el("li", {}, `${value.value || "(inconnu)"} — ${value.clicks}`)));
+    if (breakdown.values.length === 0) list.append(el("li", {}, "Aucun clic"));
+  }
+}
+
+$("#granularity").addEventListener("change", () => loadStats().catch(() => {}));
+
+// renderChart dessine un histogramme SVG des clics par période.
+function renderChart(points, granularity) {
+  const width = 600, height = 180, bottom = 20, top = 12;
+  const peak = Math.max(1, ...points.map((point) => point.clicks));
+  const step = width / Math.max(1, points.length);
+  const chart = svg("svg", { viewBox: `0 0 ${width} ${height}`, role: "img", "aria-label": "Clics par période" });
+
+  points.forEach((point, i) => {
+    const barHeight = (point.clicks / peak) * (height - bottom - top);
+    const bar = svg("rect", {
+      x: i * step + 1, y: height - bottom - barHeight,
+      width: Math.max(1, step - 2), height: barHeight,
+    });
+    const title = svg("title");
+    title.textContent = `${formatDate(point.bucket)} : ${point.clicks} clic(s)`;
+    bar.append(title);
+    chart.append(bar);
+  });
+
+  // Quelques étiquettes de dates et le maximum.
+  const labelEvery = Math.ceil(points.length / 6);
+  points.forEach((point, i) => {
+    if (i % labelEvery !== 0) return;
+    const date = new Date(point.bucket);
+    const label = svg("text", { x: i * step, y: height - 5 });
+    label.textContent = granularity === "hour"
+      ? date.toLocaleTimeString("fr-FR", { hour: "2-digit", minute: "2-digit" })
+      : date.toLocaleDateString("fr-FR", { day: "2-digit", month: "2-digit" });
+    chart.append(label);
+  });
+  const max = svg("text", { x: 0, y: 10 });
+  max.textContent = `max ${peak}`;
+  chart.append(max);
+
+  $("#chart").replaceChildren(chart);
+}
+
+// ───── Modification et suppression ─────
+
+$("#edit-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = event.target;
+  const error = form.querySelector(".error");
+  error.textContent = "";
+  try {
+    await api("PATCH", "/admin/api/links/" + encodeURIComponent(state.selected), {
+      long_url: form.long_url.value,
+      redirect_status: Number(form.redirect_status.value),
+      forward_query: form.forward_query.value,
+      forward_path: form.forward_path.checked,
+      disabled: form.disabled.checked,
+    });
+    await loadLinks();
+    await selectLink(state.selected);
+  } catch (err) {
+    error.textContent = err.message;
+  }
+});
+
+$("#delete-link").addEventListener("click", async () => {
+  if (!confirm(`Supprimer le lien ${state.selected} et tous ses clics ? Cette action est définitive.`)) return;
+  const error = $("#edit-form .error");
+  try {
+    await api("DELETE", "/admin/api/links/" + encodeURIComponent(state.selected));
+    state.selected = null;
+    $("#detail").hidden = true;
+    loadLinks();
+  } catch (err) {
+    error.textContent = err.message;
+  }
+});
+
+// ───── Démarrage ─────
+
+loadServiceStatus();
+setInterval(loadServiceStatus, 30000);
+api("GET", "/admin/api/session")
+  .then((session) => showApp(session.username))
+  .catch(showLogin);
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+)
+
+func TestAdminLinkResponseWithoutMonitor(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Le moniteur est désactivé : l'état de la destination est inconnu.
+	response := adminLinkResponse(&models.Link{ID: 1, ShortCode: "abc123", LongURL: "https://example.com"}, nil, cfg)
+	if health, ok := response["health"]; !ok || health != nil {
+		t.Errorf("health = %v, attendu null", health)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/metrics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Représente le corps d'une requête POST /links/bulk
+type BulkCreateLinksRequest struct {
+	Links []CreateLinkRequest `json:"links" binding:"required"`
+}
+
+// CreateBulkLinksHandler crée plusieurs liens dans une même transaction et
+// renvoie un résultat par élément, dans l'ordre de la requête.
+func CreateBulkLinksHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BulkCreateLinksRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Requête invalide : liste 'links' manquante"})
+			return
+		}
+		if len(req.Links) == 0 || len(req.Links) > cfg.Server.BulkMaxItems {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Le lot doit contenir entre 1 et %d liens", cfg.Server.BulkMaxItems),
+			})
+			return
+		}
+
+		// Les éléments invalides sont écartés avant la transaction et signalés individuellement.
+		results := make([]gin.H, len(req.Links))
+		var items []services.BulkLinkRequest
+		var positions []int
+		for i := range req.Links {
+			if err := binding.Validator.ValidateStruct(&req.Links[i]); err != nil {
+				results[i] = gin.H{"index": i, "error": "URL manquante ou paramètre incorrect"}
+				continue
+			}
+			items = append(items, services.BulkLinkRequest{
+				LongURL: req.Links[i].LongURL,
+				Options: req.Links[i].linkOptions(),
+			})
+			positions = append(positions, i)
+		}
+
+		created, failed := 0, len(req.Links)-len(items)
+		if len(items) > 0 {
+			bulkResults, err := linkService.CreateLinksBulk(items)
+			if err != nil {
+				slog.ErrorContext(c.Request.Context(), "Erreur création en lot", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+				return
+			}
+
+			for k, result := range bulkResults {
+				i := positions[k]
+				if result.Err != nil {
+					_, message := createLinkError(c.Request.Context(), result.Err)
+					results[i] = gin.H{"index": i, "error": message}
+					failed++
+					continue
+				}
+				response := linkResponse(result.Link, cfg)
+				response["index"] = i
+				response["reused"] = result.Reused
+				results[i] = response
+				created++
+				if !result.Reused {
+					metrics.LinksCreated.WithLabelValues("bulk").Inc()
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"created": created,
+			"failed":  failed,
+			"results": results,
+		})
+	}
+}
@@ -1,49 +1,135 @@
 package api
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/Julien-Somasundaram/urlshortener/internal/analytics"
 	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/health"
+	"github.com/Julien-Somasundaram/urlshortener/internal/logging"
+	"github.com/Julien-Somasundaram/urlshortener/internal/metrics"
 	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/monitor"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
 	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/shortcode"
+	"github.com/Julien-Somasundaram/urlshortener/internal/tracing"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
 var ClickEventsChannel chan models.ClickEvent // TODO 1: Channel global
 
+// Nombre de clics en attente dans ClickEventsChannel, lu à chaque collecte.
+var _ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+	Namespace: "urlshortener",
+	Name:      "click_events_queue_depth",
+	Help:      "Clics en attente d'écriture dans le channel des clics.",
+}, func() float64 { return float64(len(ClickEventsChannel)) })
+
 // SetupRoutes configure toutes les routes de l'API
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService, cfg *config.Config) {
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, campaignService *services.CampaignService, urlMonitor *monitor.UrlMonitor, checker *health.Checker, cfg *config.Config) {
 	if ClickEventsChannel == nil {
 		ClickEventsChannel = make(chan models.ClickEvent, cfg.Analytics.BufferSize)
 	}
 
-	// Route de health check
-	router.GET("/health", HealthCheckHandler)
+	router.Use(RequestIDMiddleware(), TracingMiddleware())
+
+	// Routes de health check (/health est l'ancienne route : répond 200 tant que le processus tourne, comme /health/live)
+	router.GET("/health", LivenessHandler(checker))
+	router.GET("/health/live", LivenessHandler(checker))
+	router.GET("/health/ready", ReadinessHandler(checker))
+
+	// Métriques Prometheus
+	if cfg.Metrics.Enabled {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
 
 	// Routes API REST
 	api := router.Group("/api/v1")
 	{
 		api.POST("/links", CreateShortLinkHandler(linkService, cfg))
+		api.POST("/links/bulk", CreateBulkLinksHandler(linkService, cfg))
 		api.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+		api.GET("/links/:shortCode/stats/timeseries", GetLinkTimeSeriesHandler(linkService))
+		api.GET("/links/:shortCode/stats/breakdown/:dimension", GetLinkBreakdownHandler(linkService))
+		api.GET("/campaigns/:id/stats", GetCampaignStatsHandler(campaignService))
+		api.GET("/openapi.json", OpenAPIHandler(cfg))
+	}
+
+	unlocker := NewUnlocker(cfg)
+
+	// Interface d'administration
+	setupAdminRoutes(router, linkService, unlocker, urlMonitor, cfg)
+
+	// La spécification OpenAPI doit décrire exactement les routes /api/v1.
+	problems, err := CheckOpenAPI(router.Routes())
+	if err != nil {
+		logging.Fatal("Lecture de la spécification OpenAPI impossible", "error", err)
+	}
+	for _, problem := range problems {
+		slog.Warn("Spécification OpenAPI désynchronisée", "route", problem)
 	}
 
-	// Redirection
-	router.GET("/:shortCode", RedirectHandler(linkService, cfg))
+	redirects := router.Group("/", countRedirect)
+	if cfg.Logging.AccessLog {
+		redirects.Use(accessLog)
+	}
+
+	// Redirection (le joker transmet les segments de chemin après le code court)
+	for _, path := range []string{"/:shortCode", "/:shortCode/*path"} {
+		redirects.GET(path, RedirectHandler(linkService, unlocker, urlMonitor, cfg))
+		redirects.HEAD(path, RedirectHandler(linkService, unlocker, urlMonitor, cfg))
+		redirects.POST(path, UnlockHandler(linkService, unlocker, cfg))
+		router.OPTIONS(path, RedirectOptionsHandler)
+		for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			router.Handle(method, path, RedirectMethodNotAllowedHandler)
+		}
+	}
 }
 
 // ───── HANDLERS ─────────────────────────────
 
-func HealthCheckHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+// countRedirect compte les réponses des routes de redirection par code HTTP.
+func countRedirect(c *gin.Context) {
+	c.Next()
+	metrics.Redirects.WithLabelValues(strconv.Itoa(c.Writer.Status())).Inc()
 }
 
 // Représente le corps d'une requête POST /links
 type CreateLinkRequest struct {
 	LongURL string `json:"long_url" binding:"required,url"`
+	Alias   string `json:"alias"` // Code court personnalisé
+
+	ForwardQuery string `json:"forward_query" binding:"omitempty,oneof=merge override"` // Transfert des paramètres de requête
+	ForwardPath  bool   `json:"forward_path"`                                           // Transfert des segments de chemin
+
+	// Paramètres de campagne ajoutés à l'URL de destination
+	UTMSource   string `json:"utm_source"`
+	UTMMedium   string `json:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign"`
+	UTMTerm     string `json:"utm_term"`
+	UTMContent  string `json:"utm_content"`
+
+	// Réponse de redirection propre au lien (sinon configuration globale)
+	RedirectStatus int  `json:"redirect_status" binding:"omitempty,oneof=301 302 307 308"`
+	CacheMaxAge    *int `json:"cache_max_age" binding:"omitempty,min=0"`
+
+	Password string `json:"password" binding:"omitempty,max=72"` // Mot de passe demandé avant la redirection
+
+	ReuseExisting bool `json:"reuse_existing"` // Retourne le lien existant vers la même destination
+
+	CodeStrategy string `json:"code_strategy" binding:"omitempty,oneof=random sequential words unambiguous"`
 }
 
 func CreateShortLinkHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
@@ -51,69 +137,235 @@ func CreateShortLinkHandler(linkService *services.LinkService, cfg *config.Confi
 		var req CreateLinkRequest
 
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "URL invalide ou manquante"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Requête invalide : URL manquante ou paramètre incorrect"})
 			return
 		}
 
-		link, err := linkService.CreateLink(req.LongURL)
+		link, reused, err := linkService.CreateLinkWithOptions(req.LongURL, req.linkOptions())
 		if err != nil {
-			log.Printf("Erreur création lien: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			status, message := createLinkError(c.Request.Context(), err)
+			c.JSON(status, gin.H{"error": message})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.ShortCode,
-			"long_url":       link.LongURL,
-			"full_short_url": cfg.Server.BaseURL + "/" + link.ShortCode,
-		})
+		status := http.StatusCreated
+		if reused {
+			status = http.StatusOK
+		} else {
+			metrics.LinksCreated.WithLabelValues("single").Inc()
+		}
+
+		response := linkResponse(link, cfg)
+		response["reused"] = reused
+		c.JSON(status, response)
 	}
 }
 
-func RedirectHandler(linkService *services.LinkService, cfg *config.Config) gin.HandlerFunc {
+// linkOptions convertit la requête en options de création du service.
+func (req *CreateLinkRequest) linkOptions() services.LinkOptions {
+	return services.LinkOptions{
+		Alias:        req.Alias,
+		ForwardQuery: req.ForwardQuery,
+		ForwardPath:  req.ForwardPath,
+		UTM: services.UTMParams{
+			Source:   req.UTMSource,
+			Medium:   req.UTMMedium,
+			Campaign: req.UTMCampaign,
+			Term:     req.UTMTerm,
+			Content:  req.UTMContent,
+		},
+		RedirectStatus: req.RedirectStatus,
+		CacheMaxAge:    req.CacheMaxAge,
+		Password:       req.Password,
+		ReuseExisting:  req.ReuseExisting,
+		CodeStrategy:   req.CodeStrategy,
+	}
+}
+
+// createLinkError associe une erreur de création au statut HTTP et au message renvoyés.
+func createLinkError(ctx context.Context, err error) (int, string) {
+	var violation *policy.Violation
+	switch {
+	case errors.As(err, &violation):
+		return http.StatusUnprocessableEntity, "Destination refusée : " + violation.Reason
+	case errors.Is(err, shortcode.ErrUnknownStrategy):
+		return http.StatusBadRequest, "Stratégie de génération de code indisponible"
+	case errors.Is(err, services.ErrInvalidAlias):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrAliasTaken):
+		return http.StatusConflict, "Alias déjà utilisé"
+	}
+	slog.ErrorContext(ctx, "Erreur création lien", "error", err)
+	return http.StatusInternalServerError, "Erreur serveur"
+}
+
+// linkResponse décrit un lien dans les réponses de l'API.
+func linkResponse(link *models.Link, cfg *config.Config) gin.H {
+	return gin.H{
+		"short_code":      link.ShortCode,
+		"campaign_id":     link.CampaignID,
+		"long_url":        link.LongURL,
+		"forward_query":   link.ForwardQuery,
+		"forward_path":    link.ForwardPath,
+		"redirect_status": link.RedirectStatus,
+		"cache_max_age":   link.CacheMaxAge,
+		"protected":       link.IsProtected(),
+		"title":           link.Title,
+		"description":     link.Description,
+		"favicon_url":     link.FaviconURL,
+		"image_url":       link.ImageURL,
+		"full_short_url":  cfg.Server.BaseURL + "/" + link.ShortCode,
+	}
+}
+
+func RedirectHandler(linkService *services.LinkService, unlocker *Unlocker, urlMonitor *monitor.UrlMonitor, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		shortCode := c.Param("shortCode")
+		shortCode, preview := previewRequest(c)
+		link, ok := findRedirectLink(c, linkService, shortCode)
+		if !ok {
+			return
+		}
 
-		link, err := linkService.GetLinkByShortCode(shortCode)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
-				return
-			}
-			log.Printf("Erreur récupération redirection: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+		// Lien protégé : formulaire de mot de passe tant qu'il n'a pas été déverrouillé.
+		if link.IsProtected() && !unlocker.IsUnlocked(c, link) {
+			renderUnlockForm(c, http.StatusOK, "")
 			return
 		}
 
+		if preview {
+			renderPreview(c, link, urlMonitor)
+			return
+		}
+
+		// Une requête HEAD (aperçu, robot) ne constitue pas un clic.
+		sendRedirect(c, linkService, link, cfg, c.Request.Method == http.MethodGet, 0)
+	}
+}
+
+// findRedirectLink récupère le lien désigné par le code court et répond
+// directement 404/500 en cas d'échec.
+func findRedirectLink(c *gin.Context, linkService *services.LinkService, shortCode string) (*models.Link, bool) {
+	link, err := linkService.GetLinkByShortCode(c.Request.Context(), shortCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+			return nil, false
+		}
+		slog.ErrorContext(c.Request.Context(), "Erreur récupération redirection", "short_code", shortCode, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+		return nil, false
+	}
+	if link.Disabled {
+		c.JSON(http.StatusGone, gin.H{"error": "Lien désactivé"})
+		return nil, false
+	}
+	return link, true
+}
+
+// sendRedirect construit la destination, publie éventuellement le clic et
+// envoie la redirection. Un status nul applique le code configuré pour le lien.
+func sendRedirect(c *gin.Context, linkService *services.LinkService, link *models.Link, cfg *config.Config, recordClick bool, status int) {
+	destination, err := services.ResolveDestination(link, c.Param("path"), c.Request.URL.Query())
+	if err != nil {
+		destinationError(c, link, err)
+		return
+	}
+
+	if recordClick {
+		// Span de publication, relié par un lien au span d'écriture du lot.
+		ctx, span := tracing.Tracer().Start(c.Request.Context(), "clicks publish",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(attribute.String("short_code", link.ShortCode)))
 		clickEvent := models.ClickEvent{
 			LinkID:    link.ID,
 			Timestamp: time.Now(),
-			UserAgent: c.Request.UserAgent(),
+			UserAgent: analytics.UserAgent(c.Request.UserAgent()),
 			IPAddress: c.ClientIP(),
+			Referrer:  analytics.ReferrerHost(c.Request.Referer()),
+			RequestID: logging.RequestID(ctx),
+		}
+		clickEvent.TraceID, clickEvent.SpanID = tracing.SpanIDs(span.SpanContext())
+		if cfg.Analytics.CountryHeader != "" {
+			clickEvent.Country = analytics.Country(c.GetHeader(cfg.Analytics.CountryHeader))
 		}
 
 		// Multiplexage non bloquant
 		select {
 		case ClickEventsChannel <- clickEvent:
+			metrics.ClickEventsEnqueued.Inc()
+			linkService.RecordClick(link.ID)
 		default:
-			log.Printf("⚠️  ClickEventsChannel is full, dropping click for %s", shortCode)
+			metrics.ClickEventsDropped.Inc()
+			span.SetStatus(codes.Error, "channel des clics plein")
+			slog.WarnContext(ctx, "Channel des clics plein, clic perdu", "short_code", link.ShortCode)
 		}
+		span.End()
+	}
 
-		c.Redirect(http.StatusFound, link.LongURL)
+	if status == 0 {
+		status = cfg.Redirect.StatusCode
+		if link.RedirectStatus != 0 {
+			status = link.RedirectStatus
+		}
+	}
+	maxAge := cfg.Redirect.CacheMaxAge
+	if link.CacheMaxAge != nil {
+		maxAge = *link.CacheMaxAge
 	}
+	// Une redirection protégée ne doit jamais être servie depuis un cache.
+	if link.IsProtected() {
+		maxAge = 0
+	}
+
+	setRedirectCacheHeaders(c, maxAge)
+	c.Redirect(status, destination)
+}
+
+// destinationError répond à une erreur de construction de la destination.
+func destinationError(c *gin.Context, link *models.Link, err error) {
+	if errors.Is(err, services.ErrInvalidForwardPath) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chemin invalide"})
+		return
+	}
+	slog.ErrorContext(c.Request.Context(), "Erreur construction destination", "short_code", link.ShortCode, "error", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+}
+
+// setRedirectCacheHeaders contrôle la mise en cache de la redirection par les
+// navigateurs et proxys. Sans cache, chaque visite repasse par le serveur et est comptée.
+func setRedirectCacheHeaders(c *gin.Context, maxAge int) {
+	if maxAge <= 0 {
+		c.Header("Cache-Control", "private, no-store, max-age=0")
+		c.Header("Expires", "0")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	c.Header("Expires", time.Now().Add(time.Duration(maxAge)*time.Second).UTC().Format(http.TimeFormat))
+}
+
+const redirectAllowedMethods = "GET, HEAD, POST, OPTIONS"
+
+func RedirectOptionsHandler(c *gin.Context) {
+	c.Header("Allow", redirectAllowedMethods)
+	c.Status(http.StatusNoContent)
+}
+
+func RedirectMethodNotAllowedHandler(c *gin.Context) {
+	c.Header("Allow", redirectAllowedMethods)
+	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Méthode non autorisée"})
 }
 
 func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		link, totalClicks, err := linkService.GetLinkStats(c.Request.Context(), shortCode)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
 				return
 			}
-			log.Printf("Erreur récupération stats: %v", err)
+			slog.ErrorContext(c.Request.Context(), "Erreur récupération stats", "short_code", shortCode, "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
 			return
 		}
@@ -121,7 +373,49 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{
 			"short_code":   link.ShortCode,
 			"long_url":     link.LongURL,
+			"title":        link.Title,
+			"description":  link.Description,
+			"favicon_url":  link.FaviconURL,
+			"image_url":    link.ImageURL,
+			"disabled":     link.Disabled,
 			"total_clicks": totalClicks,
 		})
 	}
 }
+
+func GetCampaignStatsHandler(campaignService *services.CampaignService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Identifiant de campagne invalide"})
+			return
+		}
+
+		stats, err := campaignService.GetCampaignStats(uint(id))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Campagne non trouvée"})
+				return
+			}
+			slog.ErrorContext(c.Request.Context(), "Erreur récupération stats campagne", "campaign_id", id, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			return
+		}
+
+		links := make([]gin.H, 0, len(stats.Links))
+		for _, link := range stats.Links {
+			links = append(links, gin.H{
+				"short_code":   link.ShortCode,
+				"long_url":     link.LongURL,
+				"total_clicks": link.TotalClicks,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"campaign_id":  stats.Campaign.ID,
+			"name":         stats.Campaign.Name,
+			"total_clicks": stats.TotalClicks,
+			"links":        links,
+		})
+	}
+}
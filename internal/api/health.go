@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+// LivenessHandler indique si le processus et ses workers tournent. En échec,
+// le service doit être redémarré.
+func LivenessHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		healthResponse(c, checker.Liveness(c.Request.Context()))
+	}
+}
+
+// ReadinessHandler indique si le service peut recevoir du trafic : base
+// joignable et à jour, channel des clics non saturé, écrivain de clics actif.
+// Le retard du moniteur est signalé (statut warn) sans rendre le service indisponible.
+func ReadinessHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		healthResponse(c, checker.Readiness(c.Request.Context()))
+	}
+}
+
+// healthResponse répond 503 si un composant est en échec, 200 sinon.
+func healthResponse(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/logging"
+	"github.com/Julien-Somasundaram/urlshortener/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader porte l'identifiant de requête, reçu d'un proxy ou généré.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern borne les identifiants acceptés d'un client ou d'un proxy.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestIDMiddleware attribue un identifiant à chaque requête, renvoyé dans
+// l'en-tête X-Request-ID et ajouté aux logs via le contexte de la requête.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// TracingMiddleware ouvre un span serveur par requête, rattaché à la trace
+// de l'appelant si l'en-tête traceparent est fourni.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(c.Request.URL.Path),
+				attribute.String("request_id", logging.RequestID(ctx)),
+			))
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// accessLog journalise chaque réponse des routes de redirection avec sa latence.
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	slog.InfoContext(c.Request.Context(), "Redirection",
+		"method", c.Request.Method,
+		"short_code", c.Param("shortCode"),
+		"status", c.Writer.Status(),
+		"latency", time.Since(start),
+		"client_ip", c.ClientIP(),
+		"user_agent", c.Request.UserAgent(),
+		"referrer", c.Request.Referer(),
+	)
+}
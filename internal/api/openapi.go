@@ -0,0 +1,76 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec décrit les routes /api/v1 (OpenAPI 3). Toute route ajoutée sous
+// /api/v1 doit y figurer : CheckOpenAPI le vérifie au démarrage du serveur.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// openAPIPathParam repère les paramètres de chemin OpenAPI ({shortCode}).
+var openAPIPathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// OpenAPIHandler sert la spécification de l'API, avec l'URL de base configurée
+// comme serveur.
+func OpenAPIHandler(cfg *config.Config) gin.HandlerFunc {
+	var spec map[string]any
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		panic(fmt.Sprintf("spécification OpenAPI invalide : %v", err))
+	}
+	spec["servers"] = []gin.H{{"url": cfg.Server.BaseURL}}
+
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	}
+}
+
+// CheckOpenAPI compare les routes /api/v1 du routeur aux opérations de la
+// spécification et retourne les écarts (« GET /api/v1/... »), triés.
+func CheckOpenAPI(routes gin.RoutesInfo) ([]string, error) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return nil, fmt.Errorf("spécification OpenAPI invalide : %w", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		ginPath := openAPIPathParam.ReplaceAllString(path, ":$1")
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+ginPath] = false
+		}
+	}
+
+	var problems []string
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/v1/") {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		if _, ok := documented[key]; !ok {
+			problems = append(problems, key+" : absente de la spécification")
+			continue
+		}
+		documented[key] = true
+	}
+	for key, routed := range documented {
+		if !routed {
+			problems = append(problems, key+" : documentée mais non servie")
+		}
+	}
+	slices.Sort(problems)
+	return problems, nil
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/health"
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/monitor"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"github.com/Julien-Somasundaram/urlshortener/internal/workers"
+	"github.com/Julien-Somasundaram/urlshortener/pkg/client"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRouter configure toutes les routes sur une base SQLite temporaire
+// migrée, avec l'écrivain de clics en marche.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Database.Name = filepath.Join(t.TempDir(), "api.db")
+	cfg.Logging.AccessLog = false
+
+	db, err := storage.OpenDB(cfg)
+	if err != nil {
+		t.Fatalf("connexion : %v", err)
+	}
+	db.Logger = logger.Discard
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("migrations : %v", err)
+	}
+
+	store := repository.NewGormStore(db)
+	serviceConfig, err := services.LinkServiceConfigFromConfig(cfg, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linkService := services.NewLinkService(store.Links(), store.Campaigns(), serviceConfig)
+	t.Cleanup(linkService.WaitBackgroundTasks)
+
+	events := make(chan models.ClickEvent, cfg.Analytics.BufferSize)
+	ClickEventsChannel = events
+	writer := workers.StartClickWriter(events, store.Clicks(), nil, 1, 10*time.Millisecond)
+	t.Cleanup(func() {
+		close(events)
+		writer.Wait()
+		ClickEventsChannel = nil
+	})
+
+	router := gin.New()
+	urlMonitor := monitor.NewUrlMonitor(store.Links(), nil, time.Hour)
+	SetupRoutes(router, linkService, services.NewCampaignService(store.Campaigns()), urlMonitor, health.NewChecker(time.Second), cfg)
+	return router
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	router := newTestRouter(t)
+
+	problems, err := CheckOpenAPI(router.Routes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Errorf("spécification désynchronisée : %s", problem)
+	}
+
+	// Une route non documentée doit être signalée.
+	router.GET("/api/v1/undocumented", func(c *gin.Context) {})
+	if problems, _ := CheckOpenAPI(router.Routes()); len(problems) != 1 || !strings.HasPrefix(problems[0], "GET /api/v1/undocumented") {
+		t.Errorf("écarts = %q, attendu la route non documentée", problems)
+	}
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	// Destinations locales : la récupération des métadonnées ne sort pas du test.
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><head><title>Destination</title></head></html>")
+	}))
+	defer destination.Close()
+	srv := httptest.NewServer(newTestRouter(t))
+	defer srv.Close()
+	recorder := &responseRecorder{next: srv.Client().Transport}
+	c, err := client.New(srv.URL, client.WithHTTPClient(&http.Client{Transport: recorder}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	link, err := c.CreateLink(ctx, client.CreateLinkRequest{
+		LongURL:      destination.URL + "/launch",
+		Alias:        "launch",
+		ForwardQuery: client.ForwardQueryMerge,
+		UTMCampaign:  "spring",
+	})
+	if err != nil {
+		t.Fatalf("création : %v", err)
+	}
+	recorder.assertMatches(t, link)
+	if link.ShortCode != "launch" || link.CampaignID == nil || link.ForwardQuery != client.ForwardQueryMerge {
+		t.Errorf("lien créé = %+v", link)
+	}
+
+	bulk, err := c.CreateLinksBulk(ctx, []client.CreateLinkRequest{
+		{LongURL: destination.URL + "/bulk"},
+		{LongURL: destination.URL + "/taken", Alias: "launch"},
+	})
+	if err != nil {
+		t.Fatalf("création en lot : %v", err)
+	}
+	recorder.assertMatches(t, bulk)
+	if bulk.Created != 1 || bulk.Failed != 1 || bulk.Results[1].Error == "" {
+		t.Errorf("création en lot = %+v", bulk)
+	}
+
+	// Un clic, attendu dans toutes les statistiques.
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(srv.URL + "/launch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("redirection : statut %d", resp.StatusCode)
+	}
+	var stats *client.LinkStats
+	deadline := time.Now().Add(2 * time.Second)
+	for stats == nil || stats.TotalClicks == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("clic non enregistré après 2s")
+		}
+		if stats, err = c.GetLinkStats(ctx, "launch"); err != nil {
+			t.Fatalf("statistiques : %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	recorder.assertMatches(t, stats)
+
+	series, err := c.GetLinkTimeSeries(ctx, "launch", client.StatsQuery{Granularity: client.GranularityHour})
+	if err != nil {
+		t.Fatalf("série : %v", err)
+	}
+	recorder.assertMatches(t, series)
+	if series.Total != 1 || len(series.Points) == 0 {
+		t.Errorf("série = %+v", series)
+	}
+
+	breakdown, err := c.GetLinkBreakdown(ctx, "launch", client.DimensionDevice, client.StatsQuery{}, 5)
+	if err != nil {
+		t.Fatalf("répartition : %v", err)
+	}
+	recorder.assertMatches(t, breakdown)
+	if len(breakdown.Values) != 1 || breakdown.Values[0].Clicks != 1 {
+		t.Errorf("répartition = %+v", breakdown)
+	}
+
+	campaign, err := c.GetCampaignStats(ctx, *link.CampaignID)
+	if err != nil {
+		t.Fatalf("campagne : %v", err)
+	}
+	recorder.assertMatches(t, campaign)
+	if campaign.Name != "spring" || len(campaign.Links) != 1 {
+		t.Errorf("campagne = %+v", campaign)
+	}
+
+	if _, err := c.GetLinkStats(ctx, "absent"); !client.IsNotFound(err) {
+		t.Errorf("code inconnu : erreur %v, attendu 404", err)
+	}
+}
+
+// responseRecorder conserve le corps de la dernière réponse reçue par le client.
+type responseRecorder struct {
+	next http.RoundTripper
+	body []byte
+}
+
+func (r *responseRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	r.body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(r.body))
+	return resp, err
+}
+
+// assertMatches vérifie que la dernière réponse et le type du client décrivent
+// les mêmes champs : aucun champ inconnu du client, aucun champ du client absent.
+func (r *responseRecorder) assertMatches(t *testing.T, decoded any) {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader(r.body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(reflect.New(reflect.TypeOf(decoded).Elem()).Interface()); err != nil {
+		t.Errorf("%T : %v", decoded, err)
+	}
+	var raw any
+	if err := json.Unmarshal(r.body, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range missingFields(reflect.TypeOf(decoded), raw, "") {
+		t.Errorf("%T : champ %s absent de la réponse", decoded, field)
+	}
+}
+
+// missingFields retourne les champs JSON de typ absents de raw, en descendant
+// dans les objets et les tableaux. Un champ d'élément de tableau est présent
+// s'il apparaît dans au moins un élément (champs omis quand ils sont vides).
+func missingFields(typ reflect.Type, raw any, path string) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch value := raw.(type) {
+	case []any:
+		if typ.Kind() != reflect.Slice || len(value) == 0 {
+			return nil
+		}
+		counts := make(map[string]int)
+		for _, element := range value {
+			for _, field := range missingFields(typ.Elem(), element, path+"[]") {
+				counts[field]++
+			}
+		}
+		var missing []string
+		for field, count := range counts {
+			if count == len(value) {
+				missing = append(missing, field)
+			}
+		}
+		return missing
+	case map[string]any:
+		if typ.Kind() != reflect.Struct {
+			return nil
+		}
+		var missing []string
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.Anonymous {
+				missing = append(missing, missingFields(field.Type, raw, path)...)
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			child, ok := value[name]
+			if !ok {
+				missing = append(missing, path+"."+name)
+				continue
+			}
+			missing = append(missing, missingFields(field.Type, child, path+"."+name)...)
+		}
+		return missing
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/metadata"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/monitor"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// previewSuffix ajouté au code court (ex: /abc123+) affiche l'aperçu au lieu de rediriger.
+const previewSuffix = "+"
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Aperçu du lien {{.ShortCode}}</title>
+</head>
+<body>
+<h1>Aperçu du lien {{.ShortCode}}</h1>
+<p>Ce lien court mène vers :</p>
+<p><strong>{{.Destination}}</strong></p>
+{{if .Meta}}
+{{if .Meta.ImageURL}}<p><img src="{{.Meta.ImageURL}}" alt="" style="max-width: 100%; max-height: 300px"></p>{{end}}
+{{if .Meta.Title}}<h2>{{if .Meta.FaviconURL}}<img src="{{.Meta.FaviconURL}}" alt="" width="16" height="16"> {{end}}{{.Meta.Title}}</h2>{{end}}
+{{if .Meta.Description}}<p>{{.Meta.Description}}</p>{{end}}
+{{end}}
+<ul>
+<li>Créé le : {{.CreatedAt.Format "02/01/2006 à 15:04"}}</li>
+<li>État de la destination : {{.Health}}</li>
+</ul>
+<p><a href="{{.ContinueURL}}" rel="noreferrer">Continuer vers la destination</a></p>
+</body>
+</html>
+`))
+
+// previewRequest extrait le code court et indique si l'aperçu est demandé,
+// par le suffixe « + » ou par le paramètre preview=1.
+func previewRequest(c *gin.Context) (string, bool) {
+	shortCode := c.Param("shortCode")
+	if trimmed, found := strings.CutSuffix(shortCode, previewSuffix); found {
+		return trimmed, true
+	}
+	return shortCode, c.Query("preview") == "1"
+}
+
+// withoutPreviewParam retire le paramètre preview de la requête, pour qu'il ne
+// soit ni transmis à la destination ni repris dans le lien de poursuite.
+func withoutPreviewParam(c *gin.Context) url.Values {
+	query := c.Request.URL.Query()
+	query.Del("preview")
+	return query
+}
+
+// formatHealth décrit l'état de la destination d'après le moniteur d'URLs.
+func formatHealth(urlMonitor *monitor.UrlMonitor, linkID uint) string {
+	if urlMonitor == nil {
+		return "non surveillé"
+	}
+	health, known := urlMonitor.Health(linkID)
+	if !known {
+		return "pas encore vérifié"
+	}
+	state := "accessible"
+	if !health.Accessible {
+		state = "inaccessible"
+	}
+	return state + " (vérifié le " + health.CheckedAt.Format("02/01/2006 à 15:04") + ")"
+}
+
+// renderPreview affiche la page intermédiaire d'un lien, sans enregistrer de clic.
+func renderPreview(c *gin.Context, link *models.Link, urlMonitor *monitor.UrlMonitor) {
+	query := withoutPreviewParam(c)
+	destination, err := services.ResolveDestination(link, c.Param("path"), query)
+	if err != nil {
+		destinationError(c, link, err)
+		return
+	}
+
+	continueURL := "/" + link.ShortCode + c.Param("path")
+	if encoded := query.Encode(); encoded != "" {
+		continueURL += "?" + encoded
+	}
+
+	// Métadonnées enregistrées uniquement : une visite anonyme ne déclenche
+	// jamais de requête sortante (récupération à la création et par le moniteur).
+	meta := &metadata.PageMetadata{
+		Title:       link.Title,
+		Description: link.Description,
+		FaviconURL:  link.FaviconURL,
+		ImageURL:    link.ImageURL,
+	}
+
+	c.Header("Cache-Control", "private, no-store, max-age=0")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := previewTemplate.Execute(c.Writer, gin.H{
+		"ShortCode":   link.ShortCode,
+		"Destination": destination,
+		"Meta":        meta,
+		"CreatedAt":   link.CreatedAt,
+		"Health":      formatHealth(urlMonitor, link.ID),
+		"ContinueURL": continueURL,
+	}); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Erreur rendu aperçu", "error", err)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBreakdownValues borne le paramètre limit de la répartition des clics.
+const maxBreakdownValues = 100
+
+// statsQuery lit les paramètres granularity, from et to (RFC 3339 ou AAAA-MM-JJ).
+func statsQuery(c *gin.Context) (services.StatsQuery, bool) {
+	query := services.StatsQuery{Granularity: repository.Granularity(c.Query("granularity"))}
+	for _, param := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètre " + param.name + " invalide (RFC 3339 ou AAAA-MM-JJ)"})
+				return query, false
+			}
+		}
+		*param.dest = t
+	}
+	return query, true
+}
+
+// statsError répond à une erreur des statistiques détaillées.
+func statsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+	case errors.Is(err, services.ErrInvalidStatsQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		slog.ErrorContext(c.Request.Context(), "Erreur récupération stats", "short_code", c.Param("shortCode"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+	}
+}
+
+// GetLinkTimeSeriesHandler retourne les clics d'un lien par heure ou par jour (UTC).
+func GetLinkTimeSeriesHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query, ok := statsQuery(c)
+		if !ok {
+			return
+		}
+		series, err := linkService.GetLinkTimeSeries(c.Request.Context(), c.Param("shortCode"), query)
+		if err != nil {
+			statsError(c, err)
+			return
+		}
+
+		points := make([]gin.H, 0, len(series.Points))
+		for _, point := range series.Points {
+			points = append(points, gin.H{"bucket": point.Bucket, "clicks": point.Clicks})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":  series.Link.ShortCode,
+			"granularity": series.Query.Granularity,
+			"from":        series.Query.From,
+			"to":          series.Query.To,
+			"total":       series.Total,
+			"points":      points,
+		})
+	}
+}
+
+// GetLinkBreakdownHandler retourne la répartition des clics d'un lien par
+// domaine d'origine, pays ou type d'appareil.
+func GetLinkBreakdownHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query, ok := statsQuery(c)
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > maxBreakdownValues {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètre limit invalide (1 à 100)"})
+			return
+		}
+
+		breakdown, err := linkService.GetLinkBreakdown(c.Request.Context(), c.Param("shortCode"), c.Param("dimension"), query, limit)
+		if err != nil {
+			statsError(c, err)
+			return
+		}
+
+		values := make([]gin.H, 0, len(breakdown.Values))
+		for _, value := range breakdown.Values {
+			values = append(values, gin.H{"value": value.Value, "clicks": value.Clicks})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":  breakdown.Link.ShortCode,
+			"dimension":   breakdown.Dimension,
+			"granularity": breakdown.Query.Granularity,
+			"from":        breakdown.Query.From,
+			"to":          breakdown.Query.To,
+			"values":      values,
+		})
+	}
+}
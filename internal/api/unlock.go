@@ -0,0 +1,217 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/logging"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// unlockBlockDuration est la durée du blocage après trop d'essais infructueux.
+const unlockBlockDuration = 15 * time.Minute
+
+var unlockFormTemplate = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Lien protégé</title>
+</head>
+<body>
+<h1>Lien protégé</h1>
+<p>Ce lien est protégé par un mot de passe.</p>
+{{if .Error}}<p role="alert"><strong>{{.Error}}</strong></p>{{end}}
+<form method="post">
+<label for="password">Mot de passe</label>
+<input type="password" id="password" name="password" autocomplete="current-password" required autofocus>
+<button type="submit">Accéder au lien</button>
+</form>
+</body>
+</html>
+`))
+
+// Unlocker émet et vérifie les cookies signés qui déverrouillent temporairement
+// un lien protégé, et limite les essais de mot de passe par IP et par lien.
+type Unlocker struct {
+	secret      []byte
+	ttl         time.Duration
+	secure      bool
+	maxAttempts int
+
+	mu       sync.Mutex
+	attempts map[string]*unlockAttempts
+}
+
+type unlockAttempts struct {
+	failures     int
+	blockedUntil time.Time
+	lastFailure  time.Time
+}
+
+// NewUnlocker crée un Unlocker à partir de la configuration de sécurité.
+func NewUnlocker(cfg *config.Config) *Unlocker {
+	secret := []byte(cfg.Security.UnlockSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			logging.Fatal("Échec génération de la clé de déverrouillage", "error", err)
+		}
+	}
+
+	return &Unlocker{
+		secret:      secret,
+		ttl:         time.Duration(cfg.Security.UnlockTTLMinutes) * time.Minute,
+		secure:      strings.HasPrefix(cfg.Server.BaseURL, "https://"),
+		maxAttempts: cfg.Security.UnlockMaxAttempts,
+		attempts:    make(map[string]*unlockAttempts),
+	}
+}
+
+func unlockCookieName(link *models.Link) string {
+	return "unlock_" + link.ShortCode
+}
+
+// sign calcule la signature liant le cookie au lien, à son mot de passe actuel et à l'expiration.
+func (u *Unlocker) sign(link *models.Link, expires string) string {
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write([]byte(link.ShortCode + "|" + link.PasswordHash + "|" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsUnlocked indique si la requête porte un cookie de déverrouillage valide pour le lien.
+func (u *Unlocker) IsUnlocked(c *gin.Context, link *models.Link) bool {
+	value, err := c.Cookie(unlockCookieName(link))
+	if err != nil {
+		return false
+	}
+
+	expires, signature, found := strings.Cut(value, ".")
+	if !found {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(u.sign(link, expires)))
+}
+
+// setCookie pose le cookie de déverrouillage. Son nom est propre au lien et
+// son chemin est la racine : un chemin /abc ne couvrirait pas l'aperçu /abc+.
+func (u *Unlocker) setCookie(c *gin.Context, link *models.Link) {
+	expires := strconv.FormatInt(time.Now().Add(u.ttl).Unix(), 10)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(unlockCookieName(link), expires+"."+u.sign(link, expires),
+		int(u.ttl.Seconds()), "/", "", u.secure, true)
+}
+
+// blockedFor retourne la durée de blocage restante pour cette clé IP/lien.
+func (u *Unlocker) blockedFor(key string) time.Duration {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	state, exists := u.attempts[key]
+	if !exists {
+		return 0
+	}
+	return time.Until(state.blockedUntil)
+}
+
+// recordFailure comptabilise un essai infructueux et bloque la clé au-delà du maximum autorisé.
+func (u *Unlocker) recordFailure(key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := time.Now()
+	u.pruneLocked(now)
+
+	state, exists := u.attempts[key]
+	if !exists {
+		state = &unlockAttempts{}
+		u.attempts[key] = state
+	}
+	state.failures++
+	state.lastFailure = now
+	if state.failures >= u.maxAttempts {
+		state.blockedUntil = now.Add(unlockBlockDuration)
+		state.failures = 0
+	}
+}
+
+func (u *Unlocker) recordSuccess(key string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.attempts, key)
+}
+
+// pruneLocked oublie les compteurs inactifs pour borner la mémoire utilisée.
+func (u *Unlocker) pruneLocked(now time.Time) {
+	for key, state := range u.attempts {
+		if now.Sub(state.lastFailure) > unlockBlockDuration && now.After(state.blockedUntil) {
+			delete(u.attempts, key)
+		}
+	}
+}
+
+func renderUnlockForm(c *gin.Context, status int, message string) {
+	c.Header("Cache-Control", "private, no-store, max-age=0")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := unlockFormTemplate.Execute(c.Writer, gin.H{"Error": message}); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Erreur rendu formulaire de déverrouillage", "error", err)
+	}
+}
+
+// UnlockHandler vérifie le mot de passe soumis par le formulaire d'un lien protégé,
+// pose le cookie de déverrouillage puis redirige en enregistrant le clic.
+func UnlockHandler(linkService *services.LinkService, unlocker *Unlocker, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode, preview := previewRequest(c)
+		link, ok := findRedirectLink(c, linkService, shortCode)
+		if !ok {
+			return
+		}
+		if !link.IsProtected() {
+			RedirectMethodNotAllowedHandler(c)
+			return
+		}
+
+		key := c.ClientIP() + "|" + link.ShortCode
+		if wait := unlocker.blockedFor(key); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			renderUnlockForm(c, http.StatusTooManyRequests, "Trop d'essais. Réessayez plus tard.")
+			return
+		}
+
+		if !services.CheckLinkPassword(link, c.PostForm("password")) {
+			unlocker.recordFailure(key)
+			slog.WarnContext(c.Request.Context(), "Mot de passe incorrect", "short_code", link.ShortCode, "client_ip", c.ClientIP())
+			renderUnlockForm(c, http.StatusUnauthorized, "Mot de passe incorrect.")
+			return
+		}
+
+		unlocker.recordSuccess(key)
+		unlocker.setCookie(c, link)
+		// 303 pour que le navigateur poursuive en GET : l'aperçu s'il était demandé,
+		// sinon la destination.
+		if preview {
+			c.Redirect(http.StatusSeeOther, c.Request.URL.RequestURI())
+			return
+		}
+		sendRedirect(c, linkService, link, cfg, true, http.StatusSeeOther)
+	}
+}
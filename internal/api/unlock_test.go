@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Julien-Somasundaram/urlshortener/pkg/client"
+)
+
+func TestUnlockPreview(t *testing.T) {
+	destination := httptest.NewServer(http.NotFoundHandler())
+	defer destination.Close()
+	srv := httptest.NewServer(newTestRouter(t))
+	defer srv.Close()
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateLink(context.Background(), client.CreateLinkRequest{
+		LongURL:  destination.URL + "/secret",
+		Alias:    "secret",
+		Password: "sesame",
+	}); err != nil {
+		t.Fatalf("création : %v", err)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := &http.Client{
+		Jar: jar,
+		// La redirection vers la destination n'est pas suivie.
+		CheckRedirect: func(req *http.Request, _ []*http.Request) error {
+			if req.URL.Host == strings.TrimPrefix(destination.URL, "http://") {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	// Le mot de passe soumis depuis l'aperçu ramène à l'aperçu, qui s'affiche.
+	resp, err := browser.PostForm(srv.URL+"/secret+", url.Values{"password": {"sesame"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Request.URL.Path != "/secret+" || !strings.Contains(string(body), "Aperçu du lien secret") {
+		t.Fatalf("après déverrouillage : %s %d, page :\n%s", resp.Request.URL.Path, resp.StatusCode, body)
+	}
+
+	// Le même cookie déverrouille la redirection.
+	resp, err = browser.Get(srv.URL + "/secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != destination.URL+"/secret" {
+		t.Errorf("redirection : %d vers %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	// Sans cookie, le formulaire est de nouveau demandé.
+	resp, err = http.Get(srv.URL + "/secret+")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Lien protégé") {
+		t.Errorf("sans cookie : %d, formulaire attendu", resp.StatusCode)
+	}
+}
@@ -0,0 +1,197 @@
+// Package archive définit le format d'échange des liens et des clics entre
+// instances : un fichier JSON Lines versionné, lu et écrit en flux.
+//
+// La première ligne est l'en-tête, suivie des liens puis des clics :
+//
+//	{"type":"header","format":"urlshortener-archive","version":1,"exported_at":"..."}
+//	{"type":"link","link":{"short_code":"abc123","long_url":"https://...",...}}
+//	{"type":"click","click":{"short_code":"abc123","timestamp":"...",...}}
+//
+// Les clics référencent leur lien par son code court, les identifiants
+// n'ayant pas de sens d'une base à l'autre.
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// FormatName identifie les archives produites par l'application.
+const FormatName = "urlshortener-archive"
+
+// Version est la version du format écrite par Writer. Reader accepte toutes
+// les versions inférieures ou égales.
+const Version = 1
+
+// Types d'enregistrements d'une archive.
+const (
+	TypeHeader = "header"
+	TypeLink   = "link"
+	TypeClick  = "click"
+)
+
+// maxLineSize borne la taille d'une ligne (description de page incluse).
+const maxLineSize = 1 << 20
+
+// ErrNotArchive est retournée quand la première ligne n'est pas un en-tête d'archive.
+var ErrNotArchive = errors.New("fichier qui n'est pas une archive urlshortener")
+
+// Header décrit l'archive.
+type Header struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// LinkRecord est un lien exporté. Le mot de passe n'est présent que sous
+// forme de hash bcrypt.
+type LinkRecord struct {
+	ShortCode      string    `json:"short_code"`
+	LongURL        string    `json:"long_url"`
+	ForwardQuery   string    `json:"forward_query,omitempty"`
+	ForwardPath    bool      `json:"forward_path,omitempty"`
+	Campaign       string    `json:"campaign,omitempty"`
+	RedirectStatus int       `json:"redirect_status,omitempty"`
+	CacheMaxAge    *int      `json:"cache_max_age,omitempty"`
+	PasswordHash   string    `json:"password_hash,omitempty"`
+	Title          string    `json:"title,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	FaviconURL     string    `json:"favicon_url,omitempty"`
+	ImageURL       string    `json:"image_url,omitempty"`
+	Disabled       bool      `json:"disabled,omitempty"`
+	DisabledReason string    `json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ClickRecord est un clic exporté.
+type ClickRecord struct {
+	ShortCode string    `json:"short_code"`
+	Timestamp time.Time `json:"timestamp"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	Referrer  string    `json:"referrer,omitempty"`
+	Country   string    `json:"country,omitempty"`
+}
+
+// Entry est une ligne de l'archive : Link ou Click est renseigné selon Type.
+type Entry struct {
+	Type  string       `json:"type"`
+	Link  *LinkRecord  `json:"link,omitempty"`
+	Click *ClickRecord `json:"click,omitempty"`
+}
+
+// Source fournit des enregistrements à importer : une archive (Reader) ou un
+// export d'un autre raccourcisseur (CSVReader).
+type Source interface {
+	Next() (*Entry, error) // io.EOF en fin de source
+}
+
+// Writer écrit une archive en flux.
+type Writer struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewWriter crée un Writer et écrit l'en-tête de l'archive.
+func NewWriter(w io.Writer, exportedAt time.Time) (*Writer, error) {
+	buffer := bufio.NewWriter(w)
+	writer := &Writer{buffer: buffer, encoder: json.NewEncoder(buffer)}
+	writer.encoder.SetEscapeHTML(false)
+
+	header := struct {
+		Type string `json:"type"`
+		Header
+	}{TypeHeader, Header{Format: FormatName, Version: Version, ExportedAt: exportedAt.UTC()}}
+	if err := writer.encoder.Encode(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// WriteLink ajoute un lien à l'archive.
+func (w *Writer) WriteLink(link *LinkRecord) error {
+	return w.encoder.Encode(Entry{Type: TypeLink, Link: link})
+}
+
+// WriteClick ajoute un clic à l'archive.
+func (w *Writer) WriteClick(click *ClickRecord) error {
+	return w.encoder.Encode(Entry{Type: TypeClick, Click: click})
+}
+
+// Flush écrit les données encore en mémoire tampon.
+func (w *Writer) Flush() error {
+	return w.buffer.Flush()
+}
+
+// Reader lit une archive ligne à ligne.
+type Reader struct {
+	scanner *bufio.Scanner
+	header  Header
+	line    int
+}
+
+// NewReader lit et vérifie l'en-tête de l'archive.
+func NewReader(r io.Reader) (*Reader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	reader := &Reader{scanner: scanner}
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotArchive
+	}
+	reader.line = 1
+
+	var header struct {
+		Type string `json:"type"`
+		Header
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Type != TypeHeader || header.Format != FormatName {
+		return nil, ErrNotArchive
+	}
+	if header.Version < 1 || header.Version > Version {
+		return nil, fmt.Errorf("version d'archive %d non prise en charge (maximum %d)", header.Version, Version)
+	}
+	reader.header = header.Header
+	return reader, nil
+}
+
+// Header retourne l'en-tête de l'archive.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next retourne l'enregistrement suivant, ou io.EOF en fin d'archive. Les
+// lignes vides sont ignorées, les types inconnus (versions futures) aussi.
+func (r *Reader) Next() (*Entry, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("ligne %d : %w", r.line, err)
+		}
+		switch {
+		case entry.Type == TypeLink && entry.Link != nil:
+			return &entry, nil
+		case entry.Type == TypeClick && entry.Click != nil:
+			return &entry, nil
+		case entry.Type == TypeLink || entry.Type == TypeClick:
+			return nil, fmt.Errorf("ligne %d : enregistrement %s vide", r.line, entry.Type)
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	exportedAt := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, exportedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxAge := 60
+	link := &LinkRecord{ShortCode: "abc123", LongURL: "https://example.com/<a>", Campaign: "spring", CacheMaxAge: &maxAge, CreatedAt: exportedAt}
+	click := &ClickRecord{ShortCode: "abc123", Timestamp: exportedAt, Country: "FR"}
+	if err := writer.WriteLink(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteClick(click); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `<a>`) {
+		t.Error("les URLs ne doivent pas être échappées pour HTML")
+	}
+
+	reader, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := reader.Header(); header.Format != FormatName || header.Version != Version || !header.ExportedAt.Equal(exportedAt) {
+		t.Errorf("en-tête = %+v", header)
+	}
+	entry, err := reader.Next()
+	if err != nil || entry.Type != TypeLink || entry.Link.LongURL != link.LongURL || *entry.Link.CacheMaxAge != 60 || entry.Link.Campaign != "spring" {
+		t.Fatalf("lien relu = %+v, %v", entry, err)
+	}
+	entry, err = reader.Next()
+	if err != nil || entry.Type != TypeClick || entry.Click.Country != "FR" {
+		t.Fatalf("clic relu = %+v, %v", entry, err)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("fin d'archive : %v, attendu io.EOF", err)
+	}
+}
+
+func TestReaderRejectsInvalidArchives(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("short_code,long_url\n")); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("CSV : erreur %v, attendu ErrNotArchive", err)
+	}
+	if _, err := NewReader(strings.NewReader(`{"type":"header","format":"urlshortener-archive","version":99}` + "\n")); err == nil {
+		t.Error("version future acceptée")
+	}
+
+	// Lignes vides et types inconnus ignorés, enregistrement vide refusé.
+	reader, err := NewReader(strings.NewReader(`{"type":"header","format":"urlshortener-archive","version":1}` + "\n\n" +
+		`{"type":"tag","tag":{}}` + "\n" + `{"type":"link"}` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Next(); err == nil || !strings.Contains(err.Error(), "ligne 4") {
+		t.Errorf("lien vide : erreur %v, attendu une erreur sur la ligne 4", err)
+	}
+}
@@ -0,0 +1,182 @@
+package archive
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Formats d'export d'autres raccourcisseurs pris en charge.
+const (
+	FormatBitly  = "bitly"
+	FormatYOURLS = "yourls"
+)
+
+// ErrUnknownFormat est retournée pour un format d'import tiers inconnu.
+var ErrUnknownFormat = errors.New("format d'import inconnu")
+
+// csvColumns associe un champ de LinkRecord aux en-têtes possibles de la colonne.
+type csvColumns struct {
+	shortCode []string
+	longURL   []string
+	title     []string
+	createdAt []string
+	// positional est l'ordre des colonnes d'un export sans en-tête, nil si
+	// l'en-tête est obligatoire.
+	positional []string
+}
+
+var thirdPartyColumns = map[string]csvColumns{
+	// Export CSV de Bitly : « Bitlink » contient le domaine et le code.
+	FormatBitly: {
+		shortCode: []string{"bitlink", "link", "short_url", "short link", "id"},
+		longURL:   []string{"long_url", "long url", "destination", "original url"},
+		title:     []string{"title"},
+		createdAt: []string{"created", "created_at", "date created", "creation date"},
+	},
+	// Table yourls_url : keyword, url, title, timestamp, ip, clicks.
+	FormatYOURLS: {
+		shortCode:  []string{"keyword", "shorturl", "short_url"},
+		longURL:    []string{"url", "long_url", "longurl"},
+		title:      []string{"title"},
+		createdAt:  []string{"timestamp", "date", "created"},
+		positional: []string{"keyword", "url", "title", "timestamp", "ip", "clicks"},
+	},
+}
+
+// Formats de date rencontrés dans les exports tiers.
+var thirdPartyTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"01/02/2006 15:04",
+	"Jan 2, 2006",
+}
+
+// CSVReader lit un export CSV d'un autre raccourcisseur et le convertit en liens.
+type CSVReader struct {
+	reader  *csv.Reader
+	columns map[string]int // champ → index de colonne
+	pending []string       // première ligne de données d'un export sans en-tête
+}
+
+// NewCSVReader prépare la lecture d'un export au format donné (FormatBitly ou FormatYOURLS).
+func NewCSVReader(format string, r io.Reader) (*CSVReader, error) {
+	spec, ok := thirdPartyColumns[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w : %q", ErrUnknownFormat, format)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	first, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("lecture de la première ligne : %w", err)
+	}
+
+	header := make(map[string]int, len(first))
+	for i, name := range first {
+		header[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	columns := map[string]int{}
+	lookup := func(field string, names []string) {
+		for _, name := range names {
+			if i, ok := header[name]; ok {
+				columns[field] = i
+				return
+			}
+		}
+	}
+	lookup("short_code", spec.shortCode)
+	lookup("long_url", spec.longURL)
+	lookup("title", spec.title)
+	lookup("created_at", spec.createdAt)
+
+	csvReader := &CSVReader{reader: reader, columns: columns}
+	if _, ok := columns["long_url"]; !ok {
+		if spec.positional == nil {
+			return nil, fmt.Errorf("colonne d'URL longue absente de l'en-tête %s", format)
+		}
+		// Pas d'en-tête reconnu : colonnes dans l'ordre de la table d'origine.
+		csvReader.columns = map[string]int{}
+		for i, name := range spec.positional {
+			switch name {
+			case spec.shortCode[0]:
+				csvReader.columns["short_code"] = i
+			case spec.longURL[0]:
+				csvReader.columns["long_url"] = i
+			case spec.title[0]:
+				csvReader.columns["title"] = i
+			case spec.createdAt[0]:
+				csvReader.columns["created_at"] = i
+			}
+		}
+		csvReader.pending = first
+	}
+	return csvReader, nil
+}
+
+// Next retourne le lien suivant, ou io.EOF en fin de fichier. Le code court
+// et la date de création sont vides quand l'export ne les fournit pas.
+func (c *CSVReader) Next() (*Entry, error) {
+	record := c.pending
+	c.pending = nil
+	if record == nil {
+		var err error
+		if record, err = c.reader.Read(); err != nil {
+			return nil, err
+		}
+	}
+
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	link := &LinkRecord{
+		ShortCode: shortCodeFromLink(field("short_code")),
+		LongURL:   field("long_url"),
+		Title:     field("title"),
+	}
+	// Une date illisible n'empêche pas l'import : la date d'import est alors utilisée.
+	link.CreatedAt, _ = parseThirdPartyTime(field("created_at"))
+	return &Entry{Type: TypeLink, Link: link}, nil
+}
+
+// shortCodeFromLink extrait le code d'un lien court complet (« bit.ly/3abc »).
+func shortCodeFromLink(value string) string {
+	if !strings.Contains(value, "/") {
+		return value
+	}
+	if !strings.Contains(value, "://") {
+		value = "https://" + value
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(parsed.Path, "/")
+}
+
+func parseThirdPartyTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range thirdPartyTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("format de date inconnu : %q", value)
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readAll retourne les liens lus depuis un export tiers.
+func readAll(t *testing.T, format, data string) []*LinkRecord {
+	t.Helper()
+	reader, err := NewCSVReader(format, strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ouverture : %v", err)
+	}
+	var links []*LinkRecord
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return links
+		}
+		if err != nil {
+			t.Fatalf("lecture : %v", err)
+		}
+		if entry.Type != TypeLink {
+			t.Fatalf("type %q, attendu %q", entry.Type, TypeLink)
+		}
+		links = append(links, entry.Link)
+	}
+}
+
+func TestBitlyExport(t *testing.T) {
+	links := readAll(t, FormatBitly, "\ufeffTitle,Bitlink,Long URL,Created\n"+
+		"Accueil,bit.ly/3abcDEF,https://example.com/home,2024-05-01T10:30:00+0000\n"+
+		"\"Docs, guide\",https://bit.ly/docs42,https://example.com/docs,\n"+
+		"Sans date,bit.ly/xyz,https://example.com/x,pas une date\n")
+	if len(links) != 3 {
+		t.Fatalf("%d lien(s), attendu 3", len(links))
+	}
+
+	want := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	if l := links[0]; l.ShortCode != "3abcDEF" || l.LongURL != "https://example.com/home" || l.Title != "Accueil" || !l.CreatedAt.Equal(want) {
+		t.Errorf("premier lien = %+v", l)
+	}
+	if l := links[1]; l.ShortCode != "docs42" || l.Title != "Docs, guide" || !l.CreatedAt.IsZero() {
+		t.Errorf("deuxième lien = %+v", l)
+	}
+	// Une date illisible laisse la date de création vide.
+	if l := links[2]; l.ShortCode != "xyz" || !l.CreatedAt.IsZero() {
+		t.Errorf("troisième lien = %+v", l)
+	}
+}
+
+func TestBitlyExportRequiresHeader(t *testing.T) {
+	if _, err := NewCSVReader(FormatBitly, strings.NewReader("bit.ly/abc,https://example.com\n")); err == nil {
+		t.Error("export Bitly sans colonne d'URL longue accepté")
+	}
+}
+
+func TestYOURLSExport(t *testing.T) {
+	withHeader := readAll(t, FormatYOURLS, "keyword,url,title,timestamp,ip,clicks\n"+
+		"promo,https://example.com/promo,Promo,2023-11-02 08:15:00,127.0.0.1,12\n")
+	// Dump de la table yourls_url sans en-tête : colonnes dans l'ordre de la table.
+	positional := readAll(t, FormatYOURLS, "promo,https://example.com/promo,Promo,2023-11-02 08:15:00,127.0.0.1,12\n"+
+		"2,https://example.com/two,,,,0\n")
+
+	want := time.Date(2023, 11, 2, 8, 15, 0, 0, time.UTC)
+	for name, links := range map[string][]*LinkRecord{"avec en-tête": withHeader, "sans en-tête": positional} {
+		if len(links) == 0 {
+			t.Errorf("%s : aucun lien", name)
+			continue
+		}
+		if l := links[0]; l.ShortCode != "promo" || l.LongURL != "https://example.com/promo" || l.Title != "Promo" || !l.CreatedAt.Equal(want) {
+			t.Errorf("%s : lien = %+v", name, l)
+		}
+	}
+	if len(positional) != 2 || positional[1].ShortCode != "2" || positional[1].LongURL != "https://example.com/two" {
+		t.Errorf("sans en-tête : %d lien(s), second = %+v", len(positional), positional[len(positional)-1])
+	}
+}
+
+func TestUnknownThirdPartyFormat(t *testing.T) {
+	if _, err := NewCSVReader("tinyurl", strings.NewReader("a,b\n")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("erreur %v, attendu ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,345 @@
+// Package backup sauvegarde et restaure la base SQLite. Les sauvegardes sont
+// prises à chaud avec VACUUM INTO, qui produit une copie cohérente et compactée
+// sans interrompre le serveur, puis accompagnées d'une somme de contrôle SHA-256.
+package backup
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	filePrefix      = "urlshortener-"
+	timestampFormat = "20060102T150405Z"
+	checksumSuffix  = ".sha256"
+)
+
+var (
+	// ErrUnsupportedDriver signale une base autre que SQLite, à sauvegarder avec ses outils natifs.
+	ErrUnsupportedDriver = errors.New("sauvegarde réservée à SQLite (utilisez pg_dump ou mysqldump)")
+	// ErrChecksumMismatch signale une sauvegarde altérée depuis sa création.
+	ErrChecksumMismatch = errors.New("somme de contrôle différente de celle de la sauvegarde")
+)
+
+// Options règle la création et la rotation des sauvegardes.
+type Options struct {
+	Dir      string
+	Compress bool          // Compression gzip de la copie
+	Keep     int           // Nombre de sauvegardes conservées (0 = illimité)
+	MaxAge   time.Duration // Âge maximal d'une sauvegarde (0 = illimité)
+}
+
+// OptionsFromConfig construit les options depuis la section backup.
+func OptionsFromConfig(cfg *config.Config) Options {
+	return Options{
+		Dir:      cfg.Backup.Dir,
+		Compress: cfg.Backup.Compress,
+		Keep:     cfg.Backup.Keep,
+		MaxAge:   time.Duration(cfg.Backup.MaxAgeDays) * 24 * time.Hour,
+	}
+}
+
+// Result décrit une sauvegarde créée.
+type Result struct {
+	Path     string
+	Size     int64
+	Checksum string
+	Removed  []string // Anciennes sauvegardes supprimées par la rotation
+}
+
+// Create sauvegarde la base db dans opts.Dir, puis applique la rotation.
+func Create(db *gorm.DB, opts Options) (*Result, error) {
+	if db.Dialector.Name() != "sqlite" {
+		return nil, ErrUnsupportedDriver
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	name := filePrefix + time.Now().UTC().Format(timestampFormat) + ".db"
+	snapshot := filepath.Join(opts.Dir, "."+name+".tmp")
+	defer os.Remove(snapshot)
+	// VACUUM INTO refuse d'écraser un fichier existant.
+	os.Remove(snapshot)
+	if err := db.Exec("VACUUM INTO ?", snapshot).Error; err != nil {
+		return nil, fmt.Errorf("copie de la base : %w", err)
+	}
+
+	path := filepath.Join(opts.Dir, name)
+	if opts.Compress {
+		path += ".gz"
+		if err := compressFile(snapshot, path); err != nil {
+			return nil, err
+		}
+	} else if err := os.Rename(snapshot, path); err != nil {
+		return nil, err
+	}
+
+	checksum, size, err := fileChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	line := checksum + "  " + filepath.Base(path) + "\n" // Format de sha256sum
+	if err := os.WriteFile(path+checksumSuffix, []byte(line), 0o644); err != nil {
+		return nil, err
+	}
+
+	removed, err := Rotate(opts)
+	if err != nil {
+		return nil, fmt.Errorf("rotation des sauvegardes : %w", err)
+	}
+	return &Result{Path: path, Size: size, Checksum: checksum, Removed: removed}, nil
+}
+
+// Rotate supprime les sauvegardes au-delà des opts.Keep plus récentes ou plus
+// anciennes que opts.MaxAge, et retourne les fichiers supprimés.
+func Rotate(opts Options) ([]string, error) {
+	backups, err := List(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for i, b := range backups {
+		tooMany := opts.Keep > 0 && i >= opts.Keep
+		tooOld := opts.MaxAge > 0 && time.Since(b.CreatedAt) > opts.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil {
+			return removed, err
+		}
+		os.Remove(b.Path + checksumSuffix)
+		removed = append(removed, b.Path)
+	}
+	return removed, nil
+}
+
+// Backup est une sauvegarde présente dans le dossier.
+type Backup struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// List retourne les sauvegardes de dir, de la plus récente à la plus ancienne.
+func List(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, filePrefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(strings.TrimSuffix(stamp, ".gz"), ".db")
+		if !ok {
+			continue
+		}
+		createdAt, err := time.Parse(timestampFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, name), CreatedAt: createdAt})
+	}
+	slices.SortFunc(backups, func(a, b Backup) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return backups, nil
+}
+
+// Verification est le résultat du contrôle d'une sauvegarde.
+type Verification struct {
+	ChecksumVerified bool // Faux si la somme de contrôle est absente
+	SchemaVersion    int
+}
+
+// Verify contrôle la sauvegarde path : somme de contrôle, intégrité SQLite et
+// version du schéma, qui doit être connue de ce binaire.
+func Verify(path string) (*Verification, error) {
+	tmp, err := os.CreateTemp("", "urlshortener-verify-*.db")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	return extract(path, tmp.Name())
+}
+
+// Restore remplace la base dbFile par la sauvegarde path, après l'avoir
+// vérifiée. La base remplacée est conservée à côté sous le nom
+// <dbFile>.before-restore-<date>, qui est retourné. L'appelant doit tenir le
+// verrou exclusif de la base (storage.LockSQLite), qui garantit l'arrêt des serveurs.
+func Restore(path, dbFile string) (previous string, v *Verification, err error) {
+	// Extraction à côté de la base pour un remplacement atomique par renommage.
+	tmp, err := os.CreateTemp(filepath.Dir(dbFile), "."+filepath.Base(dbFile)+".restore-*")
+	if err != nil {
+		return "", nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if v, err = extract(path, tmp.Name()); err != nil {
+		return "", nil, err
+	}
+
+	if _, err := os.Stat(dbFile); err == nil {
+		previous = dbFile + ".before-restore-" + time.Now().UTC().Format(timestampFormat)
+		if err := os.Rename(dbFile, previous); err != nil {
+			return "", nil, err
+		}
+		// Le journal WAL appartient à l'ancienne base : SQLite l'appliquerait à la nouvelle.
+		for _, suffix := range []string{"-wal", "-shm"} {
+			if _, err := os.Stat(dbFile + suffix); err == nil {
+				if err := os.Rename(dbFile+suffix, previous+suffix); err != nil {
+					return previous, nil, err
+				}
+			}
+		}
+	}
+	if err := os.Rename(tmp.Name(), dbFile); err != nil {
+		return previous, nil, err
+	}
+	return previous, v, nil
+}
+
+// extract vérifie la somme de contrôle de path, le copie décompressé vers
+// dest, puis contrôle la base obtenue.
+func extract(path, dest string) (*Verification, error) {
+	v := &Verification{}
+	if content, err := os.ReadFile(path + checksumSuffix); err == nil {
+		expected, _, _ := strings.Cut(strings.TrimSpace(string(content)), " ")
+		actual, _, err := fileChecksum(path)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(expected, actual) {
+			return nil, ErrChecksumMismatch
+		}
+		v.ChecksumVerified = true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := copyDecompressed(path, dest); err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(sqlite.Open(dest), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	defer sqlDB.Close()
+
+	var result string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("fichier SQLite illisible : %w", err)
+	}
+	if result != "ok" {
+		return nil, fmt.Errorf("base corrompue : %s", result)
+	}
+
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		return nil, err
+	}
+	if v.SchemaVersion, err = runner.Current(); err != nil {
+		return nil, err
+	}
+	if v.SchemaVersion > migrations.Latest() {
+		return nil, fmt.Errorf("%w : sauvegarde en version %d, binaire en version %d",
+			migrations.ErrSchemaMismatch, v.SchemaVersion, migrations.Latest())
+	}
+	return v, nil
+}
+
+func copyDecompressed(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if strings.HasSuffix(src, ".gz") {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("décompression : %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func compressFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	return out.Close()
+}
+
+func fileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -0,0 +1,192 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB ouvre la base SQLite dbFile et la migre.
+func openTestDB(t *testing.T, dbFile string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(dbFile), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("ouverture base : %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("migrations : %v", err)
+	}
+	return db
+}
+
+// closeDB ferme la base avant son remplacement par Restore.
+func closeDB(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+}
+
+// linkCodes retourne les codes des liens de la base dbFile.
+func linkCodes(t *testing.T, dbFile string) []string {
+	t.Helper()
+	db := openTestDB(t, dbFile)
+	defer closeDB(t, db)
+	var codes []string
+	if err := db.Table("links").Order("shortcode").Pluck("shortcode", &codes).Error; err != nil {
+		t.Fatal(err)
+	}
+	return codes
+}
+
+func insertLink(t *testing.T, db *gorm.DB, code string) {
+	t.Helper()
+	err := db.Exec("INSERT INTO links (shortcode, long_url, description, favicon_url, image_url, created_at) VALUES (?, ?, '', '', '', CURRENT_TIMESTAMP)",
+		code, "https://example.com/"+code).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(map[bool]string{false: "brute", true: "gzip"}[compress], func(t *testing.T) {
+			dir := t.TempDir()
+			dbFile := filepath.Join(dir, "app.db")
+			db := openTestDB(t, dbFile)
+			insertLink(t, db, "before")
+
+			result, err := Create(db, Options{Dir: filepath.Join(dir, "backups"), Compress: compress})
+			if err != nil {
+				t.Fatalf("sauvegarde : %v", err)
+			}
+			if strings.HasSuffix(result.Path, ".gz") != compress || result.Size == 0 {
+				t.Errorf("sauvegarde = %+v", result)
+			}
+			if v, err := Verify(result.Path); err != nil || !v.ChecksumVerified || v.SchemaVersion != migrations.Latest() {
+				t.Errorf("vérification = %+v, %v", v, err)
+			}
+
+			// Écriture postérieure à la sauvegarde, perdue par la restauration.
+			insertLink(t, db, "after")
+			closeDB(t, db)
+
+			previous, v, err := Restore(result.Path, dbFile)
+			if err != nil {
+				t.Fatalf("restauration : %v", err)
+			}
+			if v.SchemaVersion != migrations.Latest() {
+				t.Errorf("version restaurée = %d", v.SchemaVersion)
+			}
+			if got := linkCodes(t, dbFile); len(got) != 1 || got[0] != "before" {
+				t.Errorf("liens restaurés = %v, attendu [before]", got)
+			}
+			if got := linkCodes(t, previous); len(got) != 2 {
+				t.Errorf("liens de l'ancienne base %s = %v, attendu [after before]", previous, got)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsInvalidBackups(t *testing.T) {
+	dir := t.TempDir()
+	db := openTestDB(t, filepath.Join(dir, "app.db"))
+	result, err := Create(db, Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Somme de contrôle différente du fichier.
+	if err := os.WriteFile(result.Path+checksumSuffix, []byte(strings.Repeat("0", 64)+"  x.db\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(result.Path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("somme altérée : erreur %v, attendu ErrChecksumMismatch", err)
+	}
+
+	// Sans somme de contrôle, la sauvegarde reste vérifiée par SQLite.
+	os.Remove(result.Path + checksumSuffix)
+	if v, err := Verify(result.Path); err != nil || v.ChecksumVerified {
+		t.Errorf("sans somme : %+v, %v", v, err)
+	}
+
+	notSQLite := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(notSQLite, []byte(strings.Repeat("x", 4096)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(notSQLite); err == nil {
+		t.Error("fichier non SQLite accepté")
+	}
+
+	// Sauvegarde d'un binaire plus récent : restauration refusée, base intacte.
+	future := filepath.Join(dir, "future.db")
+	futureDB := openTestDB(t, future)
+	if err := futureDB.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (99, 'future', CURRENT_TIMESTAMP)").Error; err != nil {
+		t.Fatal(err)
+	}
+	closeDB(t, futureDB)
+	if _, _, err := Restore(future, filepath.Join(dir, "app.db")); !errors.Is(err, migrations.ErrSchemaMismatch) {
+		t.Errorf("version future : erreur %v, attendu ErrSchemaMismatch", err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	var names []string
+	for _, age := range []time.Duration{0, time.Hour, 48 * time.Hour, 72 * time.Hour} {
+		name := filePrefix + now.Add(-age).Format(timestampFormat) + ".db"
+		names = append(names, name)
+		for _, file := range []string{name, name + checksumSuffix} {
+			if err := os.WriteFile(filepath.Join(dir, file), nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Fichiers étrangers ignorés.
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, filePrefix+"invalid.db"), nil, 0o644)
+
+	backups, err := List(dir)
+	if err != nil || len(backups) != 4 || filepath.Base(backups[0].Path) != names[0] {
+		t.Fatalf("liste = %+v, %v", backups, err)
+	}
+
+	// Trois conservées au plus, dont aucune de plus de 60 heures.
+	removed, err := Rotate(Options{Dir: dir, Keep: 3, MaxAge: 60 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != names[3] {
+		t.Errorf("supprimées = %v, attendu %s", removed, names[3])
+	}
+	removed, err = Rotate(Options{Dir: dir, Keep: 1})
+	if err != nil || len(removed) != 2 {
+		t.Errorf("supprimées = %v, %v, attendu 2 sauvegardes", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, names[1]+checksumSuffix)); !errors.Is(err, os.ErrNotExist) {
+		t.Error("somme de contrôle d'une sauvegarde supprimée conservée")
+	}
+	if backups, _ := List(dir); len(backups) != 1 || filepath.Base(backups[0].Path) != names[0] {
+		t.Errorf("restantes = %+v, attendu la plus récente", backups)
+	}
+}
@@ -0,0 +1,38 @@
+package backup
+
+import (
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scheduler sauvegarde la base à intervalle régulier.
+type Scheduler struct {
+	db       *gorm.DB
+	opts     Options
+	interval time.Duration
+}
+
+// NewScheduler crée un planificateur de sauvegardes.
+func NewScheduler(db *gorm.DB, opts Options, interval time.Duration) *Scheduler {
+	return &Scheduler{db: db, opts: opts, interval: interval}
+}
+
+// Start sauvegarde la base à chaque intervalle, sans sauvegarde immédiate :
+// un redémarrage ne doit pas évincer de sauvegarde plus ancienne par rotation.
+func (s *Scheduler) Start() {
+	logger := slog.Default().With("component", "backup")
+	logger.Info("Sauvegardes planifiées", "interval", s.interval, "dir", s.opts.Dir)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result, err := Create(s.db, s.opts)
+		if err != nil {
+			logger.Error("Échec de la sauvegarde", "error", err)
+			continue
+		}
+		logger.Info("Sauvegarde créée", "path", result.Path, "size", result.Size, "removed", len(result.Removed))
+	}
+}
@@ -0,0 +1,122 @@
+// Package cache fournit un cache LRU en mémoire, borné en taille, dont les
+// entrées expirent après une durée de vie.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU est un cache de taille bornée : au-delà de la capacité, l'entrée la
+// moins récemment utilisée est évincée. Il est sûr en accès concurrent.
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Entrées de la plus récente à la plus ancienne
+	items    map[K]*list.Element
+	now      func() time.Time
+	onEvict  func(key K, value V)
+}
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// NewLRU crée un cache de capacity entrées au plus (minimum 1).
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &LRU[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[K]*list.Element, capacity),
+		now:      time.Now,
+	}
+}
+
+// Get retourne la valeur associée à key si elle est présente et non expirée.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := element.Value.(*lruEntry[K, V])
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(element)
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set enregistre la valeur pour la durée ttl, en évinçant si besoin l'entrée
+// la moins récemment utilisée.
+func (c *LRU[K, V]) Set(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*lruEntry[K, V])
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Delete retire key du cache.
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// OnEvict enregistre fn, appelée pour chaque entrée retirée du cache
+// (capacité, expiration ou Delete), sauf par Purge. fn est appelée sous le
+// verrou du cache et ne doit pas l'utiliser. À appeler avant toute utilisation.
+func (c *LRU[K, V]) OnEvict(fn func(key K, value V)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = fn
+}
+
+// Purge vide le cache.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[K]*list.Element, c.capacity)
+}
+
+// Len retourne le nombre d'entrées, expirées comprises tant qu'elles n'ont pas été évincées.
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	entry := element.Value.(*lruEntry[K, V])
+	delete(c.items, entry.key)
+	if c.onEvict != nil {
+		c.onEvict(entry.key, entry.value)
+	}
+}
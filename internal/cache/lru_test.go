@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2)
+	var evicted []string
+	c.OnEvict(func(key string, _ int) { evicted = append(evicted, key) })
+
+	c.Set("a", 1, time.Hour)
+	c.Set("b", 2, time.Hour)
+	c.Get("a") // "b" devient la moins récemment utilisée
+	c.Set("c", 3, time.Hour)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b aurait dû être évincée")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := c.Get(key); !ok || got != want {
+			t.Errorf("%s = %d, %v, attendu %d", key, got, ok, want)
+		}
+	}
+
+	// Une mise à jour ne crée pas d'entrée supplémentaire.
+	c.Set("a", 10, time.Hour)
+	if got, _ := c.Get("a"); got != 10 || c.Len() != 2 {
+		t.Errorf("a = %d avec %d entrées, attendu 10 avec 2 entrées", got, c.Len())
+	}
+
+	c.Delete("c")
+	c.Purge() // Sans rappel
+	if len(evicted) != 2 || evicted[0] != "b" || evicted[1] != "c" || c.Len() != 0 {
+		t.Errorf("évincées = %v, %d entrée(s) restante(s)", evicted, c.Len())
+	}
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU[string, int](10)
+	c.now = func() time.Time { return now }
+	var evicted []string
+	c.OnEvict(func(key string, _ int) { evicted = append(evicted, key) })
+
+	c.Set("short", 1, time.Minute)
+	c.Set("long", 2, time.Hour)
+	now = now.Add(time.Minute)
+
+	if _, ok := c.Get("short"); ok {
+		t.Error("entrée expirée retournée")
+	}
+	if _, ok := c.Get("long"); !ok {
+		t.Error("entrée valide absente")
+	}
+	if len(evicted) != 1 || evicted[0] != "short" || c.Len() != 1 {
+		t.Errorf("évincées = %v, %d entrée(s)", evicted, c.Len())
+	}
+}
+
+func TestLRUMinimumCapacity(t *testing.T) {
+	c := NewLRU[int, int](0)
+	c.Set(1, 1, time.Hour)
+	c.Set(2, 2, time.Hour)
+	if _, ok := c.Get(2); !ok || c.Len() != 1 {
+		t.Errorf("%d entrée(s), attendu la dernière seule", c.Len())
+	}
+}
@@ -2,7 +2,8 @@ package config
 
 import (
 	"fmt"
-	"log" // Pour logger les informations ou erreurs de chargement de config
+	"log/slog" // Pour logger les informations ou erreurs de chargement de config
+	"strings"
 
 	"github.com/spf13/viper" // La bibliothèque pour la gestion de configuration
 )
@@ -14,19 +15,118 @@ type Config struct {
 	Server struct {
 		Port    int    `mapstructure:"port"`
 		BaseURL string `mapstructure:"base_url"`
+		// Proxys autorisés à fournir l'IP client (X-Forwarded-For). Vide : aucun.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
+		BulkMaxItems   int      `mapstructure:"bulk_max_items"` // Nombre maximal de liens par création en lot
 	} `mapstructure:"server"`
 
 	Database struct {
-		Name string `mapstructure:"name"`
+		Driver string `mapstructure:"driver"` // sqlite, postgres ou mysql
+		DSN    string `mapstructure:"dsn"`    // Chaîne de connexion (facultative pour sqlite)
+		Name   string `mapstructure:"name"`   // Fichier SQLite utilisé sans DSN
+		// Pool de connexions
+		MaxOpenConns           int `mapstructure:"max_open_conns"`            // 0 = illimité
+		MaxIdleConns           int `mapstructure:"max_idle_conns"`            // Connexions inactives conservées
+		ConnMaxLifetimeMinutes int `mapstructure:"conn_max_lifetime_minutes"` // 0 = illimitée
+		// Réglages SQLite, appliqués à chaque connexion
+		SQLiteJournalMode   string `mapstructure:"sqlite_journal_mode"`    // WAL, DELETE, TRUNCATE, PERSIST, MEMORY ou OFF
+		SQLiteBusyTimeoutMs int    `mapstructure:"sqlite_busy_timeout_ms"` // Attente d'un verrou avant l'erreur « database is locked »
+		SQLiteSynchronous   string `mapstructure:"sqlite_synchronous"`     // OFF, NORMAL, FULL ou EXTRA
 	} `mapstructure:"database"`
 
+	ShortCode struct {
+		Strategy     string `mapstructure:"strategy"`      // random, sequential, words ou unambiguous
+		Length       int    `mapstructure:"length"`        // Longueur des codes aléatoires, minimale pour sequential
+		AlphabetSeed string `mapstructure:"alphabet_seed"` // Graine du mélange de l'alphabet séquentiel
+		// Allongement automatique des codes aléatoires selon le taux de collision
+		GrowthWindow    int     `mapstructure:"growth_window"`    // Nombre de créations observées (0 = désactivé)
+		GrowthThreshold float64 `mapstructure:"growth_threshold"` // Taux de collision déclenchant l'allongement
+	} `mapstructure:"shortcode"`
+
 	Analytics struct {
-		BufferSize int `mapstructure:"buffer_size"` // Taille du buffer de clics (channel)
+		BufferSize      int `mapstructure:"buffer_size"`       // Taille du buffer de clics (channel)
+		BatchSize       int `mapstructure:"batch_size"`        // Nombre maximal de clics insérés par requête
+		FlushIntervalMs int `mapstructure:"flush_interval_ms"` // Délai maximal avant l'écriture d'un lot incomplet
+		// En-tête portant le pays du client, ajouté par le proxy ou le CDN (vide = pays non collecté)
+		CountryHeader string `mapstructure:"country_header"`
 	} `mapstructure:"analytics"`
 
+	Redirect struct {
+		StatusCode  int `mapstructure:"status_code"`   // Code HTTP par défaut des redirections (301, 302, 307 ou 308)
+		CacheMaxAge int `mapstructure:"cache_max_age"` // Durée de cache en secondes des redirections (0 = no-store)
+	} `mapstructure:"redirect"`
+
+	Security struct {
+		UnlockSecret      string `mapstructure:"unlock_secret"`       // Clé HMAC des cookies de déverrouillage (aléatoire si vide)
+		UnlockTTLMinutes  int    `mapstructure:"unlock_ttl_minutes"`  // Durée de validité d'un déverrouillage
+		UnlockMaxAttempts int    `mapstructure:"unlock_max_attempts"` // Essais de mot de passe avant blocage temporaire
+	} `mapstructure:"security"`
+
+	Admin struct {
+		Username          string `mapstructure:"username"`            // Identifiant de connexion à l'interface /admin
+		PasswordHash      string `mapstructure:"password_hash"`       // Hash bcrypt du mot de passe (vide = interface désactivée)
+		SessionSecret     string `mapstructure:"session_secret"`      // Clé HMAC des cookies de session (aléatoire si vide)
+		SessionTTLMinutes int    `mapstructure:"session_ttl_minutes"` // Durée de validité d'une session
+	} `mapstructure:"admin"`
+
+	Policy struct {
+		AllowDomains    []string `mapstructure:"allow_domains"`     // Si non vide, seuls ces domaines sont acceptés
+		DenyDomains     []string `mapstructure:"deny_domains"`      // Domaines toujours refusés
+		BlockPrivateIPs bool     `mapstructure:"block_private_ips"` // Refus des destinations du réseau local
+		BlocklistFile   string   `mapstructure:"blocklist_file"`    // Liste de blocage (format hosts ou préfixes sha256:)
+	} `mapstructure:"policy"`
+
+	Dedup struct {
+		StripTrackingParams bool     `mapstructure:"strip_tracking_params"` // Ignore les paramètres de suivi pour comparer les URLs
+		TrackingParams      []string `mapstructure:"tracking_params"`       // Paramètres de suivi ('*' final = préfixe)
+	} `mapstructure:"dedup"`
+
+	Cache struct {
+		Backend            string `mapstructure:"backend"`              // memory (par instance) ou redis (partagé)
+		Size               int    `mapstructure:"size"`                 // Nombre maximal de codes en cache local (0 = désactivé)
+		TTLSeconds         int    `mapstructure:"ttl_seconds"`          // Durée de conservation d'un lien résolu
+		NegativeTTLSeconds int    `mapstructure:"negative_ttl_seconds"` // Durée de conservation d'un code inconnu
+		RedisAddr          string `mapstructure:"redis_addr"`           // Adresse host:port du serveur compatible Redis
+		RedisPassword      string `mapstructure:"redis_password"`
+		RedisDB            int    `mapstructure:"redis_db"`
+		RedisPrefix        string `mapstructure:"redis_prefix"` // Préfixe des clés et du canal d'invalidation
+	} `mapstructure:"cache"`
+
 	Monitor struct {
 		IntervalMinutes int `mapstructure:"interval_minutes"` // Intervalle de surveillance
 	} `mapstructure:"monitor"`
+
+	Logging struct {
+		Level     string `mapstructure:"level"`      // debug, info, warn ou error
+		Format    string `mapstructure:"format"`     // text ou json
+		AccessLog bool   `mapstructure:"access_log"` // Journal des redirections (IP, user agent, latence)
+	} `mapstructure:"logging"`
+
+	Tracing struct {
+		Exporter     string  `mapstructure:"exporter"`      // none, otlp, stdout ou file
+		OTLPEndpoint string  `mapstructure:"otlp_endpoint"` // URL du collecteur OTLP/HTTP (vide : variables OTEL_EXPORTER_OTLP_*)
+		File         string  `mapstructure:"file"`          // Fichier des spans (exportateur file), une ligne JSON par span
+		SampleRatio  float64 `mapstructure:"sample_ratio"`  // Part des traces conservées, de 0 à 1
+		ServiceName  string  `mapstructure:"service_name"`  // Attribut service.name des spans
+	} `mapstructure:"tracing"`
+
+	Metrics struct {
+		Enabled bool `mapstructure:"enabled"` // Exposition des métriques Prometheus sur /metrics
+	} `mapstructure:"metrics"`
+
+	Health struct {
+		TimeoutMs              int     `mapstructure:"timeout_ms"`               // Délai maximal de chaque vérification
+		QueueSaturation        float64 `mapstructure:"queue_saturation"`         // Remplissage du channel des clics au-delà duquel le service n'est plus prêt (0 à 1)
+		WorkerHeartbeatSeconds int     `mapstructure:"worker_heartbeat_seconds"` // Silence maximal de l'écrivain de clics
+	} `mapstructure:"health"`
+
+	Backup struct {
+		Dir             string `mapstructure:"dir"`              // Dossier des sauvegardes
+		Compress        bool   `mapstructure:"compress"`         // Compression gzip
+		Keep            int    `mapstructure:"keep"`             // Nombre de sauvegardes conservées (0 = illimité)
+		MaxAgeDays      int    `mapstructure:"max_age_days"`     // Âge maximal d'une sauvegarde (0 = illimité)
+		IntervalMinutes int    `mapstructure:"interval_minutes"` // Sauvegarde planifiée par le serveur (0 = désactivée)
+	} `mapstructure:"backup"`
 }
 
 // LoadConfig charge la configuration de l'application en utilisant Viper.
@@ -41,14 +141,72 @@ func LoadConfig() (*Config, error) {
 	// Valeurs par défaut
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080")
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("server.bulk_max_items", 100)
+	viper.SetDefault("database.driver", "sqlite")
+	viper.SetDefault("database.dsn", "")
 	viper.SetDefault("database.name", "urlshortener.db")
+	viper.SetDefault("database.max_open_conns", 0)
+	viper.SetDefault("database.max_idle_conns", 2)
+	viper.SetDefault("database.conn_max_lifetime_minutes", 0)
+	viper.SetDefault("database.sqlite_journal_mode", "WAL")
+	viper.SetDefault("database.sqlite_busy_timeout_ms", 5000)
+	viper.SetDefault("database.sqlite_synchronous", "NORMAL")
+	viper.SetDefault("shortcode.strategy", "random")
+	viper.SetDefault("shortcode.length", 6)
+	viper.SetDefault("shortcode.alphabet_seed", "")
+	viper.SetDefault("shortcode.growth_window", 100)
+	viper.SetDefault("shortcode.growth_threshold", 0.1)
 	viper.SetDefault("analytics.buffer_size", 100)
+	viper.SetDefault("analytics.batch_size", 100)
+	viper.SetDefault("analytics.flush_interval_ms", 200)
+	viper.SetDefault("analytics.country_header", "")
+	viper.SetDefault("redirect.status_code", 302)
+	viper.SetDefault("redirect.cache_max_age", 0)
+	viper.SetDefault("security.unlock_secret", "")
+	viper.SetDefault("security.unlock_ttl_minutes", 10)
+	viper.SetDefault("security.unlock_max_attempts", 5)
+	viper.SetDefault("admin.username", "admin")
+	viper.SetDefault("admin.password_hash", "")
+	viper.SetDefault("admin.session_secret", "")
+	viper.SetDefault("admin.session_ttl_minutes", 480)
+	viper.SetDefault("policy.allow_domains", []string{})
+	viper.SetDefault("policy.deny_domains", []string{})
+	viper.SetDefault("policy.block_private_ips", true)
+	viper.SetDefault("policy.blocklist_file", "")
+	viper.SetDefault("dedup.strip_tracking_params", true)
+	viper.SetDefault("dedup.tracking_params", []string{"utm_*", "fbclid", "gclid", "msclkid", "mc_cid", "mc_eid"})
+	viper.SetDefault("cache.backend", "memory")
+	viper.SetDefault("cache.size", 10000)
+	viper.SetDefault("cache.ttl_seconds", 300)
+	viper.SetDefault("cache.negative_ttl_seconds", 30)
+	viper.SetDefault("cache.redis_addr", "localhost:6379")
+	viper.SetDefault("cache.redis_password", "")
+	viper.SetDefault("cache.redis_db", 0)
+	viper.SetDefault("cache.redis_prefix", "urlshortener:")
 	viper.SetDefault("monitor.interval_minutes", 5)
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "text")
+	viper.SetDefault("logging.access_log", true)
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.otlp_endpoint", "http://localhost:4318")
+	viper.SetDefault("tracing.file", "traces.jsonl")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.service_name", "urlshortener")
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("health.timeout_ms", 2000)
+	viper.SetDefault("health.queue_saturation", 0.9)
+	viper.SetDefault("health.worker_heartbeat_seconds", 30)
+	viper.SetDefault("backup.dir", "backups")
+	viper.SetDefault("backup.compress", true)
+	viper.SetDefault("backup.keep", 7)
+	viper.SetDefault("backup.max_age_days", 30)
+	viper.SetDefault("backup.interval_minutes", 0)
 
 	// Lecture du fichier config.yaml
 	err := viper.ReadInConfig()
 	if err != nil {
-		fmt.Println("⚠️  Fichier config.yaml non trouvé ou invalide, utilisation des valeurs par défaut.")
+		slog.Warn("Fichier config.yaml non trouvé ou invalide, utilisation des valeurs par défaut", "error", err)
 	}
 
 	var cfg Config
@@ -56,9 +214,108 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("erreur lors du déchargement de la config : %w", err)
 	}
 
+	// La colonne shortcode est limitée à 10 caractères.
+	if cfg.ShortCode.Length < 4 || cfg.ShortCode.Length > 10 {
+		slog.Warn("Longueur de code hors limites (4 à 10), utilisation de 6", "length", cfg.ShortCode.Length)
+		cfg.ShortCode.Length = 6
+	}
+
+	switch cfg.Redirect.StatusCode {
+	case 301, 302, 307, 308:
+	default:
+		slog.Warn("Code de redirection non supporté, utilisation de 302", "status_code", cfg.Redirect.StatusCode)
+		cfg.Redirect.StatusCode = 302
+	}
+	switch cfg.Cache.Backend {
+	case "memory", "redis":
+	default:
+		slog.Warn("Backend de cache inconnu, utilisation de memory", "backend", cfg.Cache.Backend)
+		cfg.Cache.Backend = "memory"
+	}
+
+	cfg.Database.SQLiteJournalMode = strings.ToUpper(cfg.Database.SQLiteJournalMode)
+	switch cfg.Database.SQLiteJournalMode {
+	case "WAL", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "OFF":
+	default:
+		slog.Warn("Mode de journal SQLite inconnu, utilisation de WAL", "journal_mode", cfg.Database.SQLiteJournalMode)
+		cfg.Database.SQLiteJournalMode = "WAL"
+	}
+	cfg.Database.SQLiteSynchronous = strings.ToUpper(cfg.Database.SQLiteSynchronous)
+	switch cfg.Database.SQLiteSynchronous {
+	case "OFF", "NORMAL", "FULL", "EXTRA":
+	default:
+		slog.Warn("Niveau synchronous SQLite inconnu, utilisation de NORMAL", "synchronous", cfg.Database.SQLiteSynchronous)
+		cfg.Database.SQLiteSynchronous = "NORMAL"
+	}
+	cfg.Logging.Level = strings.ToLower(cfg.Logging.Level)
+	switch cfg.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		slog.Warn("Niveau de log inconnu, utilisation de info", "level", cfg.Logging.Level)
+		cfg.Logging.Level = "info"
+	}
+	cfg.Logging.Format = strings.ToLower(cfg.Logging.Format)
+	switch cfg.Logging.Format {
+	case "text", "json":
+	default:
+		slog.Warn("Format de log inconnu, utilisation de text", "format", cfg.Logging.Format)
+		cfg.Logging.Format = "text"
+	}
+	cfg.Tracing.Exporter = strings.ToLower(cfg.Tracing.Exporter)
+	switch cfg.Tracing.Exporter {
+	case "none", "otlp", "stdout", "file":
+	default:
+		slog.Warn("Exportateur de traces inconnu, traces désactivées", "exporter", cfg.Tracing.Exporter)
+		cfg.Tracing.Exporter = "none"
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		slog.Warn("Taux d'échantillonnage hors limites (0 à 1), utilisation de 1", "sample_ratio", cfg.Tracing.SampleRatio)
+		cfg.Tracing.SampleRatio = 1
+	}
+	// Un lot est créé dans une seule transaction : sa taille reste bornée.
+	if cfg.Server.BulkMaxItems < 1 || cfg.Server.BulkMaxItems > 10000 {
+		slog.Warn("Taille maximale des lots hors limites (1 à 10000), utilisation de 100", "bulk_max_items", cfg.Server.BulkMaxItems)
+		cfg.Server.BulkMaxItems = 100
+	}
+	if cfg.Security.UnlockTTLMinutes < 1 {
+		slog.Warn("Durée de déverrouillage hors limites (au moins 1 minute), utilisation de 10", "unlock_ttl_minutes", cfg.Security.UnlockTTLMinutes)
+		cfg.Security.UnlockTTLMinutes = 10
+	}
+	if cfg.Security.UnlockMaxAttempts < 1 {
+		slog.Warn("Nombre d'essais de mot de passe hors limites (au moins 1), utilisation de 5", "unlock_max_attempts", cfg.Security.UnlockMaxAttempts)
+		cfg.Security.UnlockMaxAttempts = 5
+	}
+	if cfg.Admin.SessionTTLMinutes < 1 {
+		cfg.Admin.SessionTTLMinutes = 480
+	}
+	if cfg.Health.TimeoutMs < 1 {
+		cfg.Health.TimeoutMs = 2000
+	}
+	if cfg.Health.QueueSaturation <= 0 || cfg.Health.QueueSaturation > 1 {
+		slog.Warn("Seuil de saturation hors limites (0 à 1), utilisation de 0.9", "queue_saturation", cfg.Health.QueueSaturation)
+		cfg.Health.QueueSaturation = 0.9
+	}
+	if cfg.Health.WorkerHeartbeatSeconds < 1 {
+		cfg.Health.WorkerHeartbeatSeconds = 30
+	}
+	if cfg.Analytics.BatchSize < 1 {
+		cfg.Analytics.BatchSize = 1
+	}
+	if cfg.Analytics.FlushIntervalMs < 1 {
+		cfg.Analytics.FlushIntervalMs = 200
+	}
+
+	if cfg.Redirect.CacheMaxAge < 0 {
+		cfg.Redirect.CacheMaxAge = 0
+	}
+
 	// Log de vérification
-	log.Printf("✅ Configuration loaded: Server Port=%d, DB=%s, Buffer=%d, Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
+	database := cfg.Database.Name
+	if cfg.Database.Driver != "sqlite" {
+		database = cfg.Database.Driver
+	}
+	slog.Info("Configuration chargée", "port", cfg.Server.Port, "database", database,
+		"buffer_size", cfg.Analytics.BufferSize, "monitor_interval_minutes", cfg.Monitor.IntervalMinutes)
 
 	return &cfg, nil
 }
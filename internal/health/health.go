@@ -0,0 +1,218 @@
+// Package health vérifie l'état du service : vivacité (le processus et ses
+// workers tournent) et disponibilité (il peut servir des requêtes).
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"gorm.io/gorm"
+)
+
+// États d'une vérification et du rapport.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn" // Vérification informative en échec, sans effet sur le rapport
+	StatusFail = "fail"
+)
+
+// Check vérifie un composant et retourne les détails de son état.
+type Check func(ctx context.Context) (map[string]any, error)
+
+// Result est l'état d'un composant.
+type Result struct {
+	Status   string         `json:"status"`
+	Error    string         `json:"error,omitempty"`
+	Duration string         `json:"duration"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// Report est l'état du service, composant par composant.
+type Report struct {
+	Status     string            `json:"status"`
+	Components map[string]Result `json:"components"`
+}
+
+// OK indique si tous les composants vérifiés sont opérationnels.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+	live  bool
+	info  bool
+}
+
+// Checker regroupe les vérifications du service. Les vérifications de
+// vivacité font aussi partie de la disponibilité.
+type Checker struct {
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  []namedCheck
+}
+
+// NewChecker crée un Checker dont chaque vérification est limitée à timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Live ajoute une vérification de vivacité : en échec, le processus doit être redémarré.
+func (c *Checker) Live(name string, check Check) {
+	c.add(namedCheck{name: name, check: check, live: true})
+}
+
+// Ready ajoute une vérification de disponibilité : en échec, le service ne
+// doit plus recevoir de trafic.
+func (c *Checker) Ready(name string, check Check) {
+	c.add(namedCheck{name: name, check: check})
+}
+
+// Info ajoute une vérification informative, exécutée avec la disponibilité :
+// en échec, elle est signalée par le statut warn sans rendre le service indisponible.
+func (c *Checker) Info(name string, check Check) {
+	c.add(namedCheck{name: name, check: check, info: true})
+}
+
+func (c *Checker) add(check namedCheck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check)
+}
+
+// Liveness exécute les vérifications de vivacité.
+func (c *Checker) Liveness(ctx context.Context) Report {
+	return c.run(ctx, true)
+}
+
+// Readiness exécute toutes les vérifications.
+func (c *Checker) Readiness(ctx context.Context) Report {
+	return c.run(ctx, false)
+}
+
+// run exécute les vérifications en parallèle.
+func (c *Checker) run(ctx context.Context, liveOnly bool) Report {
+	c.mu.RLock()
+	var checks []namedCheck
+	for _, check := range c.checks {
+		if check.live || !liveOnly {
+			checks = append(checks, check)
+		}
+	}
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Components: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.runCheck(ctx, check.check)
+			if check.info && result.Status == StatusFail {
+				result.Status = StatusWarn
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[check.name] = result
+			if result.Status == StatusFail {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) runCheck(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		details, err := check(ctx)
+		result := Result{Status: StatusOK, Details: details}
+		if err != nil {
+			result.Status, result.Error = StatusFail, err.Error()
+		}
+		done <- result
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Status: StatusFail, Error: fmt.Sprintf("pas de réponse après %v", c.timeout)}
+	}
+	result.Duration = time.Since(start).String()
+	return result
+}
+
+// Database vérifie que la base répond.
+func Database(db *gorm.DB) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return nil, err
+		}
+		stats := sqlDB.Stats()
+		return map[string]any{"open_connections": stats.OpenConnections, "in_use": stats.InUse}, nil
+	}
+}
+
+// Schema vérifie que le schéma de la base est à la version attendue par le binaire.
+func Schema(runner *migrations.Runner) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		current, err := runner.Current()
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]any{"current": current, "expected": migrations.Latest()}
+		return details, runner.Check()
+	}
+}
+
+// Queue vérifie que le remplissage d'une file reste sous maxSaturation (de 0 à 1).
+func Queue(size func() (length, capacity int), maxSaturation float64) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		length, capacity := size()
+		saturation := 1.0
+		if capacity > 0 {
+			saturation = float64(length) / float64(capacity)
+		}
+		details := map[string]any{"length": length, "capacity": capacity, "saturation": saturation}
+		if saturation >= maxSaturation {
+			return details, fmt.Errorf("file saturée à %.0f %% (seuil %.0f %%)", saturation*100, maxSaturation*100)
+		}
+		return details, nil
+	}
+}
+
+// ErrStopped signale un worker arrêté ou pas encore démarré.
+var ErrStopped = errors.New("arrêté ou pas encore démarré")
+
+// Heartbeat vérifie que le dernier signe de vie d'un worker date de moins de
+// maxAge. Un instant nul signale un worker arrêté.
+func Heartbeat(last func() time.Time, maxAge time.Duration) Check {
+	return func(ctx context.Context) (map[string]any, error) {
+		at := last()
+		if at.IsZero() {
+			return nil, ErrStopped
+		}
+		age := time.Since(at)
+		details := map[string]any{"last_seen": at.UTC().Format(time.RFC3339), "age": age.Round(time.Millisecond).String()}
+		if age > maxAge {
+			return details, fmt.Errorf("aucun signe de vie depuis %v (seuil %v)", age.Round(time.Second), maxAge)
+		}
+		return details, nil
+	}
+}
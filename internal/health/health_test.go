@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInfoCheckDoesNotFailReadiness(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Ready("database", func(context.Context) (map[string]any, error) { return nil, nil })
+	checker.Info("monitor", func(context.Context) (map[string]any, error) { return nil, errors.New("en retard") })
+
+	report := checker.Readiness(context.Background())
+	if !report.OK() {
+		t.Errorf("statut = %s, attendu %s", report.Status, StatusOK)
+	}
+	if got := report.Components["monitor"]; got.Status != StatusWarn || got.Error != "en retard" {
+		t.Errorf("moniteur = %+v, attendu le statut %s et l'erreur", got, StatusWarn)
+	}
+	if _, ok := checker.Liveness(context.Background()).Components["monitor"]; ok {
+		t.Error("une vérification informative ne fait pas partie de la vivacité")
+	}
+
+	checker.Ready("schema", func(context.Context) (map[string]any, error) { return nil, errors.New("en retard") })
+	if checker.Readiness(context.Background()).OK() {
+		t.Error("une vérification de disponibilité en échec doit rendre le service indisponible")
+	}
+}
@@ -0,0 +1,145 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
+	"golang.org/x/net/html"
+)
+
+// maxBodySize limite la quantité de HTML lue pour extraire les métadonnées :
+// les balises utiles se trouvent dans le <head>.
+const maxBodySize = 1 << 20
+
+// PageMetadata décrit une page de destination telle qu'affichée dans un aperçu.
+type PageMetadata struct {
+	Title       string
+	Description string
+	FaviconURL  string
+	ImageURL    string // Image Open Graph (og:image)
+}
+
+// client ne se connecte jamais au réseau local, redirections comprises : une
+// destination publique ne peut pas faire lire une adresse interne au serveur.
+var client = policy.NewHTTPClient(5 * time.Second)
+
+// Fetch télécharge la page et en extrait le titre, la description, le favicon
+// et l'image Open Graph. Les URLs relatives sont résolues par rapport à l'URL finale.
+func Fetch(ctx context.Context, pageURL string) (*PageMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("requête invalide : %w", err)
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "url-shortener-metadata/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("statut HTTP inattendu : %d", resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, fmt.Errorf("type de contenu non HTML : %q", mediaType)
+	}
+
+	return parse(io.LimitReader(resp.Body, maxBodySize), resp.Request.URL), nil
+}
+
+// parse parcourt le HTML jusqu'à la fin du <head> en relevant les balises utiles.
+func parse(r io.Reader, base *url.URL) *PageMetadata {
+	meta := &PageMetadata{}
+	var ogTitle, ogDescription string
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finalize(meta, ogTitle, ogDescription, base)
+		case html.TextToken:
+			if inTitle && meta.Title == "" {
+				meta.Title = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return finalize(meta, ogTitle, ogDescription, base)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				return finalize(meta, ogTitle, ogDescription, base)
+			case "meta":
+				content := strings.TrimSpace(attrs["content"])
+				switch strings.ToLower(attrs["property"] + attrs["name"]) {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDescription = content
+				case "og:image":
+					meta.ImageURL = content
+				case "description":
+					meta.Description = content
+				}
+			case "link":
+				for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+					if rel == "icon" && meta.FaviconURL == "" {
+						meta.FaviconURL = attrs["href"]
+					}
+				}
+			}
+		}
+	}
+}
+
+// finalize privilégie les valeurs Open Graph et rend les URLs absolues.
+func finalize(meta *PageMetadata, ogTitle, ogDescription string, base *url.URL) *PageMetadata {
+	if ogTitle != "" {
+		meta.Title = ogTitle
+	}
+	if ogDescription != "" {
+		meta.Description = ogDescription
+	}
+	if meta.FaviconURL == "" {
+		meta.FaviconURL = "/favicon.ico"
+	}
+	meta.FaviconURL = resolve(base, meta.FaviconURL)
+	meta.ImageURL = resolve(base, meta.ImageURL)
+	return meta
+}
+
+// resolve rend une URL absolue et écarte tout ce qui n'est pas http(s).
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startedAtKey = "metrics:started_at"
+
+// GormPlugin alimente DBQueryDuration depuis les callbacks de GORM.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("metrics:before_create", startTimer),
+		cb.Create().After("*").Register("metrics:after_create", observe("create")),
+		cb.Query().Before("*").Register("metrics:before_query", startTimer),
+		cb.Query().After("*").Register("metrics:after_query", observe("query")),
+		cb.Update().Before("*").Register("metrics:before_update", startTimer),
+		cb.Update().After("*").Register("metrics:after_update", observe("update")),
+		cb.Delete().Before("*").Register("metrics:before_delete", startTimer),
+		cb.Delete().After("*").Register("metrics:after_delete", observe("delete")),
+		cb.Row().Before("*").Register("metrics:before_row", startTimer),
+		cb.Row().After("*").Register("metrics:after_row", observe("row")),
+		cb.Raw().Before("*").Register("metrics:before_raw", startTimer),
+		cb.Raw().After("*").Register("metrics:after_raw", observe("raw")),
+	)
+}
+
+func startTimer(tx *gorm.DB) {
+	tx.InstanceSet(startedAtKey, time.Now())
+}
+
+func observe(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		if startedAt, ok := tx.InstanceGet(startedAtKey); ok {
+			DBQueryDuration.WithLabelValues(operation).Observe(time.Since(startedAt.(time.Time)).Seconds())
+		}
+	}
+}
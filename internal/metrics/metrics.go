@@ -0,0 +1,89 @@
+// Package metrics regroupe les métriques Prometheus du service, exposées au
+// format texte sur /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "urlshortener"
+
+// Intervalles des durées courtes (requêtes SQL, écriture d'un lot) : de 0,5 ms à 4 s.
+var fastBuckets = prometheus.ExponentialBuckets(0.0005, 2, 14)
+
+var (
+	// Redirects compte les réponses des routes de redirection, par code HTTP.
+	Redirects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Réponses des routes de redirection, par code HTTP.",
+	}, []string{"status"})
+
+	// LinksCreated compte les liens créés (hors liens existants réutilisés), par API.
+	LinksCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "links_created_total",
+		Help:      "Liens créés, par API (single ou bulk).",
+	}, []string{"api"})
+
+	// ClickEventsEnqueued compte les clics publiés dans le channel des clics.
+	ClickEventsEnqueued = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_enqueued_total",
+		Help:      "Clics publiés dans le channel des clics.",
+	})
+
+	// ClickEventsDropped compte les clics perdus, le channel étant plein.
+	ClickEventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_dropped_total",
+		Help:      "Clics perdus, le channel des clics étant plein.",
+	})
+
+	// ClickEventsPersisted compte les clics enregistrés en base, par résultat.
+	ClickEventsPersisted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_persisted_total",
+		Help:      "Clics traités par l'écrivain, par résultat (success ou failure).",
+	}, []string{"result"})
+
+	// ClickFlushDuration mesure l'écriture d'un lot de clics.
+	ClickFlushDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "click_flush_duration_seconds",
+		Help:      "Durée d'écriture d'un lot de clics, agrégats compris.",
+		Buckets:   fastBuckets,
+	})
+
+	// MonitorCheckDuration mesure la vérification d'une URL longue, par résultat.
+	MonitorCheckDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "monitor_check_duration_seconds",
+		Help:      "Durée de vérification d'une URL longue, par résultat (accessible, inaccessible ou blocked).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	// CacheRequests compte les résolutions de codes courts par les caches.
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "link_cache_requests_total",
+		Help:      "Résolutions de codes courts par cache (memory ou redis) et résultat (hit, miss ou error).",
+	}, []string{"cache", "result"})
+
+	// DBQueryDuration mesure les requêtes SQL, par opération GORM.
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Durée des requêtes SQL, par opération (create, query, update, delete, row ou raw).",
+		Buckets:   fastBuckets,
+	}, []string{"operation"})
+)
+
+// Handler sert les métriques au format texte de Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
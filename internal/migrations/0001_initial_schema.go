@@ -0,0 +1,96 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Schéma de la version 1, figé : il reprend les tables créées jusqu'ici par
+// AutoMigrate à partir des modèles. Comme AutoMigrate ne fait qu'ajouter ce
+// qui manque, cette migration crée une base vierge comme elle adopte une base
+// existante, quelle que soit la version du binaire qui l'a créée.
+
+type linkV1 struct {
+	ID                uint   `gorm:"primaryKey"`
+	ShortCode         string `gorm:"column:shortcode;type:varchar(10);uniqueIndex;not null"`
+	LongURL           string `gorm:"type:text;not null"`
+	CanonicalURLHash  string `gorm:"type:char(64);index;not null;default:''"`
+	ForwardQuery      string `gorm:"type:varchar(10);not null;default:''"`
+	ForwardPath       bool   `gorm:"not null;default:false"`
+	CampaignID        *uint  `gorm:"index"`
+	RedirectStatus    int    `gorm:"not null;default:0"`
+	CacheMaxAge       *int
+	PasswordHash      string `gorm:"type:varchar(72);not null;default:''"`
+	Title             string `gorm:"type:varchar(255);not null;default:''"`
+	Description       string `gorm:"type:text;not null"`
+	FaviconURL        string `gorm:"type:text;not null"`
+	ImageURL          string `gorm:"type:text;not null"`
+	MetadataFetchedAt *time.Time
+	Disabled          bool   `gorm:"not null;default:false"`
+	DisabledReason    string `gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt         time.Time
+}
+
+func (linkV1) TableName() string { return "links" }
+
+type clickV1 struct {
+	ID        uint   `gorm:"primaryKey"`
+	LinkID    uint   `gorm:"index"`
+	Link      linkV1 `gorm:"foreignKey:LinkID"`
+	Timestamp time.Time
+	UserAgent string `gorm:"size:255"`
+	IPAddress string `gorm:"size:50"`
+}
+
+func (clickV1) TableName() string { return "clicks" }
+
+type campaignV1 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(255);uniqueIndex;not null"`
+	CreatedAt time.Time
+}
+
+func (campaignV1) TableName() string { return "campaigns" }
+
+type sequenceV1 struct {
+	Name  string `gorm:"primaryKey;type:varchar(50)"`
+	Value uint64 `gorm:"not null"`
+}
+
+func (sequenceV1) TableName() string { return "sequences" }
+
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			if err := addLinkTextColumns(tx); err != nil {
+				return err
+			}
+			return tx.AutoMigrate(&linkV1{}, &clickV1{}, &campaignV1{}, &sequenceV1{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&clickV1{}, &linkV1{}, &campaignV1{}, &sequenceV1{})
+		},
+		DestroysData: true,
+	})
+}
+
+// addLinkTextColumns ajoute à une table links antérieure les colonnes text
+// NOT NULL sans valeur par défaut, qu'AutoMigrate ne sait pas y ajouter : hors
+// MySQL, les lignes existantes exigent une valeur par défaut explicite.
+func addLinkTextColumns(tx *gorm.DB) error {
+	if tx.Dialector.Name() == "mysql" || !tx.Migrator().HasTable(&linkV1{}) {
+		return nil
+	}
+	for _, column := range []string{"description", "favicon_url", "image_url"} {
+		if tx.Migrator().HasColumn(&linkV1{}, column) {
+			continue
+		}
+		if err := tx.Exec("ALTER TABLE links ADD COLUMN " + column + " text NOT NULL DEFAULT ''").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
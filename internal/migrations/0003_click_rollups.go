@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Version 3 : origine et pays des clics, agrégats horaires et journaliers.
+// Les agrégats des clics existants sont calculés par la commande migrate une
+// fois le schéma à jour (voir rollup rebuild).
+
+type clickV3 struct {
+	Referrer string `gorm:"type:varchar(255);not null;default:''"`
+	Country  string `gorm:"type:char(2);not null;default:''"`
+}
+
+func (clickV3) TableName() string { return "clicks" }
+
+type clickRollupV3 struct {
+	LinkID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	Bucket    time.Time `gorm:"primaryKey"`
+	Dimension string    `gorm:"primaryKey;type:varchar(16)"`
+	Value     string    `gorm:"primaryKey;type:varchar(255)"`
+	Clicks    int64     `gorm:"not null"`
+}
+
+var rollupTablesV3 = []string{"click_rollups_hourly", "click_rollups_daily"}
+
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "click_rollups",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"Referrer", "Country"} {
+				if err := tx.Migrator().AddColumn(&clickV3{}, column); err != nil {
+					return err
+				}
+			}
+			for _, table := range rollupTablesV3 {
+				if err := tx.Table(table).Migrator().CreateTable(&clickRollupV3{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, table := range rollupTablesV3 {
+				if err := tx.Migrator().DropTable(table); err != nil {
+					return err
+				}
+			}
+			for _, column := range []string{"Referrer", "Country"} {
+				if err := tx.Migrator().DropColumn(&clickV3{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		DestroysData: true,
+	})
+}
@@ -0,0 +1,310 @@
+// Package migrations fait évoluer le schéma de la base par migrations
+// versionnées, appliquées dans l'ordre et enregistrées dans la table
+// schema_migrations.
+//
+// Une migration est écrite en Go (fichier NNNN_nom.go qui appelle register
+// dans son init) ou en SQL (fichiers embarqués sql/NNNN_nom[.pilote].up.sql
+// et .down.sql). Les migrations Go ne doivent pas utiliser les structures de
+// internal/models, qui décrivent le schéma courant, mais leur propre copie
+// figée du schéma à la version concernée.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSchemaMismatch signale une base qui n'est pas à la version attendue par le binaire.
+var ErrSchemaMismatch = errors.New("schéma de la base différent de celui attendu")
+
+// DataLossError refuse un plan qui annule des migrations supprimant des données.
+type DataLossError struct {
+	Migrations []Migration // Migrations destructives du plan, dans l'ordre d'exécution
+}
+
+func (e *DataLossError) Error() string {
+	versions := make([]int, len(e.Migrations))
+	for i, m := range e.Migrations {
+		versions[i] = m.Version
+	}
+	return fmt.Sprintf("l'annulation des migrations %v supprime des données", versions)
+}
+
+// Migration est une évolution du schéma. Down annule exactement Up.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+	// DestroysData signale un Down qui supprime des tables ou des colonnes, et
+	// donc des données que Up ne restaure pas.
+	DestroysData bool
+}
+
+// schemaMigration est une ligne de la table de suivi.
+type schemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+var registered []Migration
+
+// register ajoute une migration ; deux migrations ne peuvent partager une version.
+func register(m Migration) {
+	for _, existing := range registered {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migrations : version %d déclarée deux fois (%s, %s)", m.Version, existing.Name, m.Name))
+		}
+	}
+	registered = append(registered, m)
+	slices.SortFunc(registered, func(a, b Migration) int { return a.Version - b.Version })
+}
+
+// All retourne les migrations connues, par version croissante.
+func All() []Migration {
+	return slices.Clone(registered)
+}
+
+// Latest retourne la version la plus récente connue du binaire.
+func Latest() int {
+	if len(registered) == 0 {
+		return 0
+	}
+	return registered[len(registered)-1].Version
+}
+
+// Status décrit l'état d'une migration dans la base.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Unknown   bool // Appliquée en base mais absente du binaire (binaire plus ancien que la base)
+}
+
+// Runner applique les migrations à une base.
+type Runner struct {
+	db *gorm.DB
+}
+
+// NewRunner crée un Runner sur db et la table de suivi si besoin.
+func NewRunner(db *gorm.DB) (*Runner, error) {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("création de la table schema_migrations : %w", err)
+	}
+	return &Runner{db: db}, nil
+}
+
+func (r *Runner) applied() (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := r.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Current retourne la plus haute version appliquée, 0 pour une base vierge.
+func (r *Runner) Current() (int, error) {
+	var version int
+	err := r.db.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Status retourne l'état de chaque migration, connue ou seulement présente en base.
+func (r *Runner) Status() ([]Status, error) {
+	applied, err := r.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, m := range registered {
+		status := Status{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			status.Applied, status.AppliedAt = true, row.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: row.AppliedAt, Unknown: true})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return a.Version - b.Version })
+	return statuses, nil
+}
+
+// Check vérifie que toutes les migrations du binaire, et seulement elles, sont appliquées.
+func (r *Runner) Check() error {
+	statuses, err := r.Status()
+	if err != nil {
+		return err
+	}
+	var pending, unknown []int
+	for _, status := range statuses {
+		switch {
+		case status.Unknown:
+			unknown = append(unknown, status.Version)
+		case !status.Applied:
+			pending = append(pending, status.Version)
+		}
+	}
+	switch {
+	case len(unknown) > 0:
+		return fmt.Errorf("%w : version(s) %v appliquée(s) par un binaire plus récent", ErrSchemaMismatch, unknown)
+	case len(pending) > 0:
+		return fmt.Errorf("%w : migration(s) %v en attente, exécutez 'migrate up'", ErrSchemaMismatch, pending)
+	}
+	return nil
+}
+
+// Up applique toutes les migrations en attente.
+func (r *Runner) Up() ([]Migration, error) {
+	return r.To(Latest())
+}
+
+// Down annule les steps dernières migrations appliquées.
+func (r *Runner) Down(steps int) ([]Migration, error) {
+	target, err := r.DownTarget(steps)
+	if err != nil {
+		return nil, err
+	}
+	return r.To(target)
+}
+
+// DownTarget retourne la version atteinte en annulant les steps dernières
+// migrations appliquées.
+func (r *Runner) DownTarget(steps int) (int, error) {
+	applied, err := r.applied()
+	if err != nil {
+		return 0, err
+	}
+	var versions []int
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+
+	if steps < len(versions) {
+		return versions[len(versions)-steps-1], nil
+	}
+	return 0, nil
+}
+
+// Plan retourne, dans leur ordre d'exécution, les migrations que To(version)
+// appliquerait (up) puis annulerait (down), sans rien exécuter.
+func (r *Runner) Plan(version int) (up, down []Migration, err error) {
+	if version != 0 && !slices.ContainsFunc(registered, func(m Migration) bool { return m.Version == version }) {
+		return nil, nil, fmt.Errorf("version de migration inconnue : %d", version)
+	}
+	applied, err := r.applied()
+	if err != nil {
+		return nil, nil, err
+	}
+	for v := range applied {
+		if v > version && !slices.ContainsFunc(registered, func(m Migration) bool { return m.Version == v }) {
+			return nil, nil, fmt.Errorf("%w : la version %d appliquée en base est inconnue de ce binaire", ErrSchemaMismatch, v)
+		}
+	}
+
+	// Montée : migrations en attente jusqu'à version, dans l'ordre croissant.
+	for _, m := range registered {
+		if _, ok := applied[m.Version]; !ok && m.Version <= version {
+			up = append(up, m)
+		}
+	}
+	// Descente : migrations appliquées au-delà de version, dans l'ordre décroissant.
+	for i := len(registered) - 1; i >= 0; i-- {
+		if _, ok := applied[registered[i].Version]; ok && registered[i].Version > version {
+			down = append(down, registered[i])
+		}
+	}
+	return up, down, nil
+}
+
+// To applique ou annule les migrations pour amener la base à la version
+// demandée. Chaque migration s'exécute dans sa propre transaction (MySQL
+// valide toutefois implicitement les instructions DDL) ; la première erreur
+// arrête le processus, les migrations précédentes restant acquises. Les
+// migrations retournées sont celles effectivement exécutées.
+func (r *Runner) To(version int) ([]Migration, error) {
+	up, down, err := r.Plan(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range up {
+		if err := r.run(m, true); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	for _, m := range down {
+		if err := r.run(m, false); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Migrate amène la base à version comme To mais, sans force, refuse avant
+// toute exécution un plan qui annule une migration DestroysData, en
+// retournant une *DataLossError.
+func (r *Runner) Migrate(version int, force bool) ([]Migration, error) {
+	if !force {
+		_, down, err := r.Plan(version)
+		if err != nil {
+			return nil, err
+		}
+		var destructive []Migration
+		for _, m := range down {
+			if m.DestroysData {
+				destructive = append(destructive, m)
+			}
+		}
+		if len(destructive) > 0 {
+			return nil, &DataLossError{Migrations: destructive}
+		}
+	}
+	return r.To(version)
+}
+
+func (r *Runner) run(m Migration, up bool) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if up {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		}
+		if m.Down == nil {
+			return errors.New("migration irréversible")
+		}
+		if err := m.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&schemaMigration{}, m.Version).Error
+	})
+	if err != nil {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+		return fmt.Errorf("migration %04d_%s (%s) : %w", m.Version, m.Name, direction, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package migrations
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRunner crée un Runner sur une base SQLite en mémoire vierge.
+func newTestRunner(t *testing.T) (*gorm.DB, *Runner) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("ouverture base : %v", err)
+	}
+	// Chaque connexion à ":memory:" ouvre une base distincte.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	runner, err := NewRunner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, runner
+}
+
+// versions retourne les versions des migrations, dans leur ordre.
+func versions(migrations []Migration) []int {
+	result := make([]int, len(migrations))
+	for i, m := range migrations {
+		result[i] = m.Version
+	}
+	return result
+}
+
+// assertVersion vérifie la version courante de la base.
+func assertVersion(t *testing.T, runner *Runner, want int) {
+	t.Helper()
+	if got, err := runner.Current(); err != nil || got != want {
+		t.Fatalf("version = %d, %v, attendu %d", got, err, want)
+	}
+}
+
+func TestUpAndDownOnSQLite(t *testing.T) {
+	db, runner := newTestRunner(t)
+	if !errors.Is(runner.Check(), ErrSchemaMismatch) {
+		t.Error("base vierge considérée à jour")
+	}
+
+	done, err := runner.Up()
+	if err != nil {
+		t.Fatalf("up : %v", err)
+	}
+	if got := versions(done); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("migrations appliquées = %v", got)
+	}
+	assertVersion(t, runner, Latest())
+	if err := runner.Check(); err != nil {
+		t.Errorf("après up : %v", err)
+	}
+	for _, table := range []string{"links", "clicks", "campaigns", "sequences", "click_rollups_hourly", "click_rollups_daily"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s absente après up", table)
+		}
+	}
+	if !db.Migrator().HasColumn(&clickV3{}, "Country") {
+		t.Error("colonne clicks.country absente après up")
+	}
+	if done, err := runner.Up(); err != nil || len(done) != 0 {
+		t.Errorf("second up : %v, %v, attendu aucune migration", versions(done), err)
+	}
+
+	// Descente d'une version : la migration 3 est annulée.
+	if done, err := runner.Down(1); err != nil || !slices.Equal(versions(done), []int{3}) {
+		t.Fatalf("down : %v, %v", versions(done), err)
+	}
+	assertVersion(t, runner, 2)
+	if db.Migrator().HasTable("click_rollups_hourly") || db.Migrator().HasColumn(&clickV3{}, "Country") {
+		t.Error("schéma de la version 3 encore présent après down")
+	}
+	if !errors.Is(runner.Check(), ErrSchemaMismatch) {
+		t.Error("migration en attente non signalée")
+	}
+
+	// Retour à la base vierge puis remontée complète.
+	if done, err := runner.To(0); err != nil || !slices.Equal(versions(done), []int{2, 1}) {
+		t.Fatalf("to 0 : %v, %v", versions(done), err)
+	}
+	if db.Migrator().HasTable("links") || db.Migrator().HasTable("clicks") {
+		t.Error("tables encore présentes en version 0")
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("remontée : %v", err)
+	}
+	assertVersion(t, runner, Latest())
+}
+
+func TestPlanAndDownTarget(t *testing.T) {
+	db, runner := newTestRunner(t)
+	if _, err := runner.To(2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version  int
+		up, down []int
+	}{
+		{version: 3, up: []int{3}},
+		{version: 2},
+		{version: 1, down: []int{2}},
+		{version: 0, down: []int{2, 1}},
+	}
+	for _, tt := range tests {
+		up, down, err := runner.Plan(tt.version)
+		if err != nil {
+			t.Errorf("plan %d : %v", tt.version, err)
+			continue
+		}
+		if !slices.Equal(versions(up), tt.up) || !slices.Equal(versions(down), tt.down) {
+			t.Errorf("plan %d : up %v, down %v, attendu up %v, down %v", tt.version, versions(up), versions(down), tt.up, tt.down)
+		}
+	}
+	if _, _, err := runner.Plan(42); err == nil {
+		t.Error("version inconnue acceptée")
+	}
+	assertVersion(t, runner, 2) // Plan n'exécute rien
+
+	for steps, want := range map[int]int{1: 1, 2: 0, 5: 0} {
+		if got, err := runner.DownTarget(steps); err != nil || got != want {
+			t.Errorf("DownTarget(%d) = %d, %v, attendu %d", steps, got, err, want)
+		}
+	}
+
+	// Une version appliquée par un binaire plus récent bloque la descente.
+	if err := db.Create(&schemaMigration{Version: 99, Name: "future"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := runner.Plan(1); !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("version inconnue en base : erreur %v, attendu ErrSchemaMismatch", err)
+	}
+	if !errors.Is(runner.Check(), ErrSchemaMismatch) {
+		t.Error("version inconnue en base non signalée par Check")
+	}
+}
+
+func TestMigrateRefusesDataLossWithoutForce(t *testing.T) {
+	db, runner := newTestRunner(t)
+	if _, err := runner.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO links (shortcode, long_url, description, favicon_url, image_url, created_at) VALUES ('abc123', 'https://example.com', '', '', '', CURRENT_TIMESTAMP)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version     int
+		destructive []int
+	}{
+		{version: 2, destructive: []int{3}},
+		{version: 0, destructive: []int{3, 1}},
+	}
+	for _, tt := range tests {
+		done, err := runner.Migrate(tt.version, false)
+		var dataLoss *DataLossError
+		if !errors.As(err, &dataLoss) || !slices.Equal(versions(dataLoss.Migrations), tt.destructive) {
+			t.Fatalf("migrate %d sans force : %v, attendu les migrations destructives %v", tt.version, err, tt.destructive)
+		}
+		if len(done) != 0 {
+			t.Errorf("migrate %d sans force : %v exécutée(s)", tt.version, versions(done))
+		}
+		assertVersion(t, runner, Latest())
+	}
+	var links int64
+	if err := db.Table("links").Count(&links).Error; err != nil || links != 1 {
+		t.Errorf("%d lien(s), %v, attendu le lien intact", links, err)
+	}
+
+	// Annuler une migration non destructive ne demande pas --force.
+	if _, err := runner.Migrate(2, true); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := runner.Migrate(1, false); err != nil || !slices.Equal(versions(done), []int{2}) {
+		t.Errorf("migrate 1 sans force : %v, %v", versions(done), err)
+	}
+	if done, err := runner.Migrate(0, true); err != nil || !slices.Equal(versions(done), []int{1}) {
+		t.Errorf("migrate 0 avec force : %v, %v", versions(done), err)
+	}
+	assertVersion(t, runner, 0)
+}
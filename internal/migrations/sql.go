@@ -0,0 +1,127 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// sqlFiles contient les migrations SQL, nommées NNNN_nom[.pilote].(up|down).sql.
+// Sans pilote, le fichier vaut pour tous les SGBD ; avec, il ne s'exécute que
+// sur celui-ci (sqlite, postgres ou mysql) et la migration ne fait rien ailleurs.
+//
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// sqlMigration regroupe les fichiers d'une même version.
+type sqlMigration struct {
+	name string
+	up   map[string]string // pilote ("" pour tous) → chemin du fichier
+	down map[string]string
+}
+
+func init() {
+	entries, err := fs.ReadDir(sqlFiles, "sql")
+	if err != nil {
+		panic(err)
+	}
+
+	byVersion := make(map[int]*sqlMigration)
+	for _, entry := range entries {
+		version, name, driver, direction, err := parseSQLFileName(entry.Name())
+		if err != nil {
+			panic(fmt.Sprintf("migrations : %v", err))
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &sqlMigration{name: name, up: map[string]string{}, down: map[string]string{}}
+			byVersion[version] = m
+		}
+		if m.name != name {
+			panic(fmt.Sprintf("migrations : noms différents pour la version %d (%s, %s)", version, m.name, name))
+		}
+		file := path.Join("sql", entry.Name())
+		if direction == "up" {
+			m.up[driver] = file
+		} else {
+			m.down[driver] = file
+		}
+	}
+
+	for version, m := range byVersion {
+		register(Migration{
+			Version: version,
+			Name:    m.name,
+			Up:      execSQLFile(m.up),
+			Down:    execSQLFile(m.down),
+		})
+	}
+}
+
+func parseSQLFileName(file string) (version int, name, driver, direction string, err error) {
+	parts := strings.Split(strings.TrimSuffix(file, ".sql"), ".")
+	if !strings.HasSuffix(file, ".sql") || len(parts) < 2 || len(parts) > 3 {
+		return 0, "", "", "", fmt.Errorf("nom de fichier invalide : %s", file)
+	}
+	direction = parts[len(parts)-1]
+	if direction != "up" && direction != "down" {
+		return 0, "", "", "", fmt.Errorf("sens inconnu (up ou down) : %s", file)
+	}
+	if len(parts) == 3 {
+		driver = parts[1]
+	}
+	prefix, name, ok := strings.Cut(parts[0], "_")
+	if version, err = strconv.Atoi(prefix); !ok || err != nil || version <= 0 {
+		return 0, "", "", "", fmt.Errorf("numéro de version invalide : %s", file)
+	}
+	return version, name, driver, direction, nil
+}
+
+// execSQLFile exécute le fichier propre au SGBD courant, à défaut le fichier
+// commun. Les instructions sont séparées par des points-virgules en fin de ligne.
+func execSQLFile(files map[string]string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		file, ok := files[tx.Dialector.Name()]
+		if !ok {
+			if file, ok = files[""]; !ok {
+				return nil
+			}
+		}
+		content, err := sqlFiles.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		for _, statement := range splitStatements(string(content)) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("%s : %w", path.Base(file), err)
+			}
+		}
+		return nil
+	}
+}
+
+func splitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,2 @@
+-- Retour à la collation par défaut du jeu de caractères.
+ALTER TABLE links MODIFY shortcode VARCHAR(10) CHARACTER SET utf8mb4 NOT NULL;
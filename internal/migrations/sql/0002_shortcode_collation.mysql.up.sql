@@ -0,0 +1,3 @@
+-- Les codes courts distinguent majuscules et minuscules : la collation par
+-- défaut de MySQL les confondrait, à la recherche comme dans l'index unique.
+ALTER TABLE links MODIFY shortcode VARCHAR(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL;
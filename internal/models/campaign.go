@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Campaign regroupe les liens partageant le même paramètre utm_campaign,
+// afin d'agréger leurs statistiques de clics.
+type Campaign struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(255);uniqueIndex;not null"` // Valeur du paramètre utm_campaign
+	CreatedAt time.Time
+}
@@ -1,6 +1,8 @@
 package models
 
-import "time"
+import (
+	"time"
+)
 
 // Click représente un événement de clic sur un lien raccourci.
 // GORM utilisera ces tags pour créer la table 'clicks'.
@@ -9,8 +11,10 @@ type Click struct {
 	LinkID    uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
 	Link      Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
 	Timestamp time.Time // Horodatage précis du clic
-	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
-	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+	UserAgent string    `gorm:"size:255"`                              // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
+	IPAddress string    `gorm:"size:50"`                               // Adresse IP de l'utilisateur
+	Referrer  string    `gorm:"type:varchar(255);not null;default:''"` // Domaine de la page d'origine, vide pour un accès direct
+	Country   string    `gorm:"type:char(2);not null;default:''"`      // Code pays ISO 3166-1 fourni par le proxy, vide si inconnu
 }
 
 // TODO créer la struct pour ClickEvent
@@ -22,4 +26,9 @@ type ClickEvent struct {
 	Timestamp time.Time // Horodatage du clic
 	UserAgent string    // User-Agent du navigateur
 	IPAddress string    // Adresse IP de l'utilisateur
+	Referrer  string    // Domaine de la page d'origine
+	Country   string    // Code pays ISO 3166-1
+	RequestID string    // Identifiant de la requête de redirection, repris dans les logs
+	TraceID   string    // Trace du span de publication (hexadécimal), lié au span d'écriture du lot
+	SpanID    string    // Span de publication (hexadécimal)
 }
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Tables des agrégats de clics, de même structure.
+const (
+	ClickRollupsHourly = "click_rollups_hourly"
+	ClickRollupsDaily  = "click_rollups_daily"
+)
+
+// Dimensions des agrégats de clics.
+const (
+	DimensionTotal    = "total"    // Tous les clics, valeur vide
+	DimensionReferrer = "referrer" // Domaine d'origine, vide pour un accès direct
+	DimensionCountry  = "country"  // Code pays, vide si inconnu
+	DimensionDevice   = "device"   // Type d'appareil déduit du User-Agent
+)
+
+// ClickRollup est le nombre de clics d'un lien sur une heure ou un jour (UTC),
+// pour une valeur d'une dimension. Ces agrégats sont tenus à jour à chaque
+// enregistrement de clics et peuvent être recalculés depuis la table clicks.
+type ClickRollup struct {
+	LinkID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	Bucket    time.Time `gorm:"primaryKey"` // Début de l'heure ou du jour
+	Dimension string    `gorm:"primaryKey;type:varchar(16)"`
+	Value     string    `gorm:"primaryKey;type:varchar(255)"`
+	Clicks    int64     `gorm:"not null"`
+}
@@ -2,9 +2,45 @@ package models
 
 import "time"
 
+// Modes de transfert des paramètres de requête vers l'URL de destination.
+const (
+	ForwardQueryNone     = ""         // Les paramètres de l'URL courte sont ignorés
+	ForwardQueryMerge    = "merge"    // Ajoutés à la destination, sans écraser ceux déjà présents
+	ForwardQueryOverride = "override" // Ajoutés à la destination, en remplaçant ceux déjà présents
+)
+
 type Link struct {
 	ID        uint   `gorm:"primaryKey"`
 	ShortCode string `gorm:"column:shortcode;type:varchar(10);uniqueIndex;not null"`
 	LongURL   string `gorm:"type:text;not null"`
+	// Empreinte SHA-256 de l'URL canonique, pour retrouver les destinations identiques
+	CanonicalURLHash string `gorm:"type:char(64);index;not null;default:''"`
+	ForwardQuery     string `gorm:"type:varchar(10);not null;default:''"` // Mode de transfert des paramètres de requête
+	ForwardPath      bool   `gorm:"not null;default:false"`               // Transfert des segments de chemin après le code court
+	CampaignID       *uint  `gorm:"index"`                                // Campagne UTM éventuelle du lien
+
+	// Réponse de redirection : zéro/nil signifie « utiliser la configuration globale »
+	RedirectStatus int  `gorm:"not null;default:0"` // Code HTTP (301, 302, 307 ou 308)
+	CacheMaxAge    *int // Durée de mise en cache en secondes (0 = no-store)
+
+	PasswordHash string `gorm:"type:varchar(72);not null;default:''"` // Hash bcrypt du mot de passe, vide si le lien est public
+
+	// Métadonnées de la page de destination, rafraîchies par le moniteur
+	Title string `gorm:"type:varchar(255);not null;default:''"`
+	// Pas de valeur par défaut sur les colonnes text : MySQL ne l'accepte pas.
+	Description       string `gorm:"type:text;not null"`
+	FaviconURL        string `gorm:"type:text;not null"`
+	ImageURL          string `gorm:"type:text;not null"` // Image Open Graph
+	MetadataFetchedAt *time.Time
+
+	// Un lien désactivé (par exemple par la politique de destinations) ne redirige plus
+	Disabled       bool   `gorm:"not null;default:false"`
+	DisabledReason string `gorm:"type:varchar(255);not null;default:''"`
+
 	CreatedAt time.Time
 }
+
+// IsProtected indique si le lien demande un mot de passe avant la redirection.
+func (l *Link) IsProtected() bool {
+	return l.PasswordHash != ""
+}
@@ -0,0 +1,7 @@
+package models
+
+// Sequence est un compteur persistant nommé (ex : génération séquentielle des codes courts).
+type Sequence struct {
+	Name  string `gorm:"primaryKey;type:varchar(50)"`
+	Value uint64 `gorm:"not null"`
+}
@@ -1,33 +1,69 @@
 package monitor
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Julien-Somasundaram/urlshortener/internal/metrics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
 	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/services"
+	"github.com/Julien-Somasundaram/urlshortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// LinkHealth est le dernier état connu de l'URL longue d'un lien.
+type LinkHealth struct {
+	Accessible bool
+	CheckedAt  time.Time
+}
+
 // UrlMonitor gère la surveillance périodique des URLs longues.
 type UrlMonitor struct {
 	linkRepo    repository.LinkRepository
+	policy      *policy.Policy
 	interval    time.Duration
-	knownStates map[uint]bool
+	knownStates map[uint]LinkHealth
 	mu          sync.Mutex
+	logger      *slog.Logger
+	lastRun     atomic.Int64 // Début (UnixNano) de la dernière vérification
 }
 
 // NewUrlMonitor crée un nouveau moniteur.
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+func NewUrlMonitor(linkRepo repository.LinkRepository, destinationPolicy *policy.Policy, interval time.Duration) *UrlMonitor {
 	return &UrlMonitor{
 		linkRepo:    linkRepo,
+		policy:      destinationPolicy,
 		interval:    interval,
-		knownStates: make(map[uint]bool),
+		knownStates: make(map[uint]LinkHealth),
+		logger:      slog.Default().With("component", "monitor"),
+	}
+}
+
+// Interval retourne la période entre deux vérifications.
+func (m *UrlMonitor) Interval() time.Duration {
+	return m.interval
+}
+
+// LastRun retourne le début de la dernière vérification, ou l'instant nul si
+// le moniteur n'a pas encore démarré.
+func (m *UrlMonitor) LastRun() time.Time {
+	if at := m.lastRun.Load(); at != 0 {
+		return time.Unix(0, at)
 	}
+	return time.Time{}
 }
 
 func (m *UrlMonitor) Start() {
-	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
+	m.logger.Info("Démarrage du moniteur d'URLs", "interval", m.interval)
 	ticker := time.NewTicker(m.interval)
 	defer ticker.Stop()
 
@@ -39,45 +75,101 @@ func (m *UrlMonitor) Start() {
 }
 
 func (m *UrlMonitor) checkUrls() {
-	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+	m.lastRun.Store(time.Now().UnixNano())
+	ctx, span := tracing.Tracer().Start(context.Background(), "monitor run")
+	defer span.End()
+	m.logger.InfoContext(ctx, "Lancement de la vérification de l'état des URLs")
 
 	links, err := m.linkRepo.GetAllLinks()
 	if err != nil {
-		log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
+		span.SetStatus(codes.Error, err.Error())
+		m.logger.ErrorContext(ctx, "Récupération des liens à surveiller impossible", "error", err)
 		return
 	}
 
 	for _, link := range links {
-		currentState := m.isUrlAccessible(link.LongURL)
+		if !link.Disabled {
+			m.checkLink(ctx, link)
+		}
+	}
 
-		m.mu.Lock()
-		previousState, exists := m.knownStates[link.ID]
-		m.knownStates[link.ID] = currentState
-		m.mu.Unlock()
+	span.SetAttributes(attribute.Int("monitor.links", len(links)))
+	m.logger.InfoContext(ctx, "Vérification de l'état des URLs terminée", "links", len(links))
+}
 
-		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.ShortCode, link.LongURL, formatState(currentState))
-			continue
+// checkLink vérifie la destination d'un lien et signale tout changement d'état.
+func (m *UrlMonitor) checkLink(ctx context.Context, link models.Link) {
+	ctx, span := tracing.Tracer().Start(ctx, "monitor check", trace.WithAttributes(
+		attribute.String("short_code", link.ShortCode),
+		semconv.URLFull(link.LongURL),
+	))
+	defer span.End()
+	start := time.Now()
+
+	// La politique a pu évoluer (liste de blocage rechargée) depuis la création du lien.
+	if m.policy != nil {
+		if err := m.policy.Evaluate(ctx, link.LongURL); err != nil {
+			metrics.MonitorCheckDuration.WithLabelValues("blocked").Observe(time.Since(start).Seconds())
+			span.SetAttributes(attribute.String("monitor.outcome", "blocked"))
+			if err := m.linkRepo.DisableLink(link.ID, err.Error()); err != nil {
+				span.SetStatus(codes.Error, err.Error())
+				m.logger.ErrorContext(ctx, "Désactivation du lien impossible", "short_code", link.ShortCode, "error", err)
+				return
+			}
+			m.logger.WarnContext(ctx, "[NOTIFICATION] Lien désactivé", "short_code", link.ShortCode, "long_url", link.LongURL, "reason", err)
+			return
 		}
+	}
 
-		if currentState != previousState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.ShortCode, link.LongURL, formatState(previousState), formatState(currentState))
+	currentState := m.isUrlAccessible(ctx, link.LongURL)
+	metrics.MonitorCheckDuration.WithLabelValues(outcome(currentState)).Observe(time.Since(start).Seconds())
+	span.SetAttributes(attribute.String("monitor.outcome", outcome(currentState)))
+
+	// Une destination accessible est l'occasion de rafraîchir ses métadonnées.
+	if currentState {
+		if err := services.RefreshLinkMetadata(ctx, m.linkRepo, &link); err != nil {
+			m.logger.WarnContext(ctx, "Métadonnées indisponibles", "short_code", link.ShortCode, "error", err)
 		}
 	}
 
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
-}
+	m.mu.Lock()
+	previous, exists := m.knownStates[link.ID]
+	m.knownStates[link.ID] = LinkHealth{Accessible: currentState, CheckedAt: time.Now()}
+	m.mu.Unlock()
 
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	client := http.Client{
-		Timeout: 5 * time.Second,
+	if !exists {
+		m.logger.InfoContext(ctx, "État initial du lien", "short_code", link.ShortCode, "long_url", link.LongURL,
+			"state", formatState(currentState))
+		return
 	}
 
-	resp, err := client.Head(url)
+	if currentState != previous.Accessible {
+		m.logger.WarnContext(ctx, "[NOTIFICATION] Changement d'état du lien", "short_code", link.ShortCode, "long_url", link.LongURL,
+			"from", formatState(previous.Accessible), "to", formatState(currentState))
+	}
+}
+
+// Health retourne le dernier état connu d'un lien, s'il a déjà été vérifié.
+func (m *UrlMonitor) Health(linkID uint) (LinkHealth, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health, exists := m.knownStates[linkID]
+	return health, exists
+}
+
+// client ne se connecte jamais au réseau local : une destination dont le nom
+// est résolu plus tard vers une adresse interne n'est pas sondée depuis le serveur.
+var client = policy.NewHTTPClient(5 * time.Second)
+
+func (m *UrlMonitor) isUrlAccessible(ctx context.Context, url string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
+		m.logger.WarnContext(ctx, "URL invalide", "url", url, "error", err)
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		m.logger.WarnContext(ctx, "URL injoignable", "url", url, "error", err)
 		return false
 	}
 	defer resp.Body.Close()
@@ -85,6 +177,14 @@ func (m *UrlMonitor) isUrlAccessible(url string) bool {
 	return resp.StatusCode >= 200 && resp.StatusCode < 400
 }
 
+// outcome est le libellé Prometheus de l'état d'une URL.
+func outcome(accessible bool) string {
+	if accessible {
+		return "accessible"
+	}
+	return "inaccessible"
+}
+
 func formatState(accessible bool) string {
 	if accessible {
 		return "ACCESSIBLE"
@@ -0,0 +1,280 @@
+package policy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+)
+
+// Violation décrit pourquoi une URL de destination est refusée.
+type Violation struct {
+	URL    string
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("destination refusée (%s) : %s", v.Reason, v.URL)
+}
+
+// Options regroupe les règles de la politique de destinations.
+type Options struct {
+	AllowDomains    []string // Si non vide, seuls ces domaines (et leurs sous-domaines) sont acceptés
+	DenyDomains     []string // Domaines (et sous-domaines) toujours refusés
+	BlockPrivateIPs bool     // Refuse les destinations loopback, privées ou link-local
+	BlocklistFile   string   // Liste de blocage locale, rechargeable à chaud
+}
+
+// Policy évalue les URLs de destination selon des listes de domaines, le type
+// d'adresse IP visée et une liste de blocage chargée depuis un fichier.
+//
+// Le fichier de blocage accepte, une entrée par ligne (# pour les commentaires) :
+//   - le format hosts : "0.0.0.0 domaine.com autre.com" ;
+//   - un domaine seul : "domaine.com" ;
+//   - un préfixe hexadécimal du SHA-256 d'un domaine : "sha256:9f86d081".
+type Policy struct {
+	opts     Options
+	resolver *net.Resolver
+
+	mu           sync.RWMutex
+	blockDomains map[string]struct{}
+	blockHashes  []string
+}
+
+// New crée une politique et charge la liste de blocage éventuelle.
+func New(opts Options) (*Policy, error) {
+	p := &Policy{
+		opts:         opts,
+		resolver:     net.DefaultResolver,
+		blockDomains: make(map[string]struct{}),
+	}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewFromConfig crée la politique décrite par la section 'policy' de la configuration.
+func NewFromConfig(cfg *config.Config) (*Policy, error) {
+	return New(Options{
+		AllowDomains:    cfg.Policy.AllowDomains,
+		DenyDomains:     cfg.Policy.DenyDomains,
+		BlockPrivateIPs: cfg.Policy.BlockPrivateIPs,
+		BlocklistFile:   cfg.Policy.BlocklistFile,
+	})
+}
+
+// Reload relit le fichier de liste de blocage. En cas d'erreur, la liste
+// précédemment chargée reste en vigueur.
+func (p *Policy) Reload() error {
+	if p.opts.BlocklistFile == "" {
+		return nil
+	}
+
+	file, err := os.Open(p.opts.BlocklistFile)
+	if err != nil {
+		return fmt.Errorf("ouverture liste de blocage : %w", err)
+	}
+	defer file.Close()
+
+	domains := make(map[string]struct{})
+	var hashes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		if prefix, found := strings.CutPrefix(fields[0], "sha256:"); found {
+			if _, err := hex.DecodeString(prefix); err == nil && len(prefix) >= 8 {
+				hashes = append(hashes, prefix)
+			}
+			continue
+		}
+		// Format hosts : la première colonne est l'adresse de redirection.
+		if _, err := netip.ParseAddr(fields[0]); err == nil {
+			fields = fields[1:]
+		}
+		for _, domain := range fields {
+			domains[strings.TrimSuffix(domain, ".")] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("lecture liste de blocage : %w", err)
+	}
+
+	p.mu.Lock()
+	p.blockDomains = domains
+	p.blockHashes = hashes
+	p.mu.Unlock()
+
+	slog.Info("Liste de blocage chargée", "domains", len(domains), "hash_prefixes", len(hashes))
+	return nil
+}
+
+// Evaluate retourne une *Violation si l'URL ne respecte pas la politique.
+func (p *Policy) Evaluate(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &Violation{URL: rawURL, Reason: "URL invalide"}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &Violation{URL: rawURL, Reason: "schéma non autorisé"}
+	}
+	if u.User != nil {
+		return &Violation{URL: rawURL, Reason: "identifiants dans l'URL"}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return &Violation{URL: rawURL, Reason: "hôte manquant"}
+	}
+
+	if len(p.opts.AllowDomains) > 0 && !matchesAny(host, p.opts.AllowDomains) {
+		return &Violation{URL: rawURL, Reason: "domaine hors liste autorisée"}
+	}
+	if matchesAny(host, p.opts.DenyDomains) {
+		return &Violation{URL: rawURL, Reason: "domaine interdit"}
+	}
+	if p.isBlocklisted(host) {
+		return &Violation{URL: rawURL, Reason: "domaine en liste de blocage"}
+	}
+
+	if p.opts.BlockPrivateIPs {
+		if reason := p.privateTarget(ctx, host); reason != "" {
+			return &Violation{URL: rawURL, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// parentDomains retourne l'hôte et chacun de ses domaines parents.
+func parentDomains(host string) []string {
+	domains := []string{host}
+	for {
+		_, parent, found := strings.Cut(host, ".")
+		if !found || !strings.Contains(parent, ".") {
+			return domains
+		}
+		domains = append(domains, parent)
+		host = parent
+	}
+}
+
+func matchesAny(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) isBlocklisted(host string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, domain := range parentDomains(host) {
+		if _, found := p.blockDomains[domain]; found {
+			return true
+		}
+		if len(p.blockHashes) > 0 {
+			sum := sha256.Sum256([]byte(domain))
+			digest := hex.EncodeToString(sum[:])
+			for _, prefix := range p.blockHashes {
+				if strings.HasPrefix(digest, prefix) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// privateTarget vérifie que l'hôte ne désigne pas le réseau local. Un nom
+// impossible à résoudre n'est pas refusé : le moniteur le signalera inaccessible.
+func (p *Policy) privateTarget(ctx context.Context, host string) string {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "adresse locale"
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if isPrivate(addr) {
+			return "adresse IP privée ou locale"
+		}
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	addrs, err := p.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if isPrivate(addr) {
+			return "nom résolu vers une adresse IP privée ou locale"
+		}
+	}
+	return ""
+}
+
+// ErrPrivateAddress signale une connexion refusée vers le réseau local.
+var ErrPrivateAddress = errors.New("connexion vers une adresse IP privée ou locale refusée")
+
+// DenyPrivateAddress s'utilise comme net.Dialer.Control pour refuser toute
+// connexion vers le réseau local. Vérifiée à chaque connexion sur l'adresse
+// résolue, elle couvre aussi les redirections et les noms dont la résolution
+// change après l'évaluation de la politique.
+func DenyPrivateAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("adresse de connexion invalide %q : %w", address, err)
+	}
+	if isPrivate(addrPort.Addr()) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// NewHTTPClient crée un client HTTP qui ne se connecte jamais au réseau local,
+// redirections comprises, pour les requêtes émises par le serveur vers les
+// destinations. Aucun proxy n'est utilisé, pour que la vérification porte sur
+// la cible réelle.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: timeout,
+				Control: DenyPrivateAddress,
+			}).DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     30 * time.Second,
+		},
+	}
+}
+
+func isPrivate(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsUnspecified() || addr.IsMulticast()
+}
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/cache"
+	"github.com/Julien-Somasundaram/urlshortener/internal/metrics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// CacheStats expose les compteurs du cache des liens.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// cachedLink est une entrée du cache : un lien, ou l'absence de lien pour ce code.
+type cachedLink struct {
+	link  models.Link
+	found bool
+}
+
+// CachedLinkRepository ajoute un cache LRU devant un LinkRepository pour la
+// résolution des codes courts. Les codes inconnus sont aussi mis en cache
+// (cache négatif, durée plus courte) et chaque écriture invalide les entrées
+// locales concernées ; un dépôt interne doté de son propre cache s'invalide
+// lui-même. Les autres lectures sont transmises telles quelles.
+type CachedLinkRepository struct {
+	LinkRepository
+	cache       *cache.LRU[string, cachedLink]
+	ttl         time.Duration
+	negativeTTL time.Duration
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+
+	codesMu sync.Mutex
+	codes   map[uint]string // Identifiant → code des liens en cache, pour EvictID
+
+	// fillMu ordonne le remplissage après lecture en base et les invalidations ;
+	// generation change à chaque invalidation, pour qu'une lecture commencée
+	// avant ne remette pas en cache une ligne périmée.
+	fillMu     sync.Mutex
+	generation uint64
+}
+
+// NewCachedLinkRepository crée le cache de size entrées devant inner. Les
+// liens trouvés sont conservés ttl, les codes inconnus negativeTTL.
+func NewCachedLinkRepository(inner LinkRepository, size int, ttl, negativeTTL time.Duration) *CachedLinkRepository {
+	r := &CachedLinkRepository{
+		LinkRepository: inner,
+		cache:          cache.NewLRU[string, cachedLink](size),
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+		codes:          make(map[uint]string),
+	}
+	r.cache.OnEvict(r.forgetID)
+	return r
+}
+
+// GetLinkByShortCode résout le code depuis le cache, puis depuis la base en
+// cas d'absence. Une copie est retournée : l'appelant peut la modifier.
+func (r *CachedLinkRepository) GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
+	if entry, ok := r.cache.Get(shortCode); ok {
+		r.hits.Add(1)
+		metrics.CacheRequests.WithLabelValues("memory", "hit").Inc()
+		if !entry.found {
+			return nil, gorm.ErrRecordNotFound
+		}
+		link := entry.link
+		return &link, nil
+	}
+	r.misses.Add(1)
+	metrics.CacheRequests.WithLabelValues("memory", "miss").Inc()
+
+	r.fillMu.Lock()
+	generation := r.generation
+	r.fillMu.Unlock()
+
+	link, err := r.LinkRepository.GetLinkByShortCode(ctx, shortCode)
+
+	r.fillMu.Lock()
+	defer r.fillMu.Unlock()
+	if r.generation != generation {
+		// Invalidation pendant la lecture : le résultat n'est pas mis en cache.
+		return link, err
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if r.negativeTTL > 0 {
+			r.cache.Set(shortCode, cachedLink{}, r.negativeTTL)
+		}
+	case err == nil:
+		// L'index est renseigné avant l'entrée : une éviction concurrente le nettoie.
+		r.codesMu.Lock()
+		r.codes[link.ID] = shortCode
+		r.codesMu.Unlock()
+		r.cache.Set(shortCode, cachedLink{link: *link, found: true}, r.ttl)
+	}
+	return link, err
+}
+
+func (r *CachedLinkRepository) CreateLink(link *models.Link) error {
+	err := r.LinkRepository.CreateLink(link)
+	if err == nil {
+		// Retire une éventuelle entrée négative pour ce code.
+		r.Evict(link.ShortCode)
+	}
+	return err
+}
+
+func (r *CachedLinkRepository) UpdateLinkMetadata(link *models.Link) error {
+	defer r.evictLink(link)
+	return r.LinkRepository.UpdateLinkMetadata(link)
+}
+
+func (r *CachedLinkRepository) DisableLink(linkID uint, reason string) error {
+	defer r.EvictID(linkID)
+	return r.LinkRepository.DisableLink(linkID, reason)
+}
+
+func (r *CachedLinkRepository) UpdateCanonicalHash(linkID uint, hash string) error {
+	defer r.EvictID(linkID)
+	return r.LinkRepository.UpdateCanonicalHash(linkID, hash)
+}
+
+func (r *CachedLinkRepository) ReplaceLink(link *models.Link) error {
+	defer r.evictLink(link)
+	return r.LinkRepository.ReplaceLink(link)
+}
+
+func (r *CachedLinkRepository) UpdateLinkColumns(link *models.Link, columns ...string) error {
+	defer r.evictLink(link)
+	return r.LinkRepository.UpdateLinkColumns(link, columns...)
+}
+
+func (r *CachedLinkRepository) DeleteLink(linkID uint) error {
+	defer r.EvictID(linkID)
+	return r.LinkRepository.DeleteLink(linkID)
+}
+
+// Invalidate retire le code du cache local et des caches du dépôt interne.
+func (r *CachedLinkRepository) Invalidate(shortCode string) {
+	r.Evict(shortCode)
+	if inner, ok := r.LinkRepository.(LinkInvalidator); ok {
+		inner.Invalidate(shortCode)
+	}
+}
+
+// InvalidateID retire le lien du cache local et des caches du dépôt interne.
+func (r *CachedLinkRepository) InvalidateID(linkID uint) {
+	r.EvictID(linkID)
+	if inner, ok := r.LinkRepository.(LinkInvalidator); ok {
+		inner.InvalidateID(linkID)
+	}
+}
+
+// Evict retire le code du cache local uniquement.
+func (r *CachedLinkRepository) Evict(shortCode string) {
+	r.fillMu.Lock()
+	defer r.fillMu.Unlock()
+	r.generation++
+	r.cache.Delete(shortCode)
+}
+
+// EvictID retire du cache local le lien portant cet identifiant, retrouvé
+// par l'index des codes en cache.
+func (r *CachedLinkRepository) EvictID(linkID uint) {
+	r.fillMu.Lock()
+	defer r.fillMu.Unlock()
+	r.generation++
+	r.codesMu.Lock()
+	shortCode, ok := r.codes[linkID]
+	r.codesMu.Unlock()
+	if ok {
+		r.cache.Delete(shortCode)
+	}
+}
+
+// Purge vide le cache local.
+func (r *CachedLinkRepository) Purge() {
+	r.fillMu.Lock()
+	defer r.fillMu.Unlock()
+	r.generation++
+	r.cache.Purge()
+	r.codesMu.Lock()
+	r.codes = make(map[uint]string)
+	r.codesMu.Unlock()
+}
+
+// forgetID retire de l'index le lien d'une entrée évincée du cache.
+func (r *CachedLinkRepository) forgetID(shortCode string, entry cachedLink) {
+	if !entry.found {
+		return
+	}
+	r.codesMu.Lock()
+	defer r.codesMu.Unlock()
+	if r.codes[entry.link.ID] == shortCode {
+		delete(r.codes, entry.link.ID)
+	}
+}
+
+func (r *CachedLinkRepository) evictLink(link *models.Link) {
+	if link.ShortCode != "" {
+		r.Evict(link.ShortCode)
+	}
+	r.EvictID(link.ID)
+}
+
+// Stats retourne les compteurs de succès et d'échecs du cache.
+func (r *CachedLinkRepository) Stats() CacheStats {
+	return CacheStats{Hits: r.hits.Load(), Misses: r.misses.Load(), Entries: r.cache.Len()}
+}
+
+// CachedStore est un Store dont les liens passent par un cache. Dans une
+// transaction, les lectures vont directement à la base et les liens modifiés
+// sont invalidés une fois la transaction terminée, quand le résultat est visible.
+type CachedStore struct {
+	Store
+	links CachingLinkRepository
+}
+
+// NewCachedStore associe le cache links au Store.
+func NewCachedStore(store Store, links CachingLinkRepository) *CachedStore {
+	return &CachedStore{Store: store, links: links}
+}
+
+func (s *CachedStore) Links() LinkRepository {
+	return s.links
+}
+
+func (s *CachedStore) Transaction(fn func(tx Store) error) error {
+	written := &writtenLinks{}
+	defer func() {
+		written.mu.Lock()
+		defer written.mu.Unlock()
+		for _, code := range written.codes {
+			s.links.Invalidate(code)
+		}
+		for _, id := range written.ids {
+			s.links.InvalidateID(id)
+		}
+	}()
+	return s.Store.Transaction(func(tx Store) error {
+		return fn(&trackingStore{Store: tx, written: written})
+	})
+}
+
+// writtenLinks recense les liens modifiés pendant une transaction.
+type writtenLinks struct {
+	mu    sync.Mutex
+	codes []string
+	ids   []uint
+}
+
+func (w *writtenLinks) add(code string, id uint) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if code != "" {
+		w.codes = append(w.codes, code)
+	}
+	if id != 0 {
+		w.ids = append(w.ids, id)
+	}
+}
+
+// trackingStore est le Store d'une transaction de CachedStore.
+type trackingStore struct {
+	Store
+	written *writtenLinks
+}
+
+func (s *trackingStore) Links() LinkRepository {
+	return &trackingLinkRepository{LinkRepository: s.Store.Links(), written: s.written}
+}
+
+func (s *trackingStore) Transaction(fn func(tx Store) error) error {
+	return s.Store.Transaction(func(tx Store) error {
+		return fn(&trackingStore{Store: tx, written: s.written})
+	})
+}
+
+// trackingLinkRepository note les liens écrits dans la transaction.
+type trackingLinkRepository struct {
+	LinkRepository
+	written *writtenLinks
+}
+
+func (r *trackingLinkRepository) CreateLink(link *models.Link) error {
+	err := r.LinkRepository.CreateLink(link)
+	if err == nil {
+		r.written.add(link.ShortCode, 0)
+	}
+	return err
+}
+
+func (r *trackingLinkRepository) UpdateLinkMetadata(link *models.Link) error {
+	r.written.add(link.ShortCode, link.ID)
+	return r.LinkRepository.UpdateLinkMetadata(link)
+}
+
+func (r *trackingLinkRepository) DisableLink(linkID uint, reason string) error {
+	r.written.add("", linkID)
+	return r.LinkRepository.DisableLink(linkID, reason)
+}
+
+func (r *trackingLinkRepository) UpdateCanonicalHash(linkID uint, hash string) error {
+	r.written.add("", linkID)
+	return r.LinkRepository.UpdateCanonicalHash(linkID, hash)
+}
+
+func (r *trackingLinkRepository) ReplaceLink(link *models.Link) error {
+	r.written.add(link.ShortCode, link.ID)
+	return r.LinkRepository.ReplaceLink(link)
+}
+
+func (r *trackingLinkRepository) UpdateLinkColumns(link *models.Link, columns ...string) error {
+	r.written.add(link.ShortCode, link.ID)
+	return r.LinkRepository.UpdateLinkColumns(link, columns...)
+}
+
+func (r *trackingLinkRepository) DeleteLink(linkID uint) error {
+	r.written.add("", linkID)
+	return r.LinkRepository.DeleteLink(linkID)
+}
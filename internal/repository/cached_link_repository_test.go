@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestCachedLinkRepositoryServesFromCache(t *testing.T) {
+	db, links := newTestLinks(t, "abc123")
+	repo := NewCachedLinkRepository(links, 10, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	link, err := repo.GetLinkByShortCode(ctx, "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link.LongURL = "https://example.com/modifié" // Copie : le cache n'est pas touché
+
+	if err := db.Exec("DELETE FROM links").Error; err != nil {
+		t.Fatal(err)
+	}
+	cached, err := repo.GetLinkByShortCode(ctx, "abc123")
+	if err != nil || cached.LongURL != "https://example.com/abc123" {
+		t.Fatalf("lecture depuis le cache = %+v, %v", cached, err)
+	}
+	if stats := repo.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("statistiques = %+v", stats)
+	}
+}
+
+func TestCachedLinkRepositoryNegativeCache(t *testing.T) {
+	_, links := newTestLinks(t)
+	repo := NewCachedLinkRepository(links, 10, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	if _, err := repo.GetLinkByShortCode(ctx, "absent"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("erreur %v, attendu ErrRecordNotFound", err)
+	}
+	// Créé sans passer par le cache : l'entrée négative masque le lien.
+	if err := links.CreateLink(&models.Link{ShortCode: "absent", LongURL: "https://example.com/", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetLinkByShortCode(ctx, "absent"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("cache négatif : erreur %v, attendu ErrRecordNotFound", err)
+	}
+
+	// Une création par le cache retire l'entrée négative.
+	if _, err := repo.GetLinkByShortCode(ctx, "other"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatal(err)
+	}
+	if err := repo.CreateLink(&models.Link{ShortCode: "other", LongURL: "https://example.com/", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetLinkByShortCode(ctx, "other"); err != nil {
+		t.Errorf("après création : %v", err)
+	}
+
+	// Sans durée négative, les codes inconnus ne sont pas mis en cache.
+	uncached := NewCachedLinkRepository(links, 10, time.Hour, 0)
+	uncached.GetLinkByShortCode(ctx, "missing")
+	if uncached.Stats().Entries != 0 {
+		t.Error("code inconnu mis en cache malgré une durée négative nulle")
+	}
+}
+
+func TestCachedLinkRepositoryIDIndex(t *testing.T) {
+	db, links := newTestLinks(t, "first1", "second")
+	repo := NewCachedLinkRepository(links, 1, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	first, err := repo.GetLinkByShortCode(ctx, "first1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Désactivé par identifiant : l'index retrouve le code à invalider.
+	if err := repo.DisableLink(first.ID, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if link, err := repo.GetLinkByShortCode(ctx, "first1"); err != nil || !link.Disabled {
+		t.Errorf("après désactivation : %+v, %v", link, err)
+	}
+
+	// L'éviction par capacité retire le lien de l'index.
+	second, err := repo.GetLinkByShortCode(ctx, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.codesMu.Lock()
+	_, firstIndexed := repo.codes[first.ID]
+	_, secondIndexed := repo.codes[second.ID]
+	repo.codesMu.Unlock()
+	if firstIndexed || !secondIndexed {
+		t.Errorf("index : first1 = %v, second = %v, attendu seulement second", firstIndexed, secondIndexed)
+	}
+
+	if err := db.Model(&models.Link{}).Where("id = ?", second.ID).Update("title", "Nouveau").Error; err != nil {
+		t.Fatal(err)
+	}
+	repo.InvalidateID(second.ID)
+	if link, err := repo.GetLinkByShortCode(ctx, "second"); err != nil || link.Title != "Nouveau" {
+		t.Errorf("après invalidation : %+v, %v", link, err)
+	}
+}
+
+// blockingLinks suspend la première lecture par code jusqu'à release, pour
+// invalider le cache pendant qu'elle est en cours.
+type blockingLinks struct {
+	LinkRepository
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingLinks(inner LinkRepository) *blockingLinks {
+	return &blockingLinks{LinkRepository: inner, started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (r *blockingLinks) GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
+	link, err := r.LinkRepository.GetLinkByShortCode(ctx, shortCode)
+	r.once.Do(func() {
+		close(r.started)
+		<-r.release
+	})
+	return link, err
+}
+
+// assertNoStaleRepopulate lit le lien pendant qu'il est modifié et invalidé,
+// puis vérifie que la lecture suivante voit la modification.
+func assertNoStaleRepopulate(t *testing.T, db *gorm.DB, blocking *blockingLinks, repo LinkRepository, invalidate func()) {
+	t.Helper()
+	ctx := context.Background()
+
+	done := make(chan *models.Link)
+	go func() {
+		link, _ := repo.GetLinkByShortCode(ctx, "abc123")
+		done <- link
+	}()
+	<-blocking.started // La ligne d'origine est lue, pas encore mise en cache.
+	if err := db.Model(&models.Link{}).Where("shortcode = ?", "abc123").Update("disabled", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	invalidate()
+	close(blocking.release)
+	if stale := <-done; stale == nil || stale.Disabled {
+		t.Fatalf("lecture concurrente = %+v, attendu la ligne d'origine", stale)
+	}
+
+	if link, err := repo.GetLinkByShortCode(ctx, "abc123"); err != nil || !link.Disabled {
+		t.Errorf("après invalidation : %+v, %v ; ligne périmée remise en cache", link, err)
+	}
+}
+
+func TestCachedLinkRepositoryIgnoresStaleFill(t *testing.T) {
+	db, links := newTestLinks(t, "abc123")
+	blocking := newBlockingLinks(links)
+	repo := NewCachedLinkRepository(blocking, 10, time.Hour, time.Minute)
+	assertNoStaleRepopulate(t, db, blocking, repo, func() { repo.EvictID(1) })
+}
+
+func TestRedisLinkRepositoryIgnoresStaleFill(t *testing.T) {
+	db, links := newTestLinks(t, "abc123")
+	_, clients := newTestRedis(t, 1)
+	blocking := newBlockingLinks(links)
+	repo := NewRedisLinkRepository(blocking, clients[0], testPrefix, time.Hour, time.Minute)
+	assertNoStaleRepopulate(t, db, blocking, repo, func() { repo.InvalidateID(1) })
+}
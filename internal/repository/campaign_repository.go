@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// LinkClickCount associe un lien à son nombre total de clics.
+type LinkClickCount struct {
+	LinkID      uint
+	ShortCode   string
+	LongURL     string
+	TotalClicks int
+}
+
+// CampaignRepository définit les opérations sur les campagnes.
+type CampaignRepository interface {
+	FindOrCreateCampaign(name string) (*models.Campaign, error)
+	GetCampaignByID(id uint) (*models.Campaign, error)
+	CountClicksByCampaignID(campaignID uint) ([]LinkClickCount, error)
+}
+
+// GormCampaignRepository implémente CampaignRepository avec GORM.
+type GormCampaignRepository struct {
+	db *gorm.DB
+}
+
+// NewGormCampaignRepository crée un nouveau dépôt GORM pour les campagnes.
+func NewGormCampaignRepository(db *gorm.DB) *GormCampaignRepository {
+	return &GormCampaignRepository{db: db}
+}
+
+// FindOrCreateCampaign retourne la campagne portant ce nom, en la créant si besoin.
+func (r *GormCampaignRepository) FindOrCreateCampaign(name string) (*models.Campaign, error) {
+	var campaign models.Campaign
+	result := r.db.Where("name = ?", name).First(&campaign)
+	if result.Error == nil {
+		return &campaign, nil
+	}
+	if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, result.Error
+	}
+
+	campaign = models.Campaign{Name: name}
+	// Point de sauvegarde si une transaction est en cours, pour pouvoir relire après un échec.
+	if err := r.db.Transaction(func(tx *gorm.DB) error { return tx.Create(&campaign).Error }); err != nil {
+		// Une création concurrente a pu insérer la même campagne entre-temps.
+		if retry := r.db.Where("name = ?", name).First(&campaign); retry.Error == nil {
+			return &campaign, nil
+		}
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// GetCampaignByID récupère une campagne par son identifiant.
+func (r *GormCampaignRepository) GetCampaignByID(id uint) (*models.Campaign, error) {
+	var campaign models.Campaign
+	result := r.db.First(&campaign, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &campaign, nil
+}
+
+// CountClicksByCampaignID retourne le nombre de clics de chaque lien de la campagne.
+func (r *GormCampaignRepository) CountClicksByCampaignID(campaignID uint) ([]LinkClickCount, error) {
+	var counts []LinkClickCount
+	result := r.db.Model(&models.Link{}).
+		Select("links.id AS link_id, links.shortcode AS short_code, links.long_url, COALESCE(SUM(r.clicks), 0) AS total_clicks").
+		Joins("LEFT JOIN "+models.ClickRollupsDaily+" r ON r.link_id = links.id AND r.dimension = ?", models.DimensionTotal).
+		Where("links.campaign_id = ?", campaignID).
+		Group("links.id, links.shortcode, links.long_url").
+		Order("links.id").
+		Scan(&counts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return counts, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// counterTTL est la durée de vie d'un compteur sans nouvel amorçage : il est
+// ensuite recalculé depuis la base, ce qui corrige toute dérive.
+const counterTTL = 24 * time.Hour
+
+// pendingTTL borne la durée de vie du nombre de clics en attente d'écriture :
+// les clics perdus par un arrêt brutal d'une instance n'y restent pas comptés.
+const pendingTTL = 10 * time.Minute
+
+// ClickCounter tient à jour en temps réel le nombre de clics de chaque lien,
+// partagé entre les instances, sans attendre l'enregistrement des clics en base.
+// Il suit aussi les clics comptés mais pas encore écrits, pour que l'amorçage
+// depuis la base ne les oublie pas.
+type ClickCounter interface {
+	// Increment compte un clic en attente d'écriture, et l'ajoute au total
+	// si le compteur du lien a déjà été amorcé.
+	Increment(ctx context.Context, linkID uint) error
+	// Flushed retire count clics de ceux en attente, une fois écrits en base
+	// ou abandonnés par l'écrivain.
+	Flushed(ctx context.Context, linkID uint, count int) error
+	// Get retourne le compteur, ok valant false s'il n'est pas amorcé.
+	Get(ctx context.Context, linkID uint) (count int, ok bool, err error)
+	// Seed amorce le compteur avec le total en base augmenté des clics en
+	// attente d'écriture, s'il ne l'est pas déjà.
+	Seed(ctx context.Context, linkID uint, count int) error
+	// Forget supprime le compteur d'un lien supprimé.
+	Forget(ctx context.Context, linkID uint) error
+}
+
+// incrementClick compte le clic en attente et n'incrémente que les totaux
+// amorcés : un total créé à partir de zéro ignorerait les clics déjà en base.
+var incrementClick = redis.NewScript(`
+if redis.call("INCR", KEYS[2]) == 1 then
+	redis.call("PEXPIRE", KEYS[2], ARGV[1])
+end
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCR", KEYS[1])
+end
+return 0
+`)
+
+// flushClicks retire les clics écrits de ceux en attente, sans recréer une
+// clé expirée entre-temps.
+var flushClicks = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("DECRBY", KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// seedClicks amorce le total avec les clics en base et ceux en attente.
+var seedClicks = redis.NewScript(`
+local pending = tonumber(redis.call("GET", KEYS[2]) or "0")
+if pending < 0 then
+	pending = 0
+end
+return redis.call("SET", KEYS[1], tonumber(ARGV[1]) + pending, "NX", "PX", ARGV[2])
+`)
+
+// RedisClickCounter implémente ClickCounter avec un serveur compatible Redis.
+type RedisClickCounter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisClickCounter crée un compteur de clics dont les clés sont préfixées par prefix.
+func NewRedisClickCounter(client *redis.Client, prefix string) *RedisClickCounter {
+	return &RedisClickCounter{client: client, prefix: prefix}
+}
+
+func (c *RedisClickCounter) key(linkID uint) string {
+	return c.prefix + "clicks:" + strconv.FormatUint(uint64(linkID), 10)
+}
+
+func (c *RedisClickCounter) pendingKey(linkID uint) string {
+	return c.prefix + "clicks:pending:" + strconv.FormatUint(uint64(linkID), 10)
+}
+
+func (c *RedisClickCounter) Increment(ctx context.Context, linkID uint) error {
+	keys := []string{c.key(linkID), c.pendingKey(linkID)}
+	return incrementClick.Run(ctx, c.client, keys, pendingTTL.Milliseconds()).Err()
+}
+
+func (c *RedisClickCounter) Flushed(ctx context.Context, linkID uint, count int) error {
+	return flushClicks.Run(ctx, c.client, []string{c.pendingKey(linkID)}, count).Err()
+}
+
+func (c *RedisClickCounter) Get(ctx context.Context, linkID uint) (int, bool, error) {
+	count, err := c.client.Get(ctx, c.key(linkID)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return count, true, nil
+}
+
+func (c *RedisClickCounter) Seed(ctx context.Context, linkID uint, count int) error {
+	keys := []string{c.key(linkID), c.pendingKey(linkID)}
+	err := seedClicks.Run(ctx, c.client, keys, count, counterTTL.Milliseconds()).Err()
+	if errors.Is(err, redis.Nil) {
+		return nil // Déjà amorcé
+	}
+	return err
+}
+
+func (c *RedisClickCounter) Forget(ctx context.Context, linkID uint) error {
+	return c.client.Del(ctx, c.key(linkID), c.pendingKey(linkID)).Err()
+}
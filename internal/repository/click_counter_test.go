@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClickCounterIgnoresIncrementsBeforeSeed(t *testing.T) {
+	_, clients := newTestRedis(t, 1)
+	counter := NewRedisClickCounter(clients[0], testPrefix)
+	ctx := context.Background()
+
+	if err := counter.Increment(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := counter.Get(ctx, 1); err != nil || ok {
+		t.Fatalf("compteur non amorcé : ok=%v, err=%v, attendu ok=false", ok, err)
+	}
+}
+
+func TestClickCounterSeedCountsPendingClicks(t *testing.T) {
+	server, clients := newTestRedis(t, 1)
+	counter := NewRedisClickCounter(clients[0], testPrefix)
+	ctx := context.Background()
+
+	// Trois clics comptés avant l'amorçage, dont un seul écrit en base.
+	for i := 0; i < 3; i++ {
+		if err := counter.Increment(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := counter.Flushed(ctx, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := counter.Seed(ctx, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertCount(t, counter, 1, 3)
+
+	// Un nouvel amorçage ne remplace pas le compteur existant.
+	if err := counter.Seed(ctx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	assertCount(t, counter, 1, 3)
+
+	if err := counter.Increment(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertCount(t, counter, 1, 4)
+
+	// L'écriture des clics en attente ne change pas le total.
+	if err := counter.Flushed(ctx, 1, 3); err != nil {
+		t.Fatal(err)
+	}
+	assertCount(t, counter, 1, 4)
+	if got, _ := server.Get(testPrefix + "clicks:pending:1"); got != "0" {
+		t.Errorf("clics en attente = %q, attendu 0", got)
+	}
+
+	if err := counter.Forget(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists(testPrefix+"clicks:1") || server.Exists(testPrefix+"clicks:pending:1") {
+		t.Error("Forget doit supprimer le total et les clics en attente")
+	}
+}
+
+func TestClickCounterFlushedDoesNotRecreateExpiredPending(t *testing.T) {
+	server, clients := newTestRedis(t, 1)
+	counter := NewRedisClickCounter(clients[0], testPrefix)
+	ctx := context.Background()
+
+	if err := counter.Flushed(ctx, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists(testPrefix + "clicks:pending:1") {
+		t.Error("Flushed ne doit pas créer de compteur d'attente négatif")
+	}
+	if err := counter.Seed(ctx, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	assertCount(t, counter, 1, 5)
+}
+
+func assertCount(t *testing.T, counter ClickCounter, linkID uint, want int) {
+	t.Helper()
+	got, ok, err := counter.Get(context.Background(), linkID)
+	if err != nil || !ok {
+		t.Fatalf("lecture du compteur : ok=%v, err=%v", ok, err)
+	}
+	if got != want {
+		t.Errorf("compteur = %d, attendu %d", got, want)
+	}
+}
@@ -1,14 +1,18 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/Julien-Somasundaram/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
 
 // ClickRepository définit les opérations sur les clics.
 type ClickRepository interface {
-	CreateClick(click *models.Click) error
+	CreateClick(ctx context.Context, click *models.Click) error
 	CountClicksByLinkID(linkID uint) (int, error)
+	CreateClicks(ctx context.Context, clicks []models.Click) error
+	FindClicksInBatches(batchSize int, fn func(clicks []models.Click) error) error
 }
 
 // GormClickRepository implémente ClickRepository avec GORM.
@@ -22,18 +26,39 @@ func NewGormClickRepository(db *gorm.DB) *GormClickRepository {
 	return &GormClickRepository{db: db}
 }
 
-// CreateClick insère un enregistrement de clic.
-func (r *GormClickRepository) CreateClick(click *models.Click) error {
-	result := r.db.Create(click)
-	return result.Error
+// CreateClick insère un enregistrement de clic et met à jour ses agrégats.
+func (r *GormClickRepository) CreateClick(ctx context.Context, click *models.Click) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(click).Error; err != nil {
+			return err
+		}
+		return applyRollups(tx, []models.Click{*click})
+	})
 }
 
-// CountClicksByLinkID retourne le nombre de clics pour un lien.
+// CountClicksByLinkID retourne le nombre de clics pour un lien, d'après ses agrégats journaliers.
 func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64
-	result := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count)
-	if result.Error != nil {
-		return 0, result.Error
+	return countRollupClicks(r.db, linkID)
+}
+
+// CreateClicks insère plusieurs clics en une seule requête et met à jour
+// leurs agrégats dans la même transaction.
+func (r *GormClickRepository) CreateClicks(ctx context.Context, clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
 	}
-	return int(count), nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Link").Create(&clicks).Error; err != nil {
+			return err
+		}
+		return applyRollups(tx, clicks)
+	})
+}
+
+// FindClicksInBatches parcourt les clics par lots, dans l'ordre des identifiants.
+func (r *GormClickRepository) FindClicksInBatches(batchSize int, fn func(clicks []models.Click) error) error {
+	var clicks []models.Click
+	return r.db.Order("id").FindInBatches(&clicks, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(clicks)
+	}).Error
 }
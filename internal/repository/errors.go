@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrShortCodeTaken est retournée quand l'insertion d'un lien viole l'unicité de son code court.
+var ErrShortCodeTaken = errors.New("code court déjà utilisé")
+
+// isUniqueViolation reconnaît une violation de contrainte d'unicité portant sur
+// l'une des colonnes ou l'un des index nommés, d'après l'erreur traduite par
+// GORM ou le message du pilote (qui cite la colonne ou l'index selon le SGBD).
+func isUniqueViolation(err error, names ...string) bool {
+	if err == nil {
+		return false
+	}
+	message := err.Error()
+	if !slices.ContainsFunc(names, func(name string) bool { return strings.Contains(message, name) }) {
+		return false
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey) ||
+		strings.Contains(message, "UNIQUE constraint failed") || // SQLite : nom de colonne
+		strings.Contains(message, "duplicate key value violates unique constraint") || // PostgreSQL : nom d'index
+		strings.Contains(message, "Duplicate entry") // MySQL : nom d'index
+}
+
+// IsDataError indique une erreur due au contenu d'une ligne (contrainte
+// d'unicité, de clé étrangère ou de valeur non respectée, valeur trop longue),
+// que réessayer à l'identique ne corrigerait pas, par opposition aux erreurs
+// passagères (base verrouillée, connexion perdue).
+func IsDataError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) || errors.Is(err, gorm.ErrForeignKeyViolated) || errors.Is(err, gorm.ErrCheckConstraintViolated) {
+		return true
+	}
+	message := err.Error()
+	for _, marker := range []string{
+		"constraint failed",      // SQLite : UNIQUE, FOREIGN KEY, NOT NULL, CHECK
+		"violates",               // PostgreSQL : contraintes (SQLSTATE 23xxx)
+		"value too long",         // PostgreSQL : SQLSTATE 22001
+		"invalid byte sequence",  // PostgreSQL : UTF-8 invalide
+		"Duplicate entry",        // MySQL 1062
+		"foreign key constraint", // MySQL 1451, 1452
+		"cannot be null",         // MySQL 1048
+		"Data too long",          // MySQL 1406
+		"Incorrect string value", // MySQL 1366
+	} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,15 +1,27 @@
 package repository
 
 import (
+	"context"
+	"strings"
+
 	"github.com/Julien-Somasundaram/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
 
 type LinkRepository interface {
 	CreateLink(link *models.Link) error
-	GetLinkByShortCode(shortCode string) (*models.Link, error)
+	GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error)
 	GetAllLinks() ([]models.Link, error)
 	CountClicksByLinkID(linkID uint) (int, error)
+	UpdateLinkMetadata(link *models.Link) error
+	DisableLink(linkID uint, reason string) error
+	GetLinksByCanonicalHash(hash string) ([]models.Link, error)
+	UpdateCanonicalHash(linkID uint, hash string) error
+	ReplaceLink(link *models.Link) error
+	UpdateLinkColumns(link *models.Link, columns ...string) error
+	FindLinksInBatches(batchSize int, fn func(links []models.Link) error) error
+	SearchLinks(query string, offset, limit int) ([]models.Link, int64, error)
+	DeleteLink(linkID uint) error
 }
 
 type GormLinkRepository struct {
@@ -21,13 +33,23 @@ func NewGormLinkRepository(db *gorm.DB) *GormLinkRepository {
 	return &GormLinkRepository{db: db}
 }
 
+// CreateLink insère le lien. ErrShortCodeTaken signale un code court déjà
+// attribué : l'unicité est garantie par l'index de la base, sans lecture préalable.
 func (r *GormLinkRepository) CreateLink(link *models.Link) error {
-	return r.db.Create(link).Error
+	// Transaction dédiée (point de sauvegarde si une transaction est en cours) :
+	// un échec n'invalide pas la transaction englobante et permet un nouvel essai.
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(link).Error
+	})
+	if isUniqueViolation(err, "shortcode", "idx_links_short_code") {
+		return ErrShortCodeTaken
+	}
+	return err
 }
 
-func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+func (r *GormLinkRepository) GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
 	var link models.Link
-	result := r.db.Where("shortcode = ?", shortCode).First(&link)
+	result := r.db.WithContext(ctx).Where("shortcode = ?", shortCode).First(&link)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -43,11 +65,104 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
+// CountClicksByLinkID retourne le nombre de clics du lien, d'après ses agrégats journaliers.
 func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
-	var count int64
-	result := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count)
+	return countRollupClicks(r.db, linkID)
+}
+
+// UpdateLinkMetadata enregistre uniquement les métadonnées de destination du lien.
+func (r *GormLinkRepository) UpdateLinkMetadata(link *models.Link) error {
+	return r.db.Model(link).
+		Select("title", "description", "favicon_url", "image_url", "metadata_fetched_at").
+		Updates(link).Error
+}
+
+// DisableLink désactive un lien en conservant le motif de désactivation.
+func (r *GormLinkRepository) DisableLink(linkID uint, reason string) error {
+	return r.db.Model(&models.Link{}).Where("id = ?", linkID).
+		Updates(map[string]interface{}{"disabled": true, "disabled_reason": reason}).Error
+}
+
+// GetLinksByCanonicalHash retourne les liens actifs pointant vers la même URL canonique.
+func (r *GormLinkRepository) GetLinksByCanonicalHash(hash string) ([]models.Link, error) {
+	var links []models.Link
+	result := r.db.Where("canonical_url_hash = ? AND disabled = ?", hash, false).Order("id").Find(&links)
 	if result.Error != nil {
-		return 0, result.Error
+		return nil, result.Error
 	}
-	return int(count), nil
+	return links, nil
+}
+
+// UpdateCanonicalHash enregistre l'empreinte canonique d'un lien existant.
+func (r *GormLinkRepository) UpdateCanonicalHash(linkID uint, hash string) error {
+	return r.db.Model(&models.Link{}).Where("id = ?", linkID).Update("canonical_url_hash", hash).Error
+}
+
+// ReplaceLink enregistre toutes les colonnes d'un lien existant (import en mode écrasement).
+func (r *GormLinkRepository) ReplaceLink(link *models.Link) error {
+	return r.db.Save(link).Error
+}
+
+// UpdateLinkColumns enregistre les seules colonnes données d'un lien existant,
+// sans écraser les autres avec une copie du lien éventuellement périmée.
+func (r *GormLinkRepository) UpdateLinkColumns(link *models.Link, columns ...string) error {
+	result := r.db.Model(link).Select(columns).Updates(link)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// FindLinksInBatches parcourt les liens par lots, dans l'ordre des identifiants,
+// sans charger toute la table en mémoire.
+func (r *GormLinkRepository) FindLinksInBatches(batchSize int, fn func(links []models.Link) error) error {
+	var links []models.Link
+	return r.db.Order("id").FindInBatches(&links, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(links)
+	}).Error
+}
+
+// SearchLinks retourne une page des liens dont le code court, l'URL longue ou
+// le titre contient query (tous si vide), du plus récent au plus ancien, et le
+// nombre total de liens correspondants.
+func (r *GormLinkRepository) SearchLinks(query string, offset, limit int) ([]models.Link, int64, error) {
+	db := r.db.Model(&models.Link{})
+	if query != "" {
+		pattern := "%" + escapeLike(query) + "%"
+		db = db.Where("shortcode LIKE ? ESCAPE '!' OR long_url LIKE ? ESCAPE '!' OR title LIKE ? ESCAPE '!'", pattern, pattern, pattern)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var links []models.Link
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&links).Error
+	return links, total, err
+}
+
+// DeleteLink supprime le lien, ses clics et ses agrégats.
+func (r *GormLinkRepository) DeleteLink(linkID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{models.ClickRollupsHourly, models.ClickRollupsDaily} {
+			if err := tx.Table(table).Where("link_id = ?", linkID).Delete(nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("link_id = ?", linkID).Delete(&models.Click{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Link{}, linkID)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return result.Error
+	})
+}
+
+// escapeLike protège les caractères spéciaux de LIKE. Le caractère
+// d'échappement « ! » est interprété de la même façon par tous les SGBD,
+// contrairement à la barre oblique inverse dans les littéraux MySQL.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
 }
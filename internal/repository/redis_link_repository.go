@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/metrics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// redisTimeout borne chaque appel à Redis : une redirection ne doit pas
+// attendre un cache indisponible.
+const redisTimeout = 200 * time.Millisecond
+
+// notFoundMarker est la valeur en cache d'un code inconnu.
+const notFoundMarker = "-"
+
+// LinkInvalidator retire des liens d'un cache, et des caches qu'il alimente.
+type LinkInvalidator interface {
+	Invalidate(shortCode string)
+	InvalidateID(linkID uint)
+}
+
+// CachingLinkRepository est un LinkRepository doté d'un cache invalidable.
+type CachingLinkRepository interface {
+	LinkRepository
+	LinkInvalidator
+}
+
+// RedisLinkRepository partage le cache de résolution des codes entre
+// instances via un serveur compatible Redis. Chaque invalidation est publiée
+// sur un canal pour que les caches locaux des autres instances l'appliquent.
+// En cas d'erreur Redis, les lectures retombent sur la base.
+type RedisLinkRepository struct {
+	LinkRepository
+	client      *redis.Client
+	prefix      string
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// NewRedisLinkRepository crée le cache partagé devant inner. Les clés sont
+// préfixées par prefix, ce qui permet à plusieurs déploiements de partager un serveur.
+func NewRedisLinkRepository(inner LinkRepository, client *redis.Client, prefix string, ttl, negativeTTL time.Duration) *RedisLinkRepository {
+	return &RedisLinkRepository{
+		LinkRepository: inner,
+		client:         client,
+		prefix:         prefix,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+	}
+}
+
+// InvalidationChannel retourne le canal de publication des invalidations.
+func InvalidationChannel(prefix string) string {
+	return prefix + "invalidate"
+}
+
+func (r *RedisLinkRepository) codeKey(shortCode string) string {
+	return r.prefix + "link:" + shortCode
+}
+
+func (r *RedisLinkRepository) idKey(linkID uint) string {
+	return r.prefix + "link-id:" + strconv.FormatUint(uint64(linkID), 10)
+}
+
+// generationKey compte les invalidations : un remplissage n'est écrit que si
+// aucune invalidation n'a eu lieu depuis le début de la lecture en base.
+func (r *RedisLinkRepository) generationKey() string {
+	return r.prefix + "generation"
+}
+
+func (r *RedisLinkRepository) GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
+	redisCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	cached, err := r.client.Get(redisCtx, r.codeKey(shortCode)).Result()
+	switch {
+	case err == nil && cached == notFoundMarker:
+		metrics.CacheRequests.WithLabelValues("redis", "hit").Inc()
+		return nil, gorm.ErrRecordNotFound
+	case err == nil:
+		var link models.Link
+		if err := json.Unmarshal([]byte(cached), &link); err == nil {
+			metrics.CacheRequests.WithLabelValues("redis", "hit").Inc()
+			return &link, nil
+		}
+		metrics.CacheRequests.WithLabelValues("redis", "error").Inc()
+	case errors.Is(err, redis.Nil):
+		metrics.CacheRequests.WithLabelValues("redis", "miss").Inc()
+	default:
+		metrics.CacheRequests.WithLabelValues("redis", "error").Inc()
+		slog.Warn("Cache Redis indisponible", "error", err)
+	}
+
+	generation, genErr := r.client.Get(redisCtx, r.generationKey()).Result()
+	if genErr != nil && !errors.Is(genErr, redis.Nil) {
+		// Redis indisponible : lecture en base sans remplissage.
+		return r.LinkRepository.GetLinkByShortCode(ctx, shortCode)
+	}
+
+	link, err := r.LinkRepository.GetLinkByShortCode(ctx, shortCode)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if r.negativeTTL > 0 {
+			r.fill(redisCtx, generation, func(pipe redis.Pipeliner) {
+				pipe.Set(redisCtx, r.codeKey(shortCode), notFoundMarker, r.negativeTTL)
+			})
+		}
+	case err == nil:
+		if data, marshalErr := json.Marshal(link); marshalErr == nil {
+			r.fill(redisCtx, generation, func(pipe redis.Pipeliner) {
+				pipe.Set(redisCtx, r.codeKey(shortCode), data, r.ttl)
+				pipe.Set(redisCtx, r.idKey(link.ID), shortCode, r.ttl)
+			})
+		}
+	}
+	return link, err
+}
+
+// fill exécute les écritures du remplissage si la génération vaut toujours
+// generation, lue avant la lecture en base ; sinon le cache reste vide.
+func (r *RedisLinkRepository) fill(ctx context.Context, generation string, writes func(pipe redis.Pipeliner)) {
+	key := r.generationKey()
+	r.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if current != generation {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			writes(pipe)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+func (r *RedisLinkRepository) CreateLink(link *models.Link) error {
+	err := r.LinkRepository.CreateLink(link)
+	if err == nil {
+		r.Invalidate(link.ShortCode)
+	}
+	return err
+}
+
+func (r *RedisLinkRepository) UpdateLinkMetadata(link *models.Link) error {
+	defer r.Invalidate(link.ShortCode)
+	return r.LinkRepository.UpdateLinkMetadata(link)
+}
+
+func (r *RedisLinkRepository) DisableLink(linkID uint, reason string) error {
+	defer r.InvalidateID(linkID)
+	return r.LinkRepository.DisableLink(linkID, reason)
+}
+
+func (r *RedisLinkRepository) UpdateCanonicalHash(linkID uint, hash string) error {
+	defer r.InvalidateID(linkID)
+	return r.LinkRepository.UpdateCanonicalHash(linkID, hash)
+}
+
+func (r *RedisLinkRepository) ReplaceLink(link *models.Link) error {
+	defer r.Invalidate(link.ShortCode)
+	return r.LinkRepository.ReplaceLink(link)
+}
+
+func (r *RedisLinkRepository) UpdateLinkColumns(link *models.Link, columns ...string) error {
+	defer r.Invalidate(link.ShortCode)
+	return r.LinkRepository.UpdateLinkColumns(link, columns...)
+}
+
+func (r *RedisLinkRepository) DeleteLink(linkID uint) error {
+	defer r.InvalidateID(linkID)
+	return r.LinkRepository.DeleteLink(linkID)
+}
+
+// Invalidate supprime le code du cache partagé et publie l'invalidation.
+func (r *RedisLinkRepository) Invalidate(shortCode string) {
+	if shortCode == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	pipe := r.client.TxPipeline()
+	pipe.Incr(ctx, r.generationKey())
+	pipe.Del(ctx, r.codeKey(shortCode))
+	pipe.Publish(ctx, InvalidationChannel(r.prefix), "code:"+shortCode)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("Invalidation Redis impossible", "short_code", shortCode, "error", err)
+	}
+}
+
+// InvalidateID supprime le lien du cache partagé à partir de son identifiant.
+func (r *RedisLinkRepository) InvalidateID(linkID uint) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	shortCode, err := r.client.GetDel(ctx, r.idKey(linkID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		slog.Warn("Invalidation Redis impossible", "link_id", linkID, "error", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Incr(ctx, r.generationKey())
+	if shortCode != "" {
+		pipe.Del(ctx, r.codeKey(shortCode))
+	}
+	pipe.Publish(ctx, InvalidationChannel(r.prefix), "id:"+strconv.FormatUint(uint64(linkID), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("Invalidation Redis impossible", "link_id", linkID, "error", err)
+	}
+}
+
+// LocalEvicter retire des entrées d'un cache local, sans propagation.
+type LocalEvicter interface {
+	Evict(shortCode string)
+	EvictID(linkID uint)
+}
+
+// SubscribeInvalidations applique au cache local les invalidations publiées
+// par toutes les instances, jusqu'à l'annulation de ctx. go-redis rétablit
+// l'abonnement après une coupure ; le cache local est alors vidé, des
+// messages ayant pu être perdus.
+func SubscribeInvalidations(ctx context.Context, client *redis.Client, prefix string, local interface {
+	LocalEvicter
+	Purge()
+}) {
+	pubsub := client.Subscribe(ctx, InvalidationChannel(prefix))
+	go func() {
+		defer pubsub.Close()
+		for {
+			message, err := pubsub.ReceiveMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Warn("Abonnement aux invalidations interrompu", "error", err)
+				local.Purge()
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+
+			kind, value, _ := strings.Cut(message.Payload, ":")
+			switch kind {
+			case "code":
+				local.Evict(value)
+			case "id":
+				if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+					local.EvictID(uint(id))
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testPrefix = "test:"
+
+// newTestLinks ouvre une base SQLite en mémoire contenant les liens donnés.
+func newTestLinks(t *testing.T, codes ...string) (*gorm.DB, *GormLinkRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("ouverture base : %v", err)
+	}
+	// Chaque connexion à ":memory:" ouvre une base distincte.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
+		t.Fatalf("création schéma : %v", err)
+	}
+	links := NewGormLinkRepository(db)
+	for _, code := range codes {
+		link := &models.Link{ShortCode: code, LongURL: "https://example.com/" + code, CreatedAt: time.Now()}
+		if err := links.CreateLink(link); err != nil {
+			t.Fatalf("création lien %s : %v", code, err)
+		}
+	}
+	return db, links
+}
+
+// newTestRedis démarre un serveur miniredis et retourne un client par instance simulée.
+func newTestRedis(t *testing.T, clients int) (*miniredis.Miniredis, []*redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	result := make([]*redis.Client, clients)
+	for i := range result {
+		result[i] = redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { result[i].Close() })
+	}
+	return server, result
+}
+
+func TestRedisLinkRepositoryFillsCache(t *testing.T) {
+	db, links := newTestLinks(t, "abc123")
+	server, clients := newTestRedis(t, 1)
+	repo := NewRedisLinkRepository(links, clients[0], testPrefix, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	link, err := repo.GetLinkByShortCode(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("lecture : %v", err)
+	}
+	if !server.Exists(testPrefix + "link:abc123") {
+		t.Fatal("le lien n'a pas été mis en cache")
+	}
+	if got, _ := server.Get(testPrefix + "link-id:1"); got != "abc123" {
+		t.Errorf("index identifiant → code = %q, attendu abc123", got)
+	}
+
+	// Le lien supprimé de la base reste servi par le cache.
+	if err := db.Exec("DELETE FROM links").Error; err != nil {
+		t.Fatal(err)
+	}
+	cached, err := repo.GetLinkByShortCode(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("lecture depuis le cache : %v", err)
+	}
+	if cached.ID != link.ID || cached.LongURL != link.LongURL {
+		t.Errorf("lien en cache = %+v, attendu %+v", cached, link)
+	}
+
+	// Un code inconnu est mis en cache négatif.
+	if _, err := repo.GetLinkByShortCode(ctx, "absent"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("code inconnu : erreur %v, attendu ErrRecordNotFound", err)
+	}
+	if got, _ := server.Get(testPrefix + "link:absent"); got != notFoundMarker {
+		t.Errorf("cache négatif = %q, attendu %q", got, notFoundMarker)
+	}
+}
+
+func TestRedisLinkRepositoryInvalidationFanOut(t *testing.T) {
+	_, links := newTestLinks(t, "abc123")
+	server, clients := newTestRedis(t, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Deux instances : cache local devant le cache partagé, chacune abonnée aux invalidations.
+	instances := make([]*CachedLinkRepository, len(clients))
+	for i, client := range clients {
+		shared := NewRedisLinkRepository(links, client, testPrefix, time.Hour, time.Minute)
+		instances[i] = NewCachedLinkRepository(shared, 10, time.Hour, time.Minute)
+		SubscribeInvalidations(ctx, client, testPrefix, instances[i])
+	}
+	waitFor(t, func() bool {
+		return server.PubSubNumSub(InvalidationChannel(testPrefix))[InvalidationChannel(testPrefix)] == len(clients)
+	})
+
+	for _, instance := range instances {
+		link, err := instance.GetLinkByShortCode(ctx, "abc123")
+		if err != nil || link.Disabled {
+			t.Fatalf("lecture initiale : %+v, %v", link, err)
+		}
+	}
+
+	// La désactivation par la première instance doit atteindre le cache local de la seconde.
+	if err := instances[0].DisableLink(1, "test"); err != nil {
+		t.Fatalf("désactivation : %v", err)
+	}
+	waitFor(t, func() bool {
+		link, err := instances[1].GetLinkByShortCode(ctx, "abc123")
+		return err == nil && link.Disabled
+	})
+
+	// Idem pour une invalidation par code.
+	link, _ := links.GetLinkByShortCode(ctx, "abc123")
+	link.Title = "Nouveau titre"
+	if err := instances[1].UpdateLinkMetadata(link); err != nil {
+		t.Fatalf("mise à jour : %v", err)
+	}
+	waitFor(t, func() bool {
+		link, err := instances[0].GetLinkByShortCode(ctx, "abc123")
+		return err == nil && link.Title == "Nouveau titre"
+	})
+}
+
+// waitFor attend que condition soit vraie, les invalidations étant asynchrones.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition non remplie après 2s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
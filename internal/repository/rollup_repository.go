@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/analytics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Granularity est la période d'agrégation des clics.
+type Granularity string
+
+const (
+	GranularityHour Granularity = "hour"
+	GranularityDay  Granularity = "day"
+)
+
+var granularities = []Granularity{GranularityHour, GranularityDay}
+
+// Table retourne la table des agrégats de cette granularité.
+func (g Granularity) Table() string {
+	if g == GranularityHour {
+		return models.ClickRollupsHourly
+	}
+	return models.ClickRollupsDaily
+}
+
+// Truncate retourne le début de l'heure ou du jour (UTC) contenant t.
+func (g Granularity) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if g == GranularityHour {
+		return t.Truncate(time.Hour)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Step retourne la durée d'une période.
+func (g Granularity) Step() time.Duration {
+	if g == GranularityHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// RollupPoint est le nombre de clics d'une période.
+type RollupPoint struct {
+	Bucket time.Time
+	Clicks int64
+}
+
+// RollupValue est le nombre de clics d'une valeur de dimension.
+type RollupValue struct {
+	Value  string
+	Clicks int64
+}
+
+// RollupRepository lit et recalcule les agrégats de clics.
+type RollupRepository interface {
+	// Series retourne les périodes de [from, to) ayant reçu des clics, dans l'ordre.
+	Series(linkID uint, granularity Granularity, from, to time.Time) ([]RollupPoint, error)
+	// Breakdown retourne les limit valeurs de la dimension les plus cliquées sur [from, to).
+	Breakdown(linkID uint, granularity Granularity, dimension string, from, to time.Time, limit int) ([]RollupValue, error)
+	// NeedsRebuild indique si aucun agrégat n'existe alors que des clics sont enregistrés.
+	NeedsRebuild() (bool, error)
+	// Rebuild recalcule tous les agrégats depuis la table clicks, lien par lien,
+	// et retourne le nombre de clics lus.
+	Rebuild() (int64, error)
+}
+
+// GormRollupRepository implémente RollupRepository avec GORM.
+type GormRollupRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRollupRepository crée un dépôt GORM pour les agrégats de clics.
+func NewGormRollupRepository(db *gorm.DB) *GormRollupRepository {
+	return &GormRollupRepository{db: db}
+}
+
+func (r *GormRollupRepository) Series(linkID uint, granularity Granularity, from, to time.Time) ([]RollupPoint, error) {
+	var points []RollupPoint
+	err := r.db.Table(granularity.Table()).
+		Select("bucket, clicks").
+		Where("link_id = ? AND dimension = ? AND bucket >= ? AND bucket < ?", linkID, models.DimensionTotal, from.UTC(), to.UTC()).
+		Order("bucket").
+		Scan(&points).Error
+	return points, err
+}
+
+func (r *GormRollupRepository) Breakdown(linkID uint, granularity Granularity, dimension string, from, to time.Time, limit int) ([]RollupValue, error) {
+	var values []RollupValue
+	err := r.db.Table(granularity.Table()).
+		Select("value, SUM(clicks) AS clicks").
+		Where("link_id = ? AND dimension = ? AND bucket >= ? AND bucket < ?", linkID, dimension, from.UTC(), to.UTC()).
+		Group("value").
+		Order("clicks DESC, value").
+		Limit(limit).
+		Scan(&values).Error
+	return values, err
+}
+
+func (r *GormRollupRepository) NeedsRebuild() (bool, error) {
+	var rollups, clicks bool
+	if err := r.db.Raw("SELECT EXISTS (SELECT 1 FROM " + models.ClickRollupsDaily + ")").Scan(&rollups).Error; err != nil || rollups {
+		return false, err
+	}
+	err := r.db.Raw("SELECT EXISTS (SELECT 1 FROM clicks)").Scan(&clicks).Error
+	return clicks, err
+}
+
+// rebuildLinkBatch est le nombre d'identifiants de liens lus à la fois par Rebuild.
+const rebuildLinkBatch = 500
+
+// Rebuild recalcule les agrégats lien par lien, chacun dans sa propre
+// transaction : les statistiques d'un lien ne sont jamais lues à moitié
+// recalculées, et l'enregistrement des nouveaux clics n'attend que le calcul
+// du lien en cours, pas celui de toute la table.
+func (r *GormRollupRepository) Rebuild() (int64, error) {
+	var total int64
+	var lastID uint
+	for {
+		var ids []uint
+		err := r.db.Model(&models.Link{}).Where("id > ?", lastID).Order("id").Limit(rebuildLinkBatch).Pluck("id", &ids).Error
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			clicks, err := r.rebuildLink(id)
+			total += clicks
+			if err != nil {
+				return total, fmt.Errorf("agrégats du lien %d : %w", id, err)
+			}
+		}
+		lastID = ids[len(ids)-1]
+	}
+
+	// Agrégats de liens supprimés sans passer par DeleteLink
+	for _, g := range granularities {
+		if err := r.db.Exec("DELETE FROM " + g.Table() + " WHERE link_id NOT IN (SELECT id FROM links)").Error; err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// rebuildLink remplace les agrégats du lien par ceux calculés depuis ses clics.
+func (r *GormRollupRepository) rebuildLink(linkID uint) (int64, error) {
+	var total int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, g := range granularities {
+			if err := tx.Exec("DELETE FROM "+g.Table()+" WHERE link_id = ?", linkID).Error; err != nil {
+				return err
+			}
+		}
+		var clicks []models.Click
+		// Les agrégats passent par tx : la session du lot porte encore les clauses de la lecture.
+		return tx.Where("link_id = ?", linkID).Order("id").FindInBatches(&clicks, 1000, func(_ *gorm.DB, _ int) error {
+			total += int64(len(clicks))
+			return applyRollups(tx, clicks)
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// countRollupClicks retourne le nombre total de clics d'un lien.
+func countRollupClicks(db *gorm.DB, linkID uint) (int, error) {
+	var count int64
+	err := db.Table(models.ClickRollupsDaily).
+		Select("COALESCE(SUM(clicks), 0)").
+		Where("link_id = ? AND dimension = ?", linkID, models.DimensionTotal).
+		Scan(&count).Error
+	return int(count), err
+}
+
+type rollupKey struct {
+	linkID    uint
+	bucket    time.Time
+	dimension string
+	value     string
+}
+
+// applyRollups ajoute les clics aux agrégats, à appeler dans la transaction
+// qui les enregistre.
+func applyRollups(tx *gorm.DB, clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	// Addition à la ligne existante : excluded (SQLite, PostgreSQL) ou VALUES() (MySQL).
+	for _, g := range granularities {
+		increment := g.Table() + ".clicks + excluded.clicks"
+		if tx.Dialector.Name() == "mysql" {
+			increment = "clicks + VALUES(clicks)"
+		}
+
+		rows := aggregateRollups(clicks, g)
+		err := tx.Table(g.Table()).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "link_id"}, {Name: "bucket"}, {Name: "dimension"}, {Name: "value"}},
+			DoUpdates: clause.Assignments(map[string]any{"clicks": gorm.Expr(increment)}),
+		}).CreateInBatches(&rows, 500).Error
+		if err != nil {
+			return fmt.Errorf("mise à jour de %s : %w", g.Table(), err)
+		}
+	}
+	return nil
+}
+
+// aggregateRollups regroupe les clics par lien, période et valeur de chaque
+// dimension, dans un ordre stable pour éviter les interblocages entre écrivains.
+func aggregateRollups(clicks []models.Click, g Granularity) []models.ClickRollup {
+	counts := make(map[rollupKey]int64)
+	for _, click := range clicks {
+		bucket := g.Truncate(click.Timestamp)
+		for _, dv := range [][2]string{
+			{models.DimensionTotal, ""},
+			{models.DimensionReferrer, click.Referrer},
+			{models.DimensionCountry, click.Country},
+			{models.DimensionDevice, analytics.Device(click.UserAgent)},
+		} {
+			counts[rollupKey{linkID: click.LinkID, bucket: bucket, dimension: dv[0], value: dv[1]}]++
+		}
+	}
+
+	rows := make([]models.ClickRollup, 0, len(counts))
+	for key, count := range counts {
+		rows = append(rows, models.ClickRollup{
+			LinkID: key.linkID, Bucket: key.bucket, Dimension: key.dimension, Value: key.value, Clicks: count,
+		})
+	}
+	slices.SortFunc(rows, func(a, b models.ClickRollup) int {
+		return cmp.Or(cmp.Compare(a.LinkID, b.LinkID), a.Bucket.Compare(b.Bucket),
+			cmp.Compare(a.Dimension, b.Dimension), cmp.Compare(a.Value, b.Value))
+	})
+	return rows
+}
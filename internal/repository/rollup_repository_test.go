@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestStore ouvre une base SQLite en mémoire migrée, agrégats compris.
+func newTestStore(t *testing.T) (*gorm.DB, *GormStore) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("ouverture base : %v", err)
+	}
+	// Chaque connexion à ":memory:" ouvre une base distincte.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("migrations : %v", err)
+	}
+	return db, NewGormStore(db)
+}
+
+// rollupSnapshot retourne le contenu d'une table d'agrégats, indexé par
+// lien, période, dimension et valeur.
+func rollupSnapshot(t *testing.T, db *gorm.DB, g Granularity) map[string]int64 {
+	t.Helper()
+	var rows []models.ClickRollup
+	if err := db.Table(g.Table()).Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	snapshot := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		snapshot[fmt.Sprintf("%d %s %s=%s", row.LinkID, row.Bucket.UTC().Format(time.RFC3339), row.Dimension, row.Value)] = row.Clicks
+	}
+	return snapshot
+}
+
+func TestRollupsIncrementAndRebuild(t *testing.T) {
+	db, store := newTestStore(t)
+	ctx := context.Background()
+	var links [2]*models.Link
+	for i := range links {
+		links[i] = &models.Link{ShortCode: fmt.Sprintf("roll%02d", i), LongURL: "https://example.com/", CreatedAt: time.Now()}
+		if err := store.Links().CreateLink(links[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	first, second := links[0].ID, links[1].ID
+	// Clics enregistrés en plusieurs fois sur les mêmes périodes : les agrégats sont incrémentés.
+	batches := [][]models.Click{
+		{
+			{LinkID: first, Timestamp: day.Add(9*time.Hour + 5*time.Minute), Country: "FR"},
+			{LinkID: first, Timestamp: day.Add(9*time.Hour + 50*time.Minute), Country: "FR"},
+			{LinkID: second, Timestamp: day.Add(9 * time.Hour), Referrer: "example.org"},
+		},
+		{
+			{LinkID: first, Timestamp: day.Add(9*time.Hour + 59*time.Minute), Country: "BE"},
+			{LinkID: first, Timestamp: day.Add(23 * time.Hour)},
+		},
+		{
+			{LinkID: first, Timestamp: day.Add(24*time.Hour + time.Minute)},
+		},
+	}
+	for _, batch := range batches {
+		if err := store.Clicks().CreateClicks(ctx, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Clicks().CreateClick(ctx, &models.Click{LinkID: second, Timestamp: day.Add(9*time.Hour + 30*time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	hourly, daily := rollupSnapshot(t, db, GranularityHour), rollupSnapshot(t, db, GranularityDay)
+	at := func(linkID uint, bucket time.Time, dimension, value string) string {
+		return fmt.Sprintf("%d %s %s=%s", linkID, bucket.Format(time.RFC3339), dimension, value)
+	}
+	for key, want := range map[string]int64{
+		at(first, day.Add(9*time.Hour), models.DimensionTotal, ""):     3,
+		at(first, day.Add(9*time.Hour), models.DimensionCountry, "FR"): 2,
+		at(first, day.Add(9*time.Hour), models.DimensionCountry, "BE"): 1,
+		at(first, day.Add(23*time.Hour), models.DimensionTotal, ""):    1,
+		at(first, day.Add(24*time.Hour), models.DimensionTotal, ""):    1,
+		at(second, day.Add(9*time.Hour), models.DimensionTotal, ""):    2,
+	} {
+		if hourly[key] != want {
+			t.Errorf("horaire %s = %d, attendu %d", key, hourly[key], want)
+		}
+	}
+	for key, want := range map[string]int64{
+		at(first, day, models.DimensionTotal, ""):                   4,
+		at(first, day.Add(24*time.Hour), models.DimensionTotal, ""): 1,
+		at(second, day, models.DimensionTotal, ""):                  2,
+		at(second, day, models.DimensionReferrer, "example.org"):    1,
+		at(second, day, models.DimensionReferrer, ""):               1,
+	} {
+		if daily[key] != want {
+			t.Errorf("journalier %s = %d, attendu %d", key, daily[key], want)
+		}
+	}
+	if count, err := store.Clicks().CountClicksByLinkID(first); err != nil || count != 5 {
+		t.Errorf("total du premier lien = %d, %v, attendu 5", count, err)
+	}
+
+	// Agrégats faussés, d'un lien supprimé ou manquants : le recalcul depuis
+	// la table clicks retrouve ceux tenus à jour à l'enregistrement.
+	for _, statement := range []string{
+		"UPDATE " + models.ClickRollupsHourly + " SET clicks = 99",
+		"DELETE FROM " + models.ClickRollupsDaily + " WHERE link_id = " + fmt.Sprint(second),
+		"INSERT INTO " + models.ClickRollupsDaily + " (link_id, bucket, dimension, value, clicks) VALUES (999, '2026-03-14 00:00:00', 'total', '', 7)",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	clicks, err := store.Rollups().Rebuild()
+	if err != nil {
+		t.Fatalf("recalcul : %v", err)
+	}
+	if clicks != 7 {
+		t.Errorf("%d clic(s) lu(s), attendu 7", clicks)
+	}
+	if got := rollupSnapshot(t, db, GranularityHour); !maps.Equal(got, hourly) {
+		t.Errorf("agrégats horaires recalculés = %v\nattendu %v", got, hourly)
+	}
+	if got := rollupSnapshot(t, db, GranularityDay); !maps.Equal(got, daily) {
+		t.Errorf("agrégats journaliers recalculés = %v\nattendu %v", got, daily)
+	}
+}
+
+func TestRollupsNeedRebuild(t *testing.T) {
+	db, store := newTestStore(t)
+	if needed, err := store.Rollups().NeedsRebuild(); err != nil || needed {
+		t.Errorf("base vide : recalcul nécessaire = %v, %v", needed, err)
+	}
+
+	link := &models.Link{ShortCode: "roll01", LongURL: "https://example.com/", CreatedAt: time.Now()}
+	if err := store.Links().CreateLink(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Clicks().CreateClick(context.Background(), &models.Click{LinkID: link.ID, Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if needed, err := store.Rollups().NeedsRebuild(); err != nil || needed {
+		t.Errorf("agrégats à jour : recalcul nécessaire = %v, %v", needed, err)
+	}
+
+	// Clics antérieurs aux agrégats, comme après la migration qui les introduit.
+	for _, g := range granularities {
+		if err := db.Exec("DELETE FROM " + g.Table()).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if needed, err := store.Rollups().NeedsRebuild(); err != nil || !needed {
+		t.Errorf("agrégats absents : recalcul nécessaire = %v, %v", needed, err)
+	}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SequenceRepository définit les opérations sur les compteurs persistants.
+type SequenceRepository interface {
+	NextValue(name string) (uint64, error)
+}
+
+// GormSequenceRepository implémente SequenceRepository avec GORM.
+type GormSequenceRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSequenceRepository crée un nouveau dépôt GORM pour les compteurs.
+func NewGormSequenceRepository(db *gorm.DB) *GormSequenceRepository {
+	return &GormSequenceRepository{db: db}
+}
+
+// NextValue incrémente le compteur et retourne sa nouvelle valeur. L'incrément
+// et la lecture ont lieu dans la même transaction pour rester atomiques.
+func (r *GormSequenceRepository) NextValue(name string) (uint64, error) {
+	var sequence models.Sequence
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Crée le compteur à zéro s'il n'existe pas encore.
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Sequence{Name: name}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Sequence{}).Where("name = ?", name).
+			Update("value", gorm.Expr("value + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ?", name).First(&sequence).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sequence.Value, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Store donne accès aux dépôts partageant une même connexion, ou une même
+// transaction lorsqu'il est obtenu via Transaction.
+type Store interface {
+	Links() LinkRepository
+	Campaigns() CampaignRepository
+	Sequences() SequenceRepository
+	Clicks() ClickRepository
+	Rollups() RollupRepository
+	// Transaction exécute fn dans une transaction, validée si fn ne retourne pas
+	// d'erreur. Imbriquée, elle s'appuie sur un point de sauvegarde.
+	Transaction(fn func(tx Store) error) error
+}
+
+// GormStore implémente Store avec GORM.
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore crée un Store sur la connexion GORM fournie.
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Links() LinkRepository {
+	return NewGormLinkRepository(s.db)
+}
+
+func (s *GormStore) Campaigns() CampaignRepository {
+	return NewGormCampaignRepository(s.db)
+}
+
+func (s *GormStore) Sequences() SequenceRepository {
+	return NewGormSequenceRepository(s.db)
+}
+
+func (s *GormStore) Clicks() ClickRepository {
+	return NewGormClickRepository(s.db)
+}
+
+func (s *GormStore) Rollups() RollupRepository {
+	return NewGormRollupRepository(s.db)
+}
+
+func (s *GormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormStore(tx))
+	})
+}
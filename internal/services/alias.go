@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalidAlias est retournée pour un alias au format incorrect ou réservé.
+	ErrInvalidAlias = errors.New("alias invalide")
+	// ErrAliasTaken est retournée quand l'alias demandé est déjà attribué.
+	ErrAliasTaken = errors.New("alias déjà utilisé")
+)
+
+// aliasPattern limite les alias à 3-10 caractères sûrs dans une URL (taille de la colonne shortcode).
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,10}$`)
+
+// reservedAliases correspond aux premiers segments déjà utilisés par les routes du serveur.
+var reservedAliases = map[string]struct{}{
+	"admin":   {},
+	"api":     {},
+	"health":  {},
+	"metrics": {},
+}
+
+// ValidateAlias vérifie qu'un alias personnalisé est utilisable comme code court.
+func ValidateAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w : 3 à 10 caractères parmi lettres, chiffres, '-' et '_'", ErrInvalidAlias)
+	}
+	if _, reserved := reservedAliases[strings.ToLower(alias)]; reserved {
+		return fmt.Errorf("%w : %q est réservé", ErrInvalidAlias, alias)
+	}
+	return nil
+}
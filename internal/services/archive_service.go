@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/analytics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/archive"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+)
+
+// Modes de résolution quand le code court importé est déjà utilisé.
+const (
+	ConflictSkip      = "skip"      // Le lien existant est conservé, le lien importé et ses clics ignorés
+	ConflictOverwrite = "overwrite" // Le lien existant prend les valeurs importées, ses clics sont conservés
+	ConflictRename    = "rename"    // Le lien importé reçoit un nouveau code
+	ConflictFail      = "fail"      // L'import est annulé en totalité
+)
+
+// archiveBatchSize est le nombre de liens ou de clics lus ou insérés à la fois.
+const archiveBatchSize = 500
+
+// ErrImportConflict signale un code déjà utilisé en mode ConflictFail.
+var ErrImportConflict = errors.New("code court déjà utilisé")
+
+// IsValidConflictMode indique si le mode de résolution des conflits est pris en charge.
+func IsValidConflictMode(mode string) bool {
+	switch mode {
+	case ConflictSkip, ConflictOverwrite, ConflictRename, ConflictFail:
+		return true
+	}
+	return false
+}
+
+// ArchiveService exporte et importe les liens et leurs clics.
+type ArchiveService struct {
+	store repository.Store
+	links *LinkService // Politique, normalisation et génération des codes
+}
+
+// NewArchiveService crée un service d'archives. linkService doit disposer
+// d'un Store pour la génération des codes dans la transaction d'import.
+func NewArchiveService(store repository.Store, linkService *LinkService) *ArchiveService {
+	return &ArchiveService{store: store, links: linkService}
+}
+
+// ExportSummary résume un export.
+type ExportSummary struct {
+	Links  int
+	Clicks int
+}
+
+// Export écrit tous les liens puis, si includeClicks, tous les clics dans une archive.
+func (s *ArchiveService) Export(w io.Writer, includeClicks bool) (ExportSummary, error) {
+	var summary ExportSummary
+
+	writer, err := archive.NewWriter(w, time.Now())
+	if err != nil {
+		return summary, err
+	}
+
+	codes := make(map[uint]string) // Identifiant → code, pour référencer les clics
+	campaigns := make(map[uint]string)
+	err = s.store.Links().FindLinksInBatches(archiveBatchSize, func(links []models.Link) error {
+		for i := range links {
+			link := &links[i]
+			record := &archive.LinkRecord{
+				ShortCode:      link.ShortCode,
+				LongURL:        link.LongURL,
+				ForwardQuery:   link.ForwardQuery,
+				ForwardPath:    link.ForwardPath,
+				RedirectStatus: link.RedirectStatus,
+				CacheMaxAge:    link.CacheMaxAge,
+				PasswordHash:   link.PasswordHash,
+				Title:          link.Title,
+				Description:    link.Description,
+				FaviconURL:     link.FaviconURL,
+				ImageURL:       link.ImageURL,
+				Disabled:       link.Disabled,
+				DisabledReason: link.DisabledReason,
+				CreatedAt:      link.CreatedAt,
+			}
+			if link.CampaignID != nil {
+				name, ok := campaigns[*link.CampaignID]
+				if !ok {
+					campaign, err := s.store.Campaigns().GetCampaignByID(*link.CampaignID)
+					if err != nil {
+						return fmt.Errorf("campagne %d du lien %s : %w", *link.CampaignID, link.ShortCode, err)
+					}
+					name = campaign.Name
+					campaigns[*link.CampaignID] = name
+				}
+				record.Campaign = name
+			}
+			if err := writer.WriteLink(record); err != nil {
+				return err
+			}
+			codes[link.ID] = link.ShortCode
+			summary.Links++
+		}
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	if includeClicks {
+		err = s.store.Clicks().FindClicksInBatches(archiveBatchSize, func(clicks []models.Click) error {
+			for _, click := range clicks {
+				code, ok := codes[click.LinkID]
+				if !ok {
+					continue // Clic orphelin d'un lien supprimé
+				}
+				if err := writer.WriteClick(&archive.ClickRecord{
+					ShortCode: code,
+					Timestamp: click.Timestamp,
+					UserAgent: click.UserAgent,
+					IPAddress: click.IPAddress,
+					Referrer:  click.Referrer,
+					Country:   click.Country,
+				}); err != nil {
+					return err
+				}
+				summary.Clicks++
+			}
+			return nil
+		})
+		if err != nil {
+			return summary, err
+		}
+	}
+	return summary, writer.Flush()
+}
+
+// ImportFailure décrit un lien qui n'a pas pu être importé.
+type ImportFailure struct {
+	ShortCode string
+	LongURL   string
+	Reason    string
+}
+
+// ImportSummary résume un import.
+type ImportSummary struct {
+	Imported      int // Liens créés, y compris renommés
+	Renamed       int // Liens créés avec un autre code que celui d'origine
+	Overwritten   int
+	Skipped       int
+	Clicks        int
+	SkippedClicks int // Clics dont le lien n'a pas été importé
+	Failures      []ImportFailure
+}
+
+// preparedImport est un lien de la source déjà validé, normalisé et passé par
+// la politique de destinations, ou rejeté avec reason.
+type preparedImport struct {
+	record *archive.LinkRecord
+	link   *models.Link
+	reason string
+}
+
+// Import lit la source et importe ses liens et ses clics dans une seule
+// transaction : une erreur de lecture ou un conflit en mode ConflictFail
+// annule tout l'import. Les liens invalides sont ignorés et listés dans le résumé.
+//
+// Les liens précédant le premier clic (tous, pour une archive produite par
+// Export) sont préparés avant d'ouvrir la transaction : la résolution DNS de
+// la politique ne retient pas le verrou d'écriture.
+func (s *ArchiveService) Import(source archive.Source, conflict string) (ImportSummary, error) {
+	var summary ImportSummary
+	if !IsValidConflictMode(conflict) {
+		return summary, fmt.Errorf("mode de résolution des conflits invalide : %q", conflict)
+	}
+
+	var prepared []preparedImport
+	var next *archive.Entry // Premier clic, lu avant la transaction
+	for {
+		entry, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, err
+		}
+		if entry.Type != archive.TypeLink {
+			next = entry
+			break
+		}
+		prepared = append(prepared, s.prepareImport(entry.Link))
+	}
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		summary = ImportSummary{}
+		linkIDs := make(map[string]uint) // Code de la source → lien en base, 0 si ignoré
+		var clicks []models.Click
+
+		importLink := func(p preparedImport) error {
+			id, err := s.importLink(tx, p, conflict, &summary)
+			if err != nil {
+				return err
+			}
+			if p.record.ShortCode != "" {
+				linkIDs[p.record.ShortCode] = id
+			}
+			return nil
+		}
+
+		for _, p := range prepared {
+			if err := importLink(p); err != nil {
+				return err
+			}
+		}
+
+		for entry := next; entry != nil; {
+			switch entry.Type {
+			case archive.TypeLink:
+				// Lien placé après des clics : préparé dans la transaction.
+				if err := importLink(s.prepareImport(entry.Link)); err != nil {
+					return err
+				}
+
+			case archive.TypeClick:
+				id := linkIDs[entry.Click.ShortCode]
+				if id == 0 {
+					summary.SkippedClicks++
+					break
+				}
+				clicks = append(clicks, models.Click{
+					LinkID:    id,
+					Timestamp: entry.Click.Timestamp,
+					UserAgent: analytics.UserAgent(entry.Click.UserAgent),
+					IPAddress: entry.Click.IPAddress,
+					Referrer:  entry.Click.Referrer,
+					Country:   analytics.Country(entry.Click.Country),
+				})
+				if len(clicks) >= archiveBatchSize {
+					if err := tx.Clicks().CreateClicks(context.Background(), clicks); err != nil {
+						return fmt.Errorf("erreur enregistrement clics : %w", err)
+					}
+					summary.Clicks += len(clicks)
+					clicks = clicks[:0]
+				}
+			}
+
+			var err error
+			entry, err = source.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Clicks().CreateClicks(context.Background(), clicks); err != nil {
+			return fmt.Errorf("erreur enregistrement clics : %w", err)
+		}
+		summary.Clicks += len(clicks)
+		return nil
+	})
+	return summary, err
+}
+
+// prepareImport valide et normalise le lien importé et lui applique la
+// politique de destinations, sans accès à la base.
+func (s *ArchiveService) prepareImport(record *archive.LinkRecord) preparedImport {
+	p := preparedImport{record: record}
+
+	parsed, err := url.ParseRequestURI(record.LongURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		p.reason = "URL invalide"
+		return p
+	}
+	canonicalURL, err := CanonicalizeURL(record.LongURL, s.links.canonical)
+	if err != nil {
+		p.reason = err.Error()
+		return p
+	}
+
+	link := &models.Link{
+		LongURL:          record.LongURL,
+		CanonicalURLHash: CanonicalURLHash(canonicalURL),
+		ForwardPath:      record.ForwardPath,
+		CacheMaxAge:      record.CacheMaxAge,
+		PasswordHash:     record.PasswordHash,
+		Title:            truncateRunes(record.Title, 255),
+		Description:      record.Description,
+		FaviconURL:       record.FaviconURL,
+		ImageURL:         record.ImageURL,
+		Disabled:         record.Disabled,
+		DisabledReason:   record.DisabledReason,
+		CreatedAt:        record.CreatedAt,
+	}
+	if IsValidForwardQuery(record.ForwardQuery) {
+		link.ForwardQuery = record.ForwardQuery
+	}
+	if IsValidRedirectStatus(record.RedirectStatus) {
+		link.RedirectStatus = record.RedirectStatus
+	}
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
+	}
+
+	// Comme le moniteur, un lien contraire à la politique locale est importé désactivé.
+	if !link.Disabled && s.links.policy != nil {
+		if err := s.links.policy.Evaluate(context.Background(), link.LongURL); err != nil {
+			link.Disabled = true
+			link.DisabledReason = err.Error()
+		}
+	}
+
+	p.link = link
+	return p
+}
+
+// importLink crée le lien préparé et retourne son identifiant, ou 0 s'il est
+// ignoré. Seules les erreurs qui doivent annuler l'import sont retournées.
+func (s *ArchiveService) importLink(tx repository.Store, p preparedImport, conflict string, summary *ImportSummary) (uint, error) {
+	record, link := p.record, p.link
+	if link == nil {
+		summary.Failures = append(summary.Failures, ImportFailure{ShortCode: record.ShortCode, LongURL: record.LongURL, Reason: p.reason})
+		return 0, nil
+	}
+
+	if record.Campaign != "" {
+		campaign, err := tx.Campaigns().FindOrCreateCampaign(record.Campaign)
+		if err != nil {
+			return 0, fmt.Errorf("erreur récupération campagne : %w", err)
+		}
+		link.CampaignID = &campaign.ID
+	}
+
+	// Un code absent ou incompatible avec ce raccourcisseur est remplacé.
+	if record.ShortCode == "" || ValidateAlias(record.ShortCode) != nil {
+		if err := s.insertWithNewCode(tx, link); err != nil {
+			return 0, err
+		}
+		summary.Imported++
+		if record.ShortCode != "" {
+			summary.Renamed++
+		}
+		return link.ID, nil
+	}
+
+	link.ShortCode = record.ShortCode
+	err := tx.Links().CreateLink(link)
+	if err == nil {
+		summary.Imported++
+		return link.ID, nil
+	}
+	if !errors.Is(err, repository.ErrShortCodeTaken) {
+		return 0, fmt.Errorf("erreur enregistrement lien %s : %w", record.ShortCode, err)
+	}
+
+	switch conflict {
+	case ConflictSkip:
+		summary.Skipped++
+		return 0, nil
+
+	case ConflictOverwrite:
+		existing, err := tx.Links().GetLinkByShortCode(context.Background(), record.ShortCode)
+		if err != nil {
+			return 0, err
+		}
+		link.ID = existing.ID
+		if err := tx.Links().ReplaceLink(link); err != nil {
+			return 0, fmt.Errorf("erreur remplacement lien %s : %w", record.ShortCode, err)
+		}
+		summary.Overwritten++
+		return link.ID, nil
+
+	case ConflictRename:
+		if err := s.insertWithNewCode(tx, link); err != nil {
+			return 0, err
+		}
+		summary.Imported++
+		summary.Renamed++
+		return link.ID, nil
+	}
+	return 0, fmt.Errorf("%w : %s", ErrImportConflict, record.ShortCode)
+}
+
+// insertWithNewCode insère le lien avec un code de la stratégie par défaut.
+func (s *ArchiveService) insertWithNewCode(tx repository.Store, link *models.Link) error {
+	generator, err := s.links.generator(txWriter(tx), "")
+	if err != nil {
+		return err
+	}
+	return insertWithUniqueCode(tx.Links(), link, generator)
+}
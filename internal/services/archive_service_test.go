@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/archive"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+// newTestArchive retourne une archive contenant les enregistrements dans
+// l'ordre donné, des *archive.LinkRecord ou des *archive.ClickRecord.
+func newTestArchive(t *testing.T, records ...any) archive.Source {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := archive.NewWriter(&buf, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		switch record := record.(type) {
+		case *archive.LinkRecord:
+			err = writer.WriteLink(record)
+		case *archive.ClickRecord:
+			err = writer.WriteClick(record)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := archive.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader
+}
+
+// countRows retourne le nombre de lignes du modèle vérifiant la condition.
+func countRows(t *testing.T, db *gorm.DB, model any, query string, args ...any) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestImportConflictModes(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	records := []any{
+		&archive.LinkRecord{ShortCode: "taken1", LongURL: "https://example.com/imported", Title: "Importé", CreatedAt: now},
+		&archive.LinkRecord{ShortCode: "fresh1", LongURL: "https://example.com/fresh", Campaign: "spring", CreatedAt: now},
+		&archive.LinkRecord{ShortCode: "broken", LongURL: "ftp://example.com/file", CreatedAt: now},
+		&archive.ClickRecord{ShortCode: "taken1", Timestamp: now},
+		&archive.ClickRecord{ShortCode: "fresh1", Timestamp: now},
+		&archive.ClickRecord{ShortCode: "fresh1", Timestamp: now},
+	}
+
+	tests := []struct {
+		mode    string
+		want    ImportSummary
+		longURL string // URL du lien existant après l'import
+		clicks  int64  // Clics du lien existant après l'import
+	}{
+		{mode: ConflictSkip, want: ImportSummary{Imported: 1, Skipped: 1, Clicks: 2, SkippedClicks: 1}, longURL: "https://example.com/existing", clicks: 1},
+		{mode: ConflictOverwrite, want: ImportSummary{Imported: 1, Overwritten: 1, Clicks: 3}, longURL: "https://example.com/imported", clicks: 2},
+		{mode: ConflictRename, want: ImportSummary{Imported: 2, Renamed: 1, Clicks: 3}, longURL: "https://example.com/existing", clicks: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			db, service := newTestService(t, LinkServiceConfig{})
+			existing, _, err := service.CreateLinkWithOptions("https://example.com/existing", LinkOptions{Alias: "taken1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Create(&models.Click{LinkID: existing.ID, Timestamp: now}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			store := repository.NewGormStore(db)
+			summary, err := NewArchiveService(store, service).Import(newTestArchive(t, records...), tt.mode)
+			if err != nil {
+				t.Fatalf("import : %v", err)
+			}
+			if len(summary.Failures) != 1 || summary.Failures[0].ShortCode != "broken" {
+				t.Errorf("échecs = %+v, attendu le lien ftp", summary.Failures)
+			}
+			summary.Failures = nil
+			if !reflect.DeepEqual(summary, tt.want) {
+				t.Errorf("résumé = %+v, attendu %+v", summary, tt.want)
+			}
+
+			var link models.Link
+			if err := db.First(&link, existing.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if link.ShortCode != "taken1" || link.LongURL != tt.longURL {
+				t.Errorf("lien existant = %s → %s, attendu %s", link.ShortCode, link.LongURL, tt.longURL)
+			}
+			if got := countRows(t, db, &models.Click{}, "link_id = ?", existing.ID); got != tt.clicks {
+				t.Errorf("clics du lien existant = %d, attendu %d", got, tt.clicks)
+			}
+			if got := countRows(t, db, &models.Link{}, "long_url = ?", "https://example.com/imported"); (tt.mode == ConflictSkip) != (got == 0) {
+				t.Errorf("%d lien(s) vers l'URL importée", got)
+			}
+			if tt.mode == ConflictRename {
+				var renamed models.Link
+				if err := db.Where("long_url = ?", "https://example.com/imported").First(&renamed).Error; err != nil {
+					t.Fatal(err)
+				}
+				if renamed.ShortCode == "taken1" || countRows(t, db, &models.Click{}, "link_id = ?", renamed.ID) != 1 {
+					t.Errorf("lien renommé = %s, attendu un nouveau code et son clic", renamed.ShortCode)
+				}
+			}
+
+			var fresh models.Link
+			if err := db.Where("shortcode = ?", "fresh1").First(&fresh).Error; err != nil {
+				t.Fatalf("lien fresh1 : %v", err)
+			}
+			if fresh.CampaignID == nil || countRows(t, db, &models.Click{}, "link_id = ?", fresh.ID) != 2 {
+				t.Errorf("lien fresh1 = %+v, attendu sa campagne et ses 2 clics", fresh)
+			}
+		})
+	}
+}
+
+func TestImportFailRollsBack(t *testing.T) {
+	db, service := newTestService(t, LinkServiceConfig{})
+	if _, _, err := service.CreateLinkWithOptions("https://example.com/existing", LinkOptions{Alias: "taken1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Le conflit arrive après un lien, sa campagne et ses clics, dans la
+	// transaction (lien placé après les clics) : tout est annulé.
+	source := newTestArchive(t,
+		&archive.LinkRecord{ShortCode: "fresh1", LongURL: "https://example.com/fresh", Campaign: "spring"},
+		&archive.ClickRecord{ShortCode: "fresh1", Timestamp: time.Now()},
+		&archive.LinkRecord{ShortCode: "taken1", LongURL: "https://example.com/imported"},
+	)
+
+	store := repository.NewGormStore(db)
+	if _, err := NewArchiveService(store, service).Import(source, ConflictFail); !errors.Is(err, ErrImportConflict) {
+		t.Fatalf("erreur %v, attendu ErrImportConflict", err)
+	}
+
+	if got := countRows(t, db, &models.Link{}, "1 = 1"); got != 1 {
+		t.Errorf("%d lien(s) après l'annulation, attendu 1", got)
+	}
+	if got := countRows(t, db, &models.Click{}, "1 = 1"); got != 0 {
+		t.Errorf("%d clic(s) après l'annulation, attendu 0", got)
+	}
+	if got := countRows(t, db, &models.Campaign{}, "1 = 1"); got != 0 {
+		t.Errorf("%d campagne(s) après l'annulation, attendu 0", got)
+	}
+	var existing models.Link
+	if err := db.Where("shortcode = ?", "taken1").First(&existing).Error; err != nil || existing.LongURL != "https://example.com/existing" {
+		t.Errorf("lien existant = %+v, %v", existing, err)
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+)
+
+// ErrBulkUnavailable est retournée quand le service n'a pas d'accès transactionnel.
+var ErrBulkUnavailable = errors.New("création en lot indisponible sans accès transactionnel")
+
+// BulkLinkRequest décrit un lien à créer dans un lot.
+type BulkLinkRequest struct {
+	LongURL string
+	Options LinkOptions
+}
+
+// BulkLinkResult est le résultat de la création d'un élément du lot.
+type BulkLinkResult struct {
+	Link   *models.Link
+	Reused bool
+	Err    error
+}
+
+// CreateLinksBulk crée les liens dans une seule transaction. Validation,
+// politique de destinations et hachages sont effectués pour tous les éléments
+// avant d'ouvrir la transaction, qui ne contient plus que les insertions.
+// Chaque élément dispose de son propre point de sauvegarde : un élément en
+// erreur est annulé seul et signalé dans son résultat, les autres sont validés
+// ensemble. L'erreur retournée ne concerne que la transaction elle-même.
+func (s *LinkService) CreateLinksBulk(items []BulkLinkRequest) ([]BulkLinkResult, error) {
+	if s.store == nil {
+		return nil, ErrBulkUnavailable
+	}
+
+	results := make([]BulkLinkResult, len(items))
+	prepared := make([]*preparedLink, len(items))
+	for i, item := range items {
+		p, err := s.prepareLink(item.LongURL, item.Options)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		prepared[i] = p
+	}
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		for i, p := range prepared {
+			if p == nil {
+				continue
+			}
+			var result BulkLinkResult
+			itemErr := tx.Transaction(func(itemTx repository.Store) error {
+				var err error
+				result.Link, result.Reused, err = s.insertLink(txWriter(itemTx), p)
+				return err
+			})
+			if itemErr != nil {
+				result = BulkLinkResult{Err: itemErr}
+			}
+			results[i] = result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Les métadonnées ne sont récupérées qu'une fois les liens visibles en base.
+	for _, result := range results {
+		if result.Err == nil && !result.Reused {
+			s.fetchMetadataAsync(result.Link)
+		}
+	}
+	return results, nil
+}
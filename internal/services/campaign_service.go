@@ -0,0 +1,44 @@
+package services
+
+import (
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+)
+
+// CampaignStats agrège les clics de tous les liens d'une campagne.
+type CampaignStats struct {
+	Campaign    *models.Campaign
+	TotalClicks int
+	Links       []repository.LinkClickCount
+}
+
+// CampaignService fournit des méthodes métier pour les campagnes.
+type CampaignService struct {
+	campaignRepo repository.CampaignRepository
+}
+
+// NewCampaignService crée un nouveau service de campagnes.
+func NewCampaignService(campaignRepo repository.CampaignRepository) *CampaignService {
+	return &CampaignService{
+		campaignRepo: campaignRepo,
+	}
+}
+
+// GetCampaignStats retourne une campagne et le détail des clics de ses liens.
+func (s *CampaignService) GetCampaignStats(id uint) (*CampaignStats, error) {
+	campaign, err := s.campaignRepo.GetCampaignByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.campaignRepo.CountClicksByCampaignID(campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &CampaignStats{Campaign: campaign, Links: links}
+	for _, link := range links {
+		stats.TotalClicks += link.TotalClicks
+	}
+	return stats, nil
+}
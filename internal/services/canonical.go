@@ -0,0 +1,90 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+)
+
+// CanonicalOptions contrôle la normalisation des URLs utilisée pour repérer
+// les destinations identiques.
+type CanonicalOptions struct {
+	StripTrackingParams bool     // Ignore les paramètres de suivi lors de la comparaison
+	TrackingParams      []string // Noms (ou préfixes terminés par '*') des paramètres de suivi
+}
+
+// CanonicalOptionsFromConfig lit les options de la section 'dedup' de la configuration.
+func CanonicalOptionsFromConfig(cfg *config.Config) CanonicalOptions {
+	return CanonicalOptions{
+		StripTrackingParams: cfg.Dedup.StripTrackingParams,
+		TrackingParams:      cfg.Dedup.TrackingParams,
+	}
+}
+
+// CanonicalizeURL normalise une URL : schéma et hôte en minuscules, port par
+// défaut retiré, chemin vide remplacé par "/", paramètres triés et, si demandé,
+// paramètres de suivi retirés. Le fragment est conservé.
+func CanonicalizeURL(rawURL string, opts CanonicalOptions) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("URL invalide : %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+
+	query := u.Query()
+	if opts.StripTrackingParams {
+		for key := range query {
+			if isTrackingParam(key, opts.TrackingParams) {
+				query.Del(key)
+			}
+		}
+	}
+	// Encode trie les paramètres par nom.
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// CanonicalURLHash retourne l'empreinte SHA-256 hexadécimale d'une URL canonique.
+func CanonicalURLHash(canonicalURL string) string {
+	sum := sha256.Sum256([]byte(canonicalURL))
+	return hex.EncodeToString(sum[:])
+}
+
+func isTrackingParam(key string, params []string) bool {
+	key = strings.ToLower(key)
+	for _, param := range params {
+		param = strings.ToLower(param)
+		if prefix, found := strings.CutSuffix(param, "*"); found {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == param {
+			return true
+		}
+	}
+	return false
+}
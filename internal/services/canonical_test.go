@@ -0,0 +1,42 @@
+package services
+
+import "testing"
+
+func TestCanonicalizeURL(t *testing.T) {
+	strip := CanonicalOptions{StripTrackingParams: true, TrackingParams: []string{"utm_*", "fbclid"}}
+	tests := []struct {
+		name string
+		url  string
+		opts CanonicalOptions
+		want string
+	}{
+		{name: "schéma et hôte en minuscules", url: "HTTPS://Example.COM/Path", want: "https://example.com/Path"},
+		{name: "port http par défaut", url: "http://example.com:80/a", want: "http://example.com/a"},
+		{name: "port https par défaut", url: "https://example.com:443/a", want: "https://example.com/a"},
+		{name: "port non standard conservé", url: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{name: "port http sur https conservé", url: "https://example.com:80/a", want: "https://example.com:80/a"},
+		{name: "chemin vide", url: "https://example.com", want: "https://example.com/"},
+		{name: "paramètres triés", url: "https://example.com/?b=2&a=1", want: "https://example.com/?a=1&b=2"},
+		{name: "point d'interrogation seul", url: "https://example.com/?", want: "https://example.com/"},
+		{name: "fragment conservé", url: "https://example.com/a#Section", want: "https://example.com/a#Section"},
+		{name: "IPv6", url: "http://[2001:DB8::1]:80/", want: "http://[2001:db8::1]/"},
+		{name: "suivi conservé sans option", url: "https://example.com/?utm_source=x&a=1", want: "https://example.com/?a=1&utm_source=x"},
+		{name: "suivi retiré", url: "https://example.com/?utm_source=x&UTM_Medium=y&fbclid=z&a=1", opts: strip, want: "https://example.com/?a=1"},
+		{name: "préfixe seulement", url: "https://example.com/?utmx=1&fbclid2=2", opts: strip, want: "https://example.com/?fbclid2=2&utmx=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalizeURL(tt.url, tt.opts)
+			if err != nil {
+				t.Fatalf("erreur : %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CanonicalizeURL(%q) = %q, attendu %q", tt.url, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := CanonicalizeURL("http://[::1", CanonicalOptions{}); err == nil {
+		t.Error("URL invalide acceptée")
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Julien-Somasundaram/urlshortener/internal/models"
@@ -20,8 +21,8 @@ func NewClickService(clickRepo repository.ClickRepository) *ClickService {
 }
 
 // RecordClick enregistre un clic.
-func (s *ClickService) RecordClick(click *models.Click) error {
-	if err := s.clickRepo.CreateClick(click); err != nil {
+func (s *ClickService) RecordClick(ctx context.Context, click *models.Click) error {
+	if err := s.clickRepo.CreateClick(ctx, click); err != nil {
 		return fmt.Errorf("échec de l'enregistrement du clic : %w", err)
 	}
 	return nil
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+)
+
+// maxLinksPerPage borne la taille d'une page de la recherche de liens.
+const maxLinksPerPage = 100
+
+// ErrInvalidLinkUpdate signale une modification de lien invalide.
+var ErrInvalidLinkUpdate = errors.New("modification de lien invalide")
+
+// LinkPage est une page de résultats de la recherche de liens.
+type LinkPage struct {
+	Links   []models.Link
+	Total   int64
+	Page    int
+	PerPage int
+}
+
+// SearchLinks retourne la page demandée (à partir de 1) des liens dont le code
+// court, l'URL longue ou le titre contient query.
+func (s *LinkService) SearchLinks(query string, page, perPage int) (*LinkPage, error) {
+	page = max(page, 1)
+	perPage = min(max(perPage, 1), maxLinksPerPage)
+
+	links, total, err := s.linkRepo.SearchLinks(query, (page-1)*perPage, perPage)
+	if err != nil {
+		return nil, err
+	}
+	return &LinkPage{Links: links, Total: total, Page: page, PerPage: perPage}, nil
+}
+
+// LinkUpdate regroupe les champs modifiables d'un lien existant. Un champ nil
+// est laissé inchangé.
+type LinkUpdate struct {
+	LongURL        *string
+	ForwardQuery   *string
+	ForwardPath    *bool
+	RedirectStatus *int // 0 = valeur globale
+	Disabled       *bool
+}
+
+// UpdateLink modifie le lien désigné par le code court. Comme à la création,
+// une nouvelle destination reçoit les paramètres UTM du lien (ceux de
+// l'ancienne destination et sa campagne), passe par la politique de
+// destinations et met à jour l'empreinte canonique et les métadonnées.
+// Seules les colonnes modifiées sont enregistrées : le lien lu peut provenir d'un cache.
+func (s *LinkService) UpdateLink(ctx context.Context, shortCode string, update LinkUpdate) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+	var columns []string
+
+	if update.ForwardQuery != nil {
+		if !IsValidForwardQuery(*update.ForwardQuery) {
+			return nil, fmt.Errorf("%w : mode de transfert des paramètres %q", ErrInvalidLinkUpdate, *update.ForwardQuery)
+		}
+		link.ForwardQuery = *update.ForwardQuery
+		columns = append(columns, "forward_query")
+	}
+	if update.ForwardPath != nil {
+		link.ForwardPath = *update.ForwardPath
+		columns = append(columns, "forward_path")
+	}
+	if update.RedirectStatus != nil {
+		if *update.RedirectStatus != 0 && !IsValidRedirectStatus(*update.RedirectStatus) {
+			return nil, fmt.Errorf("%w : code de redirection %d", ErrInvalidLinkUpdate, *update.RedirectStatus)
+		}
+		link.RedirectStatus = *update.RedirectStatus
+		columns = append(columns, "redirect_status")
+	}
+
+	var urlChanged bool
+	if update.LongURL != nil {
+		longURL, err := s.applyLinkUTM(link, *update.LongURL)
+		if err != nil {
+			return nil, err
+		}
+		urlChanged = longURL != link.LongURL
+		if urlChanged {
+			if s.policy != nil {
+				if err := s.policy.Evaluate(ctx, longURL); err != nil {
+					return nil, err
+				}
+			}
+			canonicalURL, err := CanonicalizeURL(longURL, s.canonical)
+			if err != nil {
+				return nil, fmt.Errorf("%w : %v", ErrInvalidLinkUpdate, err)
+			}
+			link.LongURL = longURL
+			link.CanonicalURLHash = CanonicalURLHash(canonicalURL)
+			link.Title, link.Description, link.FaviconURL, link.ImageURL, link.MetadataFetchedAt = "", "", "", "", nil
+			columns = append(columns, "long_url", "canonical_url_hash", "title", "description", "favicon_url", "image_url", "metadata_fetched_at")
+		}
+	}
+
+	if update.Disabled != nil && *update.Disabled != link.Disabled {
+		link.Disabled = *update.Disabled
+		link.DisabledReason = ""
+		if link.Disabled {
+			link.DisabledReason = "désactivé depuis l'administration"
+		}
+		columns = append(columns, "disabled", "disabled_reason")
+	}
+
+	if len(columns) == 0 {
+		return link, nil
+	}
+	if err := s.linkRepo.UpdateLinkColumns(link, columns...); err != nil {
+		return nil, fmt.Errorf("erreur enregistrement lien : %w", err)
+	}
+	if urlChanged {
+		s.fetchMetadataAsync(link)
+	}
+	// Relecture après invalidation des caches : l'appelant reçoit le lien tel qu'enregistré.
+	if stored, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode); err == nil {
+		link = stored
+	}
+	return link, nil
+}
+
+// applyLinkUTM ajoute à longURL les paramètres UTM de l'URL actuelle du lien
+// et, si elle n'en porte pas, le nom de sa campagne, pour que le lien reste
+// rattaché à son suivi.
+func (s *LinkService) applyLinkUTM(link *models.Link, longURL string) (string, error) {
+	utm := utmFromURL(link.LongURL)
+	if utm.Campaign == "" && link.CampaignID != nil {
+		campaign, err := s.campaignRepo.GetCampaignByID(*link.CampaignID)
+		if err != nil {
+			return "", fmt.Errorf("erreur récupération campagne : %w", err)
+		}
+		utm.Campaign = campaign.Name
+	}
+	longURL, err := AppendUTM(longURL, utm)
+	if err != nil {
+		return "", fmt.Errorf("%w : %v", ErrInvalidLinkUpdate, err)
+	}
+	return longURL, nil
+}
+
+// DeleteLink supprime le lien désigné par le code court, avec ses clics.
+func (s *LinkService) DeleteLink(ctx context.Context, shortCode string) error {
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return err
+	}
+	if err := s.linkRepo.DeleteLink(link.ID); err != nil {
+		return err
+	}
+
+	if s.counter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), counterTimeout)
+		defer cancel()
+		if err := s.counter.Forget(ctx, link.ID); err != nil {
+			slog.Warn("Compteur de clics non supprimé", "link_id", link.ID, "error", err)
+		}
+	}
+	return nil
+}
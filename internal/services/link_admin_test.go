@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
+)
+
+func TestUpdateLinkKeepsUTMAndPolicy(t *testing.T) {
+	destinations, err := policy.New(policy.Options{DenyDomains: []string{"denied.example"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical := CanonicalOptions{StripTrackingParams: true, TrackingParams: []string{"utm_*"}}
+	db, service := newTestService(t, LinkServiceConfig{Canonical: canonical, Policy: destinations})
+	ctx := context.Background()
+
+	spring, _, err := service.CreateLinkWithOptions("https://example.com/old", LinkOptions{Alias: "spring", UTM: UTMParams{Campaign: "spring", Source: "mail"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lien rattaché à une campagne sans paramètres UTM dans son URL (lien importé).
+	imported, _, err := service.CreateLinkWithOptions("https://example.com/imported", LinkOptions{Alias: "imported"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.Link{}).Where("id = ?", imported.ID).Update("campaign_id", spring.CampaignID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.CreateLinkWithOptions("https://example.com/plain", LinkOptions{Alias: "plain"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		code    string
+		longURL string
+		want    string
+	}{
+		{code: "spring", longURL: "https://example.org/new?utm_source=other&x=1", want: "https://example.org/new?utm_campaign=spring&utm_source=mail&x=1"},
+		{code: "imported", longURL: "https://example.org/new", want: "https://example.org/new?utm_campaign=spring"},
+		{code: "plain", longURL: "https://example.org/new", want: "https://example.org/new"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			link, err := service.UpdateLink(ctx, tt.code, LinkUpdate{LongURL: &tt.longURL})
+			if err != nil {
+				t.Fatalf("modification : %v", err)
+			}
+			if link.LongURL != tt.want {
+				t.Errorf("destination = %s, attendu %s", link.LongURL, tt.want)
+			}
+			canonicalURL, err := CanonicalizeURL(tt.want, canonical)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if link.CanonicalURLHash != CanonicalURLHash(canonicalURL) {
+				t.Errorf("empreinte canonique non recalculée")
+			}
+		})
+	}
+
+	denied := "https://denied.example/"
+	var violation *policy.Violation
+	if _, err := service.UpdateLink(ctx, "plain", LinkUpdate{LongURL: &denied}); !errors.As(err, &violation) {
+		t.Errorf("destination refusée : erreur %v, attendu une violation de la politique", err)
+	}
+	if link, err := service.GetLinkByShortCode(ctx, "plain"); err != nil || link.LongURL != "https://example.org/new" {
+		t.Errorf("lien après refus = %+v, %v", link, err)
+	}
+}
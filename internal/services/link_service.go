@@ -1,101 +1,487 @@
 package services
 
 import (
-	"crypto/rand"
+	"context"
 	"errors"
 	"fmt"
-	"log"
-	"math/big"
+	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
-	"gorm.io/gorm"
-
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
 	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/policy"
 	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/shortcode"
+	"gorm.io/gorm"
 )
 
-const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+// defaultCodeLength est la longueur des codes générés sans registre configuré.
+const defaultCodeLength = 6
+
+// counterTimeout borne les accès au compteur de clics partagé.
+const counterTimeout = 200 * time.Millisecond
+
+// maxConcurrentFetches borne les récupérations de métadonnées menées en parallèle.
+const maxConcurrentFetches = 8
 
 type LinkService struct {
-	linkRepo repository.LinkRepository
+	linkRepo     repository.LinkRepository
+	campaignRepo repository.CampaignRepository
+	store        repository.Store // Accès transactionnel, nécessaire aux créations en lot
+	policy       *policy.Policy   // Politique de destinations, nil pour tout accepter
+	canonical    CanonicalOptions
+	generators   *shortcode.Registry
+	counter      repository.ClickCounter     // Compteurs de clics partagés, nil pour compter en base
+	rollups      repository.RollupRepository // Agrégats de clics, nil sans séries temporelles
+	background   sync.WaitGroup              // Récupérations de métadonnées en cours
+	fetchSlots   chan struct{}               // Limite les récupérations simultanées (imports en lot)
+}
+
+// LinkServiceConfig regroupe les dépendances facultatives du service de liens.
+type LinkServiceConfig struct {
+	Store      repository.Store            // nil : créations en lot indisponibles
+	Policy     *policy.Policy              // nil : toutes les destinations sont acceptées
+	Canonical  CanonicalOptions            // Normalisation des URLs pour la réutilisation
+	Generators *shortcode.Registry         // nil : codes aléatoires base62 de 6 caractères
+	Counter    repository.ClickCounter     // nil : statistiques calculées en base
+	Rollups    repository.RollupRepository // nil : séries temporelles indisponibles
+}
+
+// LinkServiceConfigFromConfig construit les dépendances du service de liens à
+// partir de la configuration applicative.
+func LinkServiceConfigFromConfig(cfg *config.Config, destinationPolicy *policy.Policy, store repository.Store) (LinkServiceConfig, error) {
+	growth := shortcode.GrowthOptions{Window: cfg.ShortCode.GrowthWindow, Threshold: cfg.ShortCode.GrowthThreshold}
+	generators, err := shortcode.NewRegistry(cfg.ShortCode.Strategy, cfg.ShortCode.Length, growth, store.Sequences(), cfg.ShortCode.AlphabetSeed)
+	if err != nil {
+		return LinkServiceConfig{}, err
+	}
+	return LinkServiceConfig{
+		Store:      store,
+		Policy:     destinationPolicy,
+		Canonical:  CanonicalOptionsFromConfig(cfg),
+		Generators: generators,
+		Rollups:    store.Rollups(),
+	}, nil
 }
 
 // NewLinkService crée une nouvelle instance de service de liens
-func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
+func NewLinkService(linkRepo repository.LinkRepository, campaignRepo repository.CampaignRepository, cfg LinkServiceConfig) *LinkService {
+	generators := cfg.Generators
+	if generators == nil {
+		// Seule la stratégie séquentielle nécessite une source de compteur.
+		generators, _ = shortcode.NewRegistry(shortcode.StrategyRandom, defaultCodeLength, shortcode.GrowthOptions{}, nil, "")
+	}
+
 	return &LinkService{
-		linkRepo: linkRepo,
+		linkRepo:     linkRepo,
+		campaignRepo: campaignRepo,
+		store:        cfg.Store,
+		policy:       cfg.Policy,
+		canonical:    cfg.Canonical,
+		generators:   generators,
+		counter:      cfg.Counter,
+		rollups:      cfg.Rollups,
+		fetchSlots:   make(chan struct{}, maxConcurrentFetches),
 	}
 }
 
-// GenerateShortCode génère un code court sécurisé d'une longueur donnée
+// GenerateShortCode génère un code court aléatoire sécurisé d'une longueur donnée
 func (s *LinkService) GenerateShortCode(length int) (string, error) {
-	code := make([]byte, length)
-	for i := range code {
-		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	return shortcode.NewRandomGenerator(shortcode.Base62Alphabet, length, nil).Generate()
+}
+
+// LinkOptions regroupe les options facultatives d'un lien à sa création.
+type LinkOptions struct {
+	Alias        string // Code court personnalisé (vide : code généré)
+	ForwardQuery string // Mode de transfert des paramètres de requête (voir models.ForwardQuery*)
+	ForwardPath  bool   // Transfert des segments de chemin après le code court
+	UTM          UTMParams
+
+	RedirectStatus int  // Code HTTP de redirection (0 = valeur globale)
+	CacheMaxAge    *int // Durée de cache en secondes (nil = valeur globale)
+
+	Password string // Mot de passe en clair, stocké sous forme de hash bcrypt
+
+	ReuseExisting bool // Retourne un lien existant équivalent au lieu d'en créer un nouveau
+
+	CodeStrategy string // Stratégie de génération du code (vide : stratégie configurée)
+}
+
+// CreateLink génère un short code unique, crée et stocke un nouveau lien
+func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+	link, _, err := s.CreateLinkWithOptions(longURL, LinkOptions{})
+	return link, err
+}
+
+// CreateLinkWithOptions crée un lien comme CreateLink en appliquant les options fournies.
+// Le booléen retourné indique qu'un lien existant a été réutilisé (option ReuseExisting).
+func (s *LinkService) CreateLinkWithOptions(longURL string, opts LinkOptions) (*models.Link, bool, error) {
+	link, reused, err := s.createLink(s.directWriter(), longURL, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	if !reused {
+		s.fetchMetadataAsync(link)
+	}
+	return link, reused, nil
+}
+
+// linkWriter regroupe les dépôts utilisés pour créer un lien, liés à la
+// connexion principale ou à une transaction.
+type linkWriter struct {
+	links     repository.LinkRepository
+	campaigns repository.CampaignRepository
+	sequences shortcode.SequenceSource // nil : compteur propre au générateur
+}
+
+func (s *LinkService) directWriter() linkWriter {
+	return linkWriter{links: s.linkRepo, campaigns: s.campaignRepo}
+}
+
+func txWriter(tx repository.Store) linkWriter {
+	return linkWriter{links: tx.Links(), campaigns: tx.Campaigns(), sequences: tx.Sequences()}
+}
+
+// preparedLink est un lien validé, dont la destination a passé la politique
+// et dont les hachages sont calculés : il ne reste qu'à l'insérer.
+type preparedLink struct {
+	longURL       string
+	canonicalHash string
+	passwordHash  string
+	opts          LinkOptions
+}
+
+// createLink prépare puis réutilise ou insère le lien via les dépôts fournis.
+func (s *LinkService) createLink(w linkWriter, longURL string, opts LinkOptions) (*models.Link, bool, error) {
+	prepared, err := s.prepareLink(longURL, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.insertLink(w, prepared)
+}
+
+// prepareLink valide les options, applique la politique de destinations et
+// calcule les hachages. Ces étapes lentes (DNS, bcrypt) ne touchent pas la base
+// et restent hors de toute transaction.
+func (s *LinkService) prepareLink(longURL string, opts LinkOptions) (*preparedLink, error) {
+	if !IsValidForwardQuery(opts.ForwardQuery) {
+		return nil, fmt.Errorf("mode de transfert des paramètres invalide : %q", opts.ForwardQuery)
+	}
+	if opts.RedirectStatus != 0 && !IsValidRedirectStatus(opts.RedirectStatus) {
+		return nil, fmt.Errorf("code de redirection invalide : %d", opts.RedirectStatus)
+	}
+	if opts.CacheMaxAge != nil && *opts.CacheMaxAge < 0 {
+		return nil, fmt.Errorf("durée de cache invalide : %d", *opts.CacheMaxAge)
+	}
+
+	if opts.Alias != "" {
+		if err := ValidateAlias(opts.Alias); err != nil {
+			return nil, err
+		}
+	} else if _, err := s.generators.Get(opts.CodeStrategy); err != nil {
+		return nil, err
+	}
+
+	longURL, err := AppendUTM(longURL, opts.UTM)
+	if err != nil {
+		return nil, fmt.Errorf("erreur ajout paramètres UTM : %w", err)
+	}
+
+	if s.policy != nil {
+		if err := s.policy.Evaluate(context.Background(), longURL); err != nil {
+			return nil, err
+		}
+	}
+
+	canonicalURL, err := CanonicalizeURL(longURL, s.canonical)
+	if err != nil {
+		return nil, err
+	}
+
+	var passwordHash string
+	if opts.Password != "" {
+		if passwordHash, err = HashLinkPassword(opts.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	return &preparedLink{
+		longURL:       longURL,
+		canonicalHash: CanonicalURLHash(canonicalURL),
+		passwordHash:  passwordHash,
+		opts:          opts,
+	}, nil
+}
+
+// insertLink réutilise ou insère un lien préparé. Seules les écritures en base
+// y figurent, pour garder les transactions courtes.
+func (s *LinkService) insertLink(w linkWriter, p *preparedLink) (*models.Link, bool, error) {
+	opts := p.opts
+
+	var campaignID *uint
+	if opts.UTM.Campaign != "" {
+		campaign, err := w.campaigns.FindOrCreateCampaign(opts.UTM.Campaign)
+		if err != nil {
+			return nil, false, fmt.Errorf("erreur récupération campagne : %w", err)
+		}
+		campaignID = &campaign.ID
+	}
+
+	// Un alias demande explicitement un nouveau code : pas de réutilisation.
+	if opts.ReuseExisting && opts.Alias == "" {
+		existing, err := findReusableLink(w.links, p.canonicalHash, campaignID, opts)
 		if err != nil {
-			return "", fmt.Errorf("erreur génération caractère : %w", err)
+			return nil, false, err
 		}
-		code[i] = charset[n.Int64()]
+		if existing != nil {
+			return existing, true, nil
+		}
+	}
+
+	link := &models.Link{
+		LongURL:          p.longURL,
+		CanonicalURLHash: p.canonicalHash,
+		ForwardQuery:     opts.ForwardQuery,
+		ForwardPath:      opts.ForwardPath,
+		CampaignID:       campaignID,
+		CreatedAt:        time.Now(),
+
+		RedirectStatus: opts.RedirectStatus,
+		CacheMaxAge:    opts.CacheMaxAge,
+		PasswordHash:   p.passwordHash,
+	}
+
+	if opts.Alias != "" {
+		link.ShortCode = opts.Alias
+		if err := w.links.CreateLink(link); err != nil {
+			if errors.Is(err, repository.ErrShortCodeTaken) {
+				return nil, false, ErrAliasTaken
+			}
+			return nil, false, fmt.Errorf("erreur enregistrement lien : %w", err)
+		}
+		return link, false, nil
 	}
-	return string(code), nil
+
+	generator, err := s.generator(w, opts.CodeStrategy)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := insertWithUniqueCode(w.links, link, generator); err != nil {
+		return nil, false, err
+	}
+	return link, false, nil
 }
 
-// CreateLink génère un short code unique, crée et stocke un nouveau lien
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
-	var shortCode string
+// generator retourne le générateur de la stratégie, lié au compteur de la
+// transaction en cours pour la stratégie séquentielle.
+func (s *LinkService) generator(w linkWriter, strategy string) (shortcode.CodeGenerator, error) {
+	generator, err := s.generators.Get(strategy)
+	if err != nil {
+		return nil, err
+	}
+	if binder, ok := generator.(shortcode.SourceBinder); ok && w.sequences != nil {
+		generator = binder.WithSource(w.sequences)
+	}
+	return generator, nil
+}
+
+// fetchMetadataAsync récupère les métadonnées de la destination sans retarder
+// la réponse, sur une copie pour ne pas modifier le lien retourné à l'appelant.
+func (s *LinkService) fetchMetadataAsync(link *models.Link) {
+	linkCopy := *link
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.fetchSlots <- struct{}{}
+		defer func() { <-s.fetchSlots }()
+		if err := RefreshLinkMetadata(context.Background(), s.linkRepo, &linkCopy); err != nil {
+			slog.Warn("Métadonnées indisponibles", "short_code", linkCopy.ShortCode, "error", err)
+		}
+	}()
+}
+
+// insertWithUniqueCode attribue un code au lien et l'insère directement : la
+// contrainte d'unicité de la base départage les créations concurrentes, et une
+// collision entraîne simplement un nouvel essai avec un autre code.
+func insertWithUniqueCode(links repository.LinkRepository, link *models.Link, generator shortcode.CodeGenerator) error {
 	const maxRetries = 5
 
+	reporter, _ := generator.(shortcode.CollisionReporter)
 	for i := 0; i < maxRetries; i++ {
-		code, err := s.GenerateShortCode(6)
+		code, err := generator.Generate()
 		if err != nil {
-			return nil, fmt.Errorf("erreur génération code : %w", err)
+			return fmt.Errorf("erreur génération code : %w", err)
 		}
 
-		_, err = s.linkRepo.GetLinkByShortCode(code)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				shortCode = code
-				break
-			}
-			return nil, fmt.Errorf("erreur vérification unicité code : %w", err)
+		link.ID = 0
+		link.ShortCode = code
+		err = links.CreateLink(link)
+		collided := errors.Is(err, repository.ErrShortCodeTaken)
+		if reporter != nil && (err == nil || collided) {
+			reporter.ReportAttempt(collided)
+		}
+		if err == nil {
+			return nil
+		}
+		if !collided {
+			return fmt.Errorf("erreur enregistrement lien : %w", err)
 		}
 
-		log.Printf("⚠️  Short code '%s' déjà utilisé, nouvelle tentative (%d/%d)...", code, i+1, maxRetries)
+		slog.Warn("Code court déjà utilisé, nouvelle tentative", "short_code", code, "attempt", i+1, "max_retries", maxRetries)
 	}
 
-	if shortCode == "" {
-		return nil, errors.New("échec génération code unique après plusieurs tentatives")
+	return errors.New("échec génération code unique après plusieurs tentatives")
+}
+
+// findReusableLink cherche un lien existant vers la même URL canonique dont le
+// comportement est identique à celui demandé. L'empreinte ignore les paramètres
+// de suivi : pour ne pas mélanger les campagnes, la campagne et les paramètres
+// UTM demandés doivent aussi correspondre. Un lien protégé ou désactivé n'est
+// jamais réutilisé.
+func findReusableLink(links repository.LinkRepository, canonicalHash string, campaignID *uint, opts LinkOptions) (*models.Link, error) {
+	if opts.Password != "" {
+		return nil, nil
 	}
 
-	link := &models.Link{
-		ShortCode: shortCode,
-		LongURL:   longURL,
-		CreatedAt: time.Now(),
+	candidates, err := links.GetLinksByCanonicalHash(canonicalHash)
+	if err != nil {
+		return nil, fmt.Errorf("erreur recherche lien existant : %w", err)
+	}
+
+	for i := range candidates {
+		link := &candidates[i]
+		if link.IsProtected() || link.Disabled ||
+			!opts.UTM.matches(link.LongURL) ||
+			link.ForwardQuery != opts.ForwardQuery ||
+			link.ForwardPath != opts.ForwardPath ||
+			link.RedirectStatus != opts.RedirectStatus ||
+			!equalUintPtr(link.CampaignID, campaignID) ||
+			!equalIntPtr(link.CacheMaxAge, opts.CacheMaxAge) {
+			continue
+		}
+		return link, nil
 	}
+	return nil, nil
+}
 
-	if err := s.linkRepo.CreateLink(link); err != nil {
-		return nil, fmt.Errorf("erreur enregistrement lien : %w", err)
+// BackfillCanonicalHashes calcule l'empreinte canonique des liens créés avant
+// son introduction. Retourne le nombre de liens mis à jour.
+func (s *LinkService) BackfillCanonicalHashes() (int, error) {
+	links, err := s.linkRepo.GetAllLinks()
+	if err != nil {
+		return 0, err
 	}
 
-	return link, nil
+	updated := 0
+	for _, link := range links {
+		if link.CanonicalURLHash != "" {
+			continue
+		}
+		canonicalURL, err := CanonicalizeURL(link.LongURL, s.canonical)
+		if err != nil {
+			slog.Warn("URL non canonisable", "short_code", link.ShortCode, "error", err)
+			continue
+		}
+		if err := s.linkRepo.UpdateCanonicalHash(link.ID, CanonicalURLHash(canonicalURL)); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// ReservedCodeLinks retourne les codes des liens créés avant la réservation
+// de leur code par une route du serveur : ces liens ne sont plus joignables.
+func (s *LinkService) ReservedCodeLinks(ctx context.Context) ([]string, error) {
+	var codes []string
+	for alias := range reservedAliases {
+		if _, err := s.linkRepo.GetLinkByShortCode(ctx, alias); err == nil {
+			codes = append(codes, alias)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return codes, err
+		}
+	}
+	sort.Strings(codes)
+	return codes, nil
+}
+
+func equalUintPtr(a, b *uint) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func equalIntPtr(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// WaitBackgroundTasks attend la fin des récupérations de métadonnées lancées
+// par CreateLink, pour les commandes CLI qui se terminent juste après.
+func (s *LinkService) WaitBackgroundTasks() {
+	s.background.Wait()
 }
 
 // GetLinkByShortCode récupère un lien par son code court
-func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
-	return s.linkRepo.GetLinkByShortCode(shortCode)
+func (s *LinkService) GetLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
+	return s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+}
+
+// ListLinks retourne tous les liens enregistrés
+func (s *LinkService) ListLinks() ([]models.Link, error) {
+	return s.linkRepo.GetAllLinks()
 }
 
 // GetLinkStats retourne un lien et son nombre total de clics
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
-	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+func (s *LinkService) GetLinkStats(ctx context.Context, shortCode string) (*models.Link, int, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	totalClicks, err := s.linkRepo.CountClicksByLinkID(link.ID)
+	totalClicks, err := s.countClicks(link.ID)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	return link, totalClicks, nil
 }
+
+// RecordClick met à jour le compteur de clics partagé, l'enregistrement du
+// clic en base restant à la charge des workers.
+func (s *LinkService) RecordClick(linkID uint) {
+	if s.counter == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), counterTimeout)
+	defer cancel()
+	if err := s.counter.Increment(ctx, linkID); err != nil {
+		slog.Warn("Compteur de clics indisponible", "link_id", linkID, "error", err)
+	}
+}
+
+// countClicks lit le compteur partagé, amorcé depuis la base au premier accès.
+// Sans compteur, ou s'il est indisponible, le total est calculé en base.
+func (s *LinkService) countClicks(linkID uint) (int, error) {
+	if s.counter == nil {
+		return s.linkRepo.CountClicksByLinkID(linkID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), counterTimeout)
+	defer cancel()
+	count, ok, err := s.counter.Get(ctx, linkID)
+	if err == nil && ok {
+		return count, nil
+	}
+	if err != nil {
+		slog.Warn("Compteur de clics indisponible", "link_id", linkID, "error", err)
+	}
+
+	count, err = s.linkRepo.CountClicksByLinkID(linkID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.counter.Seed(ctx, linkID, count); err != nil {
+		slog.Warn("Amorçage du compteur de clics impossible", "link_id", linkID, "error", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestService crée un service de liens sur une base SQLite en mémoire
+// migrée. Le Store de cfg est remplacé par celui de la base de test.
+func newTestService(t *testing.T, cfg LinkServiceConfig) (*gorm.DB, *LinkService) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("ouverture base : %v", err)
+	}
+	// Chaque connexion à ":memory:" ouvre une base distincte.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("migrations : %v", err)
+	}
+
+	store := repository.NewGormStore(db)
+	cfg.Store = store
+	service := NewLinkService(store.Links(), store.Campaigns(), cfg)
+	t.Cleanup(service.WaitBackgroundTasks)
+	return db, service
+}
+
+func TestCreateLinkReusesCanonicalDestination(t *testing.T) {
+	canonical := CanonicalOptions{StripTrackingParams: true, TrackingParams: []string{"utm_*"}}
+	_, service := newTestService(t, LinkServiceConfig{Canonical: canonical})
+
+	original, _, err := service.CreateLinkWithOptions("http://example.com/?a=1&b=2", LinkOptions{})
+	if err != nil {
+		t.Fatalf("création : %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		opts   LinkOptions
+		reused bool
+	}{
+		{name: "même destination canonique", url: "HTTP://Example.com:80/?b=2&a=1&utm_source=x", opts: LinkOptions{ReuseExisting: true}, reused: true},
+		{name: "réutilisation non demandée", url: "http://example.com/?a=1&b=2", reused: false},
+		{name: "autre destination", url: "http://example.com/?a=1&b=3", opts: LinkOptions{ReuseExisting: true}, reused: false},
+		{name: "transfert différent", url: "http://example.com/?a=1&b=2", opts: LinkOptions{ReuseExisting: true, ForwardPath: true}, reused: false},
+		{name: "paramètres UTM demandés", url: "http://example.com/?a=1&b=2", opts: LinkOptions{ReuseExisting: true, UTM: UTMParams{Source: "x"}}, reused: false},
+		{name: "alias", url: "http://example.com/?a=1&b=2", opts: LinkOptions{ReuseExisting: true, Alias: "alias1"}, reused: false},
+		{name: "mot de passe", url: "http://example.com/?a=1&b=2", opts: LinkOptions{ReuseExisting: true, Password: "secret"}, reused: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, reused, err := service.CreateLinkWithOptions(tt.url, tt.opts)
+			if err != nil {
+				t.Fatalf("création : %v", err)
+			}
+			if reused != tt.reused || (link.ID == original.ID) != tt.reused {
+				t.Errorf("réutilisé = %v (lien %d, original %d), attendu %v", reused, link.ID, original.ID, tt.reused)
+			}
+		})
+	}
+}
+
+func TestCreateLinkReusesCampaignLinkWithSameUTM(t *testing.T) {
+	canonical := CanonicalOptions{StripTrackingParams: true, TrackingParams: []string{"utm_*"}}
+	_, service := newTestService(t, LinkServiceConfig{Canonical: canonical})
+	spring := LinkOptions{ReuseExisting: true, UTM: UTMParams{Campaign: "spring", Source: "mail"}}
+
+	original, _, err := service.CreateLinkWithOptions("https://example.com/", spring)
+	if err != nil {
+		t.Fatalf("création : %v", err)
+	}
+	if _, reused, err := service.CreateLinkWithOptions("https://EXAMPLE.com:443", spring); err != nil || !reused {
+		t.Errorf("même campagne : réutilisé = %v, %v", reused, err)
+	}
+
+	other := spring
+	other.UTM.Source = "social"
+	link, reused, err := service.CreateLinkWithOptions("https://example.com/", other)
+	if err != nil || reused || link.ID == original.ID {
+		t.Errorf("autre source UTM : réutilisé = %v, %v", reused, err)
+	}
+}
+
+func TestCreateLinkNeverReusesDisabledLink(t *testing.T) {
+	db, service := newTestService(t, LinkServiceConfig{})
+
+	original, _, err := service.CreateLinkWithOptions("https://example.com/", LinkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(original).Update("disabled", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, reused, err := service.CreateLinkWithOptions("https://example.com/", LinkOptions{ReuseExisting: true}); err != nil || reused {
+		t.Errorf("lien désactivé : réutilisé = %v, %v", reused, err)
+	}
+}
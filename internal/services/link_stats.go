@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+)
+
+// maxSeriesPoints borne le nombre de périodes d'une série temporelle.
+const maxSeriesPoints = 1000
+
+// Nombre de périodes retournées par défaut, selon la granularité.
+const (
+	defaultHourlyPoints = 24
+	defaultDailyPoints  = 30
+)
+
+var (
+	// ErrStatsUnavailable signale un service construit sans agrégats de clics.
+	ErrStatsUnavailable = errors.New("statistiques détaillées indisponibles")
+	// ErrInvalidStatsQuery signale une granularité, une dimension ou une période invalide.
+	ErrInvalidStatsQuery = errors.New("requête de statistiques invalide")
+)
+
+// Dimensions proposées pour la répartition des clics.
+var breakdownDimensions = []string{models.DimensionReferrer, models.DimensionCountry, models.DimensionDevice}
+
+// StatsQuery décrit la période interrogée. Les bornes nulles prennent les
+// valeurs par défaut ; elles sont alignées sur les périodes de la granularité.
+type StatsQuery struct {
+	Granularity repository.Granularity // hour ou day (défaut)
+	From        time.Time              // Incluse
+	To          time.Time              // Exclue
+}
+
+// normalize applique les valeurs par défaut et vérifie la requête.
+func (q *StatsQuery) normalize() error {
+	switch q.Granularity {
+	case "":
+		q.Granularity = repository.GranularityDay
+	case repository.GranularityHour, repository.GranularityDay:
+	default:
+		return fmt.Errorf("%w : granularité %q (hour ou day)", ErrInvalidStatsQuery, q.Granularity)
+	}
+
+	step := q.Granularity.Step()
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	// La période contenant To est incluse.
+	q.To = q.Granularity.Truncate(q.To.Add(step - time.Nanosecond))
+	if q.From.IsZero() {
+		points := defaultDailyPoints
+		if q.Granularity == repository.GranularityHour {
+			points = defaultHourlyPoints
+		}
+		q.From = q.To.Add(-time.Duration(points) * step)
+	}
+	q.From = q.Granularity.Truncate(q.From)
+
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w : la date de début doit précéder la date de fin", ErrInvalidStatsQuery)
+	}
+	if q.To.Sub(q.From)/step > maxSeriesPoints {
+		return fmt.Errorf("%w : plus de %d périodes demandées", ErrInvalidStatsQuery, maxSeriesPoints)
+	}
+	return nil
+}
+
+// TimeSeries est le nombre de clics d'un lien par période, périodes sans clic comprises.
+type TimeSeries struct {
+	Link   *models.Link
+	Query  StatsQuery
+	Points []repository.RollupPoint
+	Total  int64
+}
+
+// GetLinkTimeSeries retourne les clics du lien par heure ou par jour.
+func (s *LinkService) GetLinkTimeSeries(ctx context.Context, shortCode string, query StatsQuery) (*TimeSeries, error) {
+	if s.rollups == nil {
+		return nil, ErrStatsUnavailable
+	}
+	if err := query.normalize(); err != nil {
+		return nil, err
+	}
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.rollups.Series(link.ID, query.Granularity, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &TimeSeries{Link: link, Query: query}
+	next := 0
+	for bucket := query.From; bucket.Before(query.To); bucket = bucket.Add(query.Granularity.Step()) {
+		point := repository.RollupPoint{Bucket: bucket}
+		if next < len(stored) && stored[next].Bucket.Equal(bucket) {
+			point.Clicks = stored[next].Clicks
+			next++
+		}
+		series.Total += point.Clicks
+		series.Points = append(series.Points, point)
+	}
+	return series, nil
+}
+
+// Breakdown est la répartition des clics d'un lien selon une dimension.
+type Breakdown struct {
+	Link      *models.Link
+	Query     StatsQuery
+	Dimension string
+	Values    []repository.RollupValue
+}
+
+// GetLinkBreakdown retourne les limit valeurs de la dimension (referrer,
+// country ou device) ayant reçu le plus de clics sur la période.
+func (s *LinkService) GetLinkBreakdown(ctx context.Context, shortCode, dimension string, query StatsQuery, limit int) (*Breakdown, error) {
+	if s.rollups == nil {
+		return nil, ErrStatsUnavailable
+	}
+	if !slices.Contains(breakdownDimensions, dimension) {
+		return nil, fmt.Errorf("%w : dimension %q (referrer, country ou device)", ErrInvalidStatsQuery, dimension)
+	}
+	if err := query.normalize(); err != nil {
+		return nil, err
+	}
+	link, err := s.linkRepo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.rollups.Breakdown(link.ID, query.Granularity, dimension, query.From, query.To, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &Breakdown{Link: link, Query: query, Dimension: dimension, Values: values}, nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/metadata"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+)
+
+// metadataFetchTimeout borne la durée totale d'un rafraîchissement de métadonnées.
+const metadataFetchTimeout = 10 * time.Second
+
+// RefreshLinkMetadata télécharge la page de destination du lien et enregistre
+// son titre, sa description, son favicon et son image Open Graph.
+func RefreshLinkMetadata(ctx context.Context, linkRepo repository.LinkRepository, link *models.Link) error {
+	ctx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+	defer cancel()
+
+	meta, err := metadata.Fetch(ctx, link.LongURL)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	link.Title = truncateRunes(meta.Title, 255)
+	link.Description = truncateRunes(meta.Description, 1000)
+	link.FaviconURL = meta.FaviconURL
+	link.ImageURL = meta.ImageURL
+	link.MetadataFetchedAt = &now
+	return linkRepo.UpdateLinkMetadata(link)
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
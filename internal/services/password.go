@@ -0,0 +1,26 @@
+package services
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+)
+
+// HashLinkPassword calcule le hash bcrypt du mot de passe d'un lien.
+func HashLinkPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("erreur hachage mot de passe : %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckLinkPassword vérifie le mot de passe fourni pour un lien protégé.
+func CheckLinkPassword(link *models.Link, password string) bool {
+	if !link.IsProtected() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) == nil
+}
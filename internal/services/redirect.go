@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+)
+
+// IsValidForwardQuery indique si le mode de transfert des paramètres est reconnu.
+func IsValidForwardQuery(mode string) bool {
+	switch mode {
+	case models.ForwardQueryNone, models.ForwardQueryMerge, models.ForwardQueryOverride:
+		return true
+	}
+	return false
+}
+
+// IsValidRedirectStatus indique si le code HTTP est utilisable pour une redirection.
+func IsValidRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// ErrInvalidForwardPath signale un chemin supplémentaire contenant un segment "." ou "..".
+var ErrInvalidForwardPath = errors.New("chemin supplémentaire invalide")
+
+// ResolveDestination construit l'URL de redirection d'un lien à partir du chemin
+// supplémentaire et des paramètres de requête reçus sur l'URL courte, selon les
+// options du lien. Sans option activée, l'URL longue est renvoyée telle quelle.
+func ResolveDestination(link *models.Link, extraPath string, incoming url.Values) (string, error) {
+	extraPath = strings.Trim(extraPath, "/")
+	forwardPath := link.ForwardPath && extraPath != ""
+	forwardQuery := link.ForwardQuery != models.ForwardQueryNone && len(incoming) > 0
+	if !forwardPath && !forwardQuery {
+		return link.LongURL, nil
+	}
+
+	dest, err := url.Parse(link.LongURL)
+	if err != nil {
+		return "", fmt.Errorf("URL de destination invalide : %w", err)
+	}
+
+	if forwardPath {
+		// JoinPath résout les segments "." et ".." : ils sont refusés, y compris
+		// encodés (%2e%2e), pour ne jamais remonter au-dessus du chemin de destination.
+		for _, segment := range strings.Split(extraPath, "/") {
+			decoded, err := url.PathUnescape(segment)
+			if err != nil || decoded == "." || decoded == ".." {
+				return "", ErrInvalidForwardPath
+			}
+		}
+		dest = dest.JoinPath(extraPath)
+	}
+
+	if forwardQuery {
+		query := dest.Query()
+		for key, values := range incoming {
+			if _, exists := query[key]; exists && link.ForwardQuery == models.ForwardQueryMerge {
+				continue
+			}
+			query[key] = values
+		}
+		dest.RawQuery = query.Encode()
+	}
+
+	return dest.String(), nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+)
+
+func TestResolveDestination(t *testing.T) {
+	tests := []struct {
+		name         string
+		longURL      string
+		forwardPath  bool
+		forwardQuery string
+		extraPath    string
+		query        string
+		want         string
+		wantErr      error
+	}{
+		{name: "sans option", longURL: "https://example.com/docs?a=1", extraPath: "guide", query: "a=2", want: "https://example.com/docs?a=1"},
+		{name: "chemin ajouté", longURL: "https://example.com/docs", forwardPath: true, extraPath: "guide/intro", want: "https://example.com/docs/guide/intro"},
+		{name: "barres obliques superflues", longURL: "https://example.com/docs/", forwardPath: true, extraPath: "/guide/", want: "https://example.com/docs/guide"},
+		{name: "chemin conservé avec la requête", longURL: "https://example.com/docs?v=2", forwardPath: true, extraPath: "guide", want: "https://example.com/docs/guide?v=2"},
+		{name: "chemin ignoré sans option", longURL: "https://example.com/docs", extraPath: "guide", want: "https://example.com/docs"},
+		{name: "segment ..", longURL: "https://example.com/docs", forwardPath: true, extraPath: "../admin", wantErr: ErrInvalidForwardPath},
+		{name: "segment .", longURL: "https://example.com/docs", forwardPath: true, extraPath: "guide/./intro", wantErr: ErrInvalidForwardPath},
+		{name: "segment .. final", longURL: "https://example.com/docs", forwardPath: true, extraPath: "guide/..", wantErr: ErrInvalidForwardPath},
+		{name: "segment .. encodé", longURL: "https://example.com/docs", forwardPath: true, extraPath: "%2e%2e/admin", wantErr: ErrInvalidForwardPath},
+		{name: "segment .. encodé en majuscules", longURL: "https://example.com/docs", forwardPath: true, extraPath: "guide/%2E%2E", wantErr: ErrInvalidForwardPath},
+		{name: "points dans un nom", longURL: "https://example.com/docs", forwardPath: true, extraPath: "v1..2/file.txt", want: "https://example.com/docs/v1..2/file.txt"},
+		{name: "paramètres ignorés", longURL: "https://example.com/?a=1", forwardQuery: models.ForwardQueryNone, query: "a=2&b=3", want: "https://example.com/?a=1"},
+		{name: "fusion", longURL: "https://example.com/?a=1", forwardQuery: models.ForwardQueryMerge, query: "a=2&b=3", want: "https://example.com/?a=1&b=3"},
+		{name: "remplacement", longURL: "https://example.com/?a=1", forwardQuery: models.ForwardQueryOverride, query: "a=2&b=3", want: "https://example.com/?a=2&b=3"},
+		{name: "fusion sans paramètre reçu", longURL: "https://example.com/?b=1&a=2", forwardQuery: models.ForwardQueryMerge, want: "https://example.com/?b=1&a=2"},
+		{name: "chemin et paramètres", longURL: "https://example.com/docs?a=1", forwardPath: true, forwardQuery: models.ForwardQueryMerge, extraPath: "guide", query: "b=2", want: "https://example.com/docs/guide?a=1&b=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := &models.Link{LongURL: tt.longURL, ForwardPath: tt.forwardPath, ForwardQuery: tt.forwardQuery}
+			incoming, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ResolveDestination(link, tt.extraPath, incoming)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("erreur %v, attendu %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erreur inattendue : %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("destination = %s, attendu %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// UTMParams regroupe les paramètres de suivi de campagne ajoutés à une URL.
+type UTMParams struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// IsZero indique qu'aucun paramètre UTM n'est renseigné.
+func (p UTMParams) IsZero() bool {
+	return p == UTMParams{}
+}
+
+// values associe chaque paramètre d'URL utm_* à sa valeur, vide si non renseignée.
+func (p UTMParams) values() map[string]string {
+	return map[string]string{
+		"utm_source":   p.Source,
+		"utm_medium":   p.Medium,
+		"utm_campaign": p.Campaign,
+		"utm_term":     p.Term,
+		"utm_content":  p.Content,
+	}
+}
+
+// utmFromURL retourne les paramètres UTM présents dans l'URL fournie.
+func utmFromURL(longURL string) UTMParams {
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return UTMParams{}
+	}
+	query := u.Query()
+	return UTMParams{
+		Source:   query.Get("utm_source"),
+		Medium:   query.Get("utm_medium"),
+		Campaign: query.Get("utm_campaign"),
+		Term:     query.Get("utm_term"),
+		Content:  query.Get("utm_content"),
+	}
+}
+
+// matches indique si les paramètres UTM renseignés figurent avec la même
+// valeur dans l'URL fournie.
+func (p UTMParams) matches(longURL string) bool {
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return false
+	}
+	query := u.Query()
+	for key, value := range p.values() {
+		if value != "" && query.Get(key) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// AppendUTM ajoute les paramètres UTM renseignés à l'URL fournie. Les paramètres
+// utm_* déjà présents sont remplacés, les autres paramètres et le fragment sont conservés.
+func AppendUTM(longURL string, p UTMParams) (string, error) {
+	if p.IsZero() {
+		return longURL, nil
+	}
+
+	u, err := url.Parse(longURL)
+	if err != nil {
+		return "", fmt.Errorf("URL invalide : %w", err)
+	}
+
+	query := u.Query()
+	for key, value := range p.values() {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,296 @@
+package shortcode
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	mathrand "math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Noms des stratégies de génération disponibles.
+const (
+	StrategyRandom      = "random"      // Caractères aléatoires en base62
+	StrategySequential  = "sequential"  // Compteur encodé avec un alphabet mélangé
+	StrategyWords       = "words"       // Mots lisibles suivis de deux chiffres
+	StrategyUnambiguous = "unambiguous" // Caractères aléatoires sans 0/O/o, 1/l/I
+)
+
+// Alphabets utilisés par les générateurs.
+const (
+	Base62Alphabet      = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	UnambiguousAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// ErrUnknownStrategy est retournée pour une stratégie non enregistrée.
+var ErrUnknownStrategy = errors.New("stratégie de génération de code inconnue")
+
+// MaxLength est la longueur maximale d'un code (taille de la colonne shortcode).
+const MaxLength = 10
+
+// CodeGenerator produit des codes courts. L'unicité est garantie par la base :
+// un code déjà attribué fait échouer l'insertion et un nouveau code est demandé.
+type CodeGenerator interface {
+	Generate() (string, error)
+}
+
+// CollisionReporter est implémenté par les générateurs qui s'adaptent au taux
+// de collision observé lors des insertions.
+type CollisionReporter interface {
+	ReportAttempt(collided bool)
+}
+
+// RandomGenerator tire chaque caractère au hasard dans un alphabet. Avec un
+// CollisionTracker, sa longueur augmente quand les collisions deviennent fréquentes.
+type RandomGenerator struct {
+	alphabet string
+	length   atomic.Int64
+	tracker  *CollisionTracker
+}
+
+// NewRandomGenerator crée un générateur aléatoire. tracker peut être nil pour une longueur fixe.
+func NewRandomGenerator(alphabet string, length int, tracker *CollisionTracker) *RandomGenerator {
+	g := &RandomGenerator{alphabet: alphabet, tracker: tracker}
+	g.length.Store(int64(length))
+	return g
+}
+
+func (g *RandomGenerator) Generate() (string, error) {
+	code := make([]byte, g.length.Load())
+	max := big.NewInt(int64(len(g.alphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("erreur génération caractère : %w", err)
+		}
+		code[i] = g.alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// ReportAttempt enregistre le résultat d'une insertion et allonge les codes
+// d'un caractère si le taux de collision de la fenêtre dépasse le seuil.
+func (g *RandomGenerator) ReportAttempt(collided bool) {
+	if g.tracker == nil || !g.tracker.Record(collided) {
+		return
+	}
+	length := g.length.Load()
+	if length >= MaxLength {
+		return
+	}
+	if g.length.CompareAndSwap(length, length+1) {
+		slog.Warn("Taux de collision élevé, allongement des codes", "length", length+1)
+	}
+}
+
+// CollisionTracker mesure le taux de collision sur une fenêtre glissante des
+// dernières tentatives d'insertion.
+type CollisionTracker struct {
+	mu         sync.Mutex
+	window     []bool
+	next       int
+	filled     int
+	collisions int
+	threshold  float64
+}
+
+// NewCollisionTracker crée un suivi sur size tentatives avec le seuil donné (entre 0 et 1).
+func NewCollisionTracker(size int, threshold float64) *CollisionTracker {
+	return &CollisionTracker{window: make([]bool, size), threshold: threshold}
+}
+
+// Record ajoute une tentative et indique si le seuil est dépassé sur une
+// fenêtre complète. La fenêtre repart alors de zéro.
+func (t *CollisionTracker) Record(collided bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.filled == len(t.window) && t.window[t.next] {
+		t.collisions--
+	}
+	t.window[t.next] = collided
+	if collided {
+		t.collisions++
+	}
+	t.next = (t.next + 1) % len(t.window)
+	if t.filled < len(t.window) {
+		t.filled++
+	}
+
+	if t.filled < len(t.window) || float64(t.collisions)/float64(t.filled) <= t.threshold {
+		return false
+	}
+	clear(t.window)
+	t.next, t.filled, t.collisions = 0, 0, 0
+	return true
+}
+
+// SequenceSource fournit des valeurs de compteur strictement croissantes.
+type SequenceSource interface {
+	NextValue(name string) (uint64, error)
+}
+
+// SourceBinder est implémenté par les générateurs adossés à un compteur, pour
+// lire ce compteur dans la transaction en cours.
+type SourceBinder interface {
+	WithSource(source SequenceSource) CodeGenerator
+}
+
+// SequentialGenerator encode un compteur persistant avec un alphabet mélangé :
+// deux valeurs distinctes donnent toujours deux codes distincts.
+type SequentialGenerator struct {
+	source    SequenceSource
+	alphabet  string
+	minLength int
+}
+
+// sequenceName identifie le compteur des codes séquentiels.
+const sequenceName = "shortcode"
+
+// NewSequentialGenerator crée un générateur séquentiel. La graine détermine le
+// mélange de l'alphabet et ne doit plus changer une fois des codes émis.
+func NewSequentialGenerator(source SequenceSource, seed string, minLength int) *SequentialGenerator {
+	return &SequentialGenerator{
+		source:    source,
+		alphabet:  ShuffleAlphabet(Base62Alphabet, seed),
+		minLength: minLength,
+	}
+}
+
+// WithSource retourne une copie du générateur lisant le compteur depuis source.
+func (g *SequentialGenerator) WithSource(source SequenceSource) CodeGenerator {
+	bound := *g
+	bound.source = source
+	return &bound
+}
+
+func (g *SequentialGenerator) Generate() (string, error) {
+	value, err := g.source.NextValue(sequenceName)
+	if err != nil {
+		return "", fmt.Errorf("erreur lecture compteur : %w", err)
+	}
+	code := Encode(value, g.alphabet, g.minLength)
+	if len(code) > MaxLength {
+		return "", fmt.Errorf("compteur épuisé : la valeur %d dépasse %d caractères", value, MaxLength)
+	}
+	return code, nil
+}
+
+// Encode écrit value dans la base de l'alphabet, complétée à gauche jusqu'à minLength.
+func Encode(value uint64, alphabet string, minLength int) string {
+	base := uint64(len(alphabet))
+	var digits []byte
+	for value > 0 || len(digits) < minLength {
+		digits = append(digits, alphabet[value%base])
+		value /= base
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// ShuffleAlphabet mélange l'alphabet de façon déterministe à partir de la graine.
+func ShuffleAlphabet(alphabet, seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	rng := mathrand.New(mathrand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+	letters := []byte(alphabet)
+	rng.Shuffle(len(letters), func(i, j int) { letters[i], letters[j] = letters[j], letters[i] })
+	return string(letters)
+}
+
+// Mots courts pour rester dans les 10 caractères de la colonne shortcode.
+var (
+	adjectives = strings.Fields("red blue gold pink gray tiny big old new fast slow calm bold cool warm wild " +
+		"shy odd raw dry wet hot icy fit fun sly sky top zen epic")
+	nouns = strings.Fields("fox owl cat dog elk bee ant yak emu cod eel ram hen koi bat jay " +
+		"oak elm fig ash sun moon star hill lake wave leaf rock tree bird fish wolf")
+)
+
+// WordsGenerator produit des codes lisibles, par exemple « BlueFox42 ».
+type WordsGenerator struct{}
+
+func (WordsGenerator) Generate() (string, error) {
+	adjective, err := pick(adjectives)
+	if err != nil {
+		return "", err
+	}
+	noun, err := pick(nouns)
+	if err != nil {
+		return "", err
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(100))
+	if err != nil {
+		return "", fmt.Errorf("erreur génération nombre : %w", err)
+	}
+	return fmt.Sprintf("%s%s%02d", capitalize(adjective), capitalize(noun), n.Int64()), nil
+}
+
+func pick(words []string) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+	if err != nil {
+		return "", fmt.Errorf("erreur choix du mot : %w", err)
+	}
+	return words[n.Int64()], nil
+}
+
+func capitalize(word string) string {
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// Registry associe un nom de stratégie à son générateur.
+type Registry struct {
+	generators      map[string]CodeGenerator
+	defaultStrategy string
+}
+
+// GrowthOptions règle l'allongement automatique des codes aléatoires.
+type GrowthOptions struct {
+	Window    int     // Nombre de tentatives observées (0 désactive l'allongement)
+	Threshold float64 // Taux de collision au-delà duquel les codes sont allongés
+}
+
+func (o GrowthOptions) tracker() *CollisionTracker {
+	if o.Window <= 0 {
+		return nil
+	}
+	return NewCollisionTracker(o.Window, o.Threshold)
+}
+
+// NewRegistry crée le registre des stratégies. length s'applique aux stratégies
+// aléatoires et sert de longueur minimale à la stratégie séquentielle.
+func NewRegistry(defaultStrategy string, length int, growth GrowthOptions, source SequenceSource, seed string) (*Registry, error) {
+	r := &Registry{
+		generators: map[string]CodeGenerator{
+			StrategyRandom:      NewRandomGenerator(Base62Alphabet, length, growth.tracker()),
+			StrategyUnambiguous: NewRandomGenerator(UnambiguousAlphabet, length, growth.tracker()),
+			StrategyWords:       WordsGenerator{},
+		},
+		defaultStrategy: defaultStrategy,
+	}
+	if source != nil {
+		r.generators[StrategySequential] = NewSequentialGenerator(source, seed, length)
+	}
+	if _, found := r.generators[defaultStrategy]; !found {
+		return nil, fmt.Errorf("%w : %q", ErrUnknownStrategy, defaultStrategy)
+	}
+	return r, nil
+}
+
+// Get retourne le générateur de la stratégie, ou celui par défaut si le nom est vide.
+func (r *Registry) Get(strategy string) (CodeGenerator, error) {
+	if strategy == "" {
+		strategy = r.defaultStrategy
+	}
+	generator, found := r.generators[strategy]
+	if !found {
+		return nil, fmt.Errorf("%w : %q", ErrUnknownStrategy, strategy)
+	}
+	return generator, nil
+}
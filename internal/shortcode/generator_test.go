@@ -0,0 +1,197 @@
+package shortcode
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCollisionTracker(t *testing.T) {
+	tracker := NewCollisionTracker(4, 0.5)
+
+	// Fenêtre incomplète : jamais de dépassement.
+	for i := 0; i < 3; i++ {
+		if tracker.Record(true) {
+			t.Fatalf("dépassement signalé après %d tentative(s) sur une fenêtre de 4", i+1)
+		}
+	}
+	// 3 collisions sur 4 : seuil dépassé, puis la fenêtre repart de zéro.
+	if !tracker.Record(false) {
+		t.Fatal("3 collisions sur 4 : dépassement attendu")
+	}
+	for i := 0; i < 3; i++ {
+		if tracker.Record(true) {
+			t.Fatal("dépassement signalé avant que la nouvelle fenêtre soit complète")
+		}
+	}
+
+	// Au seuil exact, pas de dépassement ; la fenêtre glisse.
+	tracker = NewCollisionTracker(4, 0.5)
+	for _, collided := range []bool{true, true, false, false, false, false} {
+		if tracker.Record(collided) {
+			t.Fatalf("dépassement signalé à un taux inférieur ou égal au seuil")
+		}
+	}
+	// Fenêtre [false false true true] puis [false true true true] : 3/4.
+	tracker.Record(true)
+	tracker.Record(true)
+	if !tracker.Record(true) {
+		t.Error("la fenêtre glissante doit signaler 3 collisions sur 4")
+	}
+}
+
+func TestRandomGeneratorGrowsOnCollisions(t *testing.T) {
+	generator := NewRandomGenerator(Base62Alphabet, 8, NewCollisionTracker(2, 0.5))
+	assertLength := func(want int) {
+		t.Helper()
+		code, err := generator.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != want {
+			t.Errorf("code %q de longueur %d, attendu %d", code, len(code), want)
+		}
+	}
+
+	assertLength(8)
+	generator.ReportAttempt(true)
+	generator.ReportAttempt(false)
+	assertLength(8)
+
+	// Chaque fenêtre saturée allonge les codes d'un caractère, jusqu'à MaxLength.
+	for i := 0; i < 10; i++ {
+		generator.ReportAttempt(true)
+		generator.ReportAttempt(true)
+	}
+	assertLength(MaxLength)
+}
+
+func TestRandomGeneratorFixedLengthWithoutTracker(t *testing.T) {
+	generator := NewRandomGenerator(UnambiguousAlphabet, 6, nil)
+	for i := 0; i < 10; i++ {
+		generator.ReportAttempt(true)
+	}
+	code, err := generator.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 6 || strings.ContainsAny(code, "0Oo1lI") {
+		t.Errorf("code %q : 6 caractères sans ambiguïté attendus", code)
+	}
+}
+
+// counterSource simule le compteur persistant.
+type counterSource struct{ next uint64 }
+
+func (s *counterSource) NextValue(string) (uint64, error) {
+	value := s.next
+	s.next++
+	return value, nil
+}
+
+func TestSequentialGeneratorUnique(t *testing.T) {
+	source := &counterSource{}
+	generator := NewSequentialGenerator(source, "graine", 4)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 20000; i++ {
+		code, err := generator.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[code] {
+			t.Fatalf("code %q émis deux fois", code)
+		}
+		seen[code] = true
+		if len(code) < 4 || strings.Trim(code, Base62Alphabet) != "" {
+			t.Fatalf("code %q : au moins 4 caractères base62 attendus", code)
+		}
+	}
+
+	// Les codes consécutifs ne se suivent pas dans l'alphabet d'origine.
+	first := NewSequentialGenerator(&counterSource{next: 1}, "graine", 4)
+	a, _ := first.Generate()
+	b, _ := first.Generate()
+	if a == Encode(1, Base62Alphabet, 4) && b == Encode(2, Base62Alphabet, 4) {
+		t.Errorf("alphabet non mélangé : %q puis %q", a, b)
+	}
+}
+
+func TestSequentialGeneratorMaxLength(t *testing.T) {
+	// 62^10 - 1 est la plus grande valeur tenant sur 10 caractères.
+	largest := uint64(math.Pow(62, 10)) - 1
+	source := &counterSource{next: largest}
+	generator := NewSequentialGenerator(source, "", 4)
+
+	code, err := generator.Generate()
+	if err != nil || len(code) != MaxLength {
+		t.Fatalf("valeur %d : code %q, %v", largest, code, err)
+	}
+	if code, err := generator.Generate(); err == nil {
+		t.Errorf("valeur %d : code %q de %d caractères accepté", largest+1, code, len(code))
+	}
+}
+
+func TestShuffleAlphabet(t *testing.T) {
+	shuffled := ShuffleAlphabet(Base62Alphabet, "graine")
+	if shuffled != ShuffleAlphabet(Base62Alphabet, "graine") {
+		t.Error("le mélange doit être déterministe")
+	}
+	if shuffled == ShuffleAlphabet(Base62Alphabet, "autre") {
+		t.Error("deux graines doivent donner deux mélanges")
+	}
+	if len(shuffled) != len(Base62Alphabet) || strings.Trim(Base62Alphabet, shuffled) != "" {
+		t.Errorf("%q n'est pas une permutation de l'alphabet", shuffled)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		value     uint64
+		minLength int
+		want      string
+	}{
+		{0, 0, ""},
+		{0, 3, "aaa"},
+		{1, 3, "aab"},
+		{61, 1, "9"},
+		{62, 1, "ba"},
+		{62*62 + 1, 2, "bab"},
+	}
+	for _, tt := range tests {
+		if got := Encode(tt.value, Base62Alphabet, tt.minLength); got != tt.want {
+			t.Errorf("Encode(%d, %d) = %q, attendu %q", tt.value, tt.minLength, got, tt.want)
+		}
+	}
+}
+
+func TestWordsFitMaxLength(t *testing.T) {
+	for _, adjective := range adjectives {
+		for _, noun := range nouns {
+			if length := len(adjective) + len(noun) + 2; length > MaxLength {
+				t.Errorf("%s%s00 : %d caractères", adjective, noun, length)
+			}
+		}
+	}
+	code, err := WordsGenerator{}.Generate()
+	if err != nil || len(code) > MaxLength {
+		t.Errorf("code %q, %v", code, err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry, err := NewRegistry(StrategyRandom, 6, GrowthOptions{}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Get(StrategySequential); !errors.Is(err, ErrUnknownStrategy) {
+		t.Errorf("séquentiel sans compteur : erreur %v, attendu ErrUnknownStrategy", err)
+	}
+	if generator, err := registry.Get(""); err != nil || generator == nil {
+		t.Errorf("stratégie par défaut : %v", err)
+	}
+	if _, err := NewRegistry(StrategySequential, 6, GrowthOptions{}, nil, ""); !errors.Is(err, ErrUnknownStrategy) {
+		t.Errorf("stratégie par défaut indisponible : erreur %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/metrics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/tracing"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Pilotes de base de données pris en charge (database.driver).
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// OpenDB ouvre la base configurée et dimensionne son pool de connexions.
+// Pour SQLite, le DSN par défaut est le fichier database.name.
+func OpenDB(cfg *config.Config) (*gorm.DB, error) {
+	dialector, err := dialectorFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetimeMinutes) * time.Minute)
+	return db, nil
+}
+
+func dialectorFor(cfg *config.Config) (gorm.Dialector, error) {
+	driver, dsn := cfg.Database.Driver, cfg.Database.DSN
+	switch driver {
+	case DriverSQLite, "":
+		if dsn == "" {
+			dsn = cfg.Database.Name
+		}
+		sqlDB, err := sql.Open(sqlite.DriverName, sqliteDSN(dsn, cfg))
+		if err != nil {
+			return nil, err
+		}
+		// Une seule transaction à la fois : voir serializedPool.
+		return sqlite.New(sqlite.Config{Conn: newSerializedPool(sqlDB)}), nil
+
+	case DriverPostgres:
+		if dsn == "" {
+			return nil, fmt.Errorf("database.dsn est requis pour le pilote %s", driver)
+		}
+		return postgres.Open(dsn), nil
+
+	case DriverMySQL:
+		if dsn == "" {
+			return nil, fmt.Errorf("database.dsn est requis pour le pilote %s", driver)
+		}
+		// Les dates sont lues en time.Time : parseTime est imposé.
+		mysqlConfig, err := mysqldriver.ParseDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("DSN MySQL invalide : %w", err)
+		}
+		mysqlConfig.ParseTime = true
+		return mysql.Open(mysqlConfig.FormatDSN()), nil
+	}
+	return nil, fmt.Errorf("pilote de base de données inconnu : %q (sqlite, postgres ou mysql)", driver)
+}
+
+// sqliteDSN complète le DSN SQLite avec les réglages de la configuration,
+// sauf ceux qu'il fixe déjà. Passés dans le DSN, ils s'appliquent à chaque
+// connexion du pool, et pas seulement à la première.
+func sqliteDSN(dsn string, cfg *config.Config) string {
+	params := []struct {
+		names []string
+		value string
+	}{
+		{[]string{"_journal_mode", "_journal"}, cfg.Database.SQLiteJournalMode},
+		{[]string{"_busy_timeout", "_timeout"}, strconv.Itoa(cfg.Database.SQLiteBusyTimeoutMs)},
+		{[]string{"_synchronous", "_sync"}, cfg.Database.SQLiteSynchronous},
+		// Les transactions prennent le verrou d'écriture dès BEGIN : une
+		// transaction qui lit puis écrit échouerait sinon immédiatement, sans
+		// attendre le busy timeout, si une autre a écrit entre-temps.
+		{[]string{"_txlock"}, "immediate"},
+	}
+
+	path, query, _ := strings.Cut(dsn, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return dsn
+	}
+	for _, param := range params {
+		if !slices.ContainsFunc(param.names, values.Has) {
+			values.Set(param.names[0], param.value)
+		}
+	}
+	return path + "?" + values.Encode()
+}
+
+// SQLiteFile retourne le chemin du fichier de la base SQLite configurée.
+func SQLiteFile(cfg *config.Config) (string, error) {
+	if cfg.Database.Driver != DriverSQLite && cfg.Database.Driver != "" {
+		return "", fmt.Errorf("opération réservée au pilote sqlite (pilote configuré : %s)", cfg.Database.Driver)
+	}
+	if cfg.Database.DSN == "" {
+		return cfg.Database.Name, nil
+	}
+	// DSN de la forme [file:]chemin[?paramètres]
+	file, _, _ := strings.Cut(strings.TrimPrefix(cfg.Database.DSN, "file:"), "?")
+	return file, nil
+}
@@ -0,0 +1,228 @@
+package storage_test
+
+// Suite d'intégration des dépôts sur chaque pilote de base de données.
+// SQLite s'exécute toujours, sur un fichier temporaire. PostgreSQL et MySQL ne
+// s'exécutent que si leur DSN est fourni, sur une base dédiée aux tests dont
+// le schéma est supprimé puis recréé :
+//
+//	URLSHORTENER_TEST_POSTGRES_DSN="host=localhost user=test password=test dbname=urlshortener_test" \
+//	URLSHORTENER_TEST_MYSQL_DSN="test:test@tcp(localhost:3306)/urlshortener_test" \
+//	go test ./internal/storage/
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/analytics"
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/migrations"
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// integrationBackends associe chaque pilote à la variable d'environnement de son DSN.
+var integrationBackends = []struct {
+	driver string
+	dsnEnv string
+}{
+	{storage.DriverSQLite, ""},
+	{storage.DriverPostgres, "URLSHORTENER_TEST_POSTGRES_DSN"},
+	{storage.DriverMySQL, "URLSHORTENER_TEST_MYSQL_DSN"},
+}
+
+func TestIntegration(t *testing.T) {
+	for _, backend := range integrationBackends {
+		t.Run(backend.driver, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Database.Driver = backend.driver
+			cfg.Database.MaxOpenConns = 4
+			cfg.Database.MaxIdleConns = 4
+			if backend.dsnEnv == "" {
+				cfg.Database.Name = filepath.Join(t.TempDir(), "integration.db")
+				cfg.Database.SQLiteJournalMode = "WAL"
+				cfg.Database.SQLiteSynchronous = "NORMAL"
+				cfg.Database.SQLiteBusyTimeoutMs = 5000
+			} else if cfg.Database.DSN = os.Getenv(backend.dsnEnv); cfg.Database.DSN == "" {
+				t.Skipf("%s non défini", backend.dsnEnv)
+			}
+
+			db := openIntegrationDB(t, cfg)
+			store := repository.NewGormStore(db)
+			t.Run("ShortCodeIsCaseSensitive", func(t *testing.T) { testShortCodeCase(t, store) })
+			t.Run("DuplicateShortCode", func(t *testing.T) { testDuplicateShortCode(t, store) })
+			t.Run("SearchEscapesWildcards", func(t *testing.T) { testSearchEscapes(t, store) })
+			t.Run("ClickRollups", func(t *testing.T) { testClickRollups(t, store) })
+			t.Run("DeleteLink", func(t *testing.T) { testDeleteLink(t, store) })
+		})
+	}
+}
+
+// openIntegrationDB ouvre la base et recrée son schéma par les migrations.
+func openIntegrationDB(t *testing.T, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	db, err := storage.OpenDB(cfg)
+	if err != nil {
+		t.Fatalf("connexion : %v", err)
+	}
+	db.Logger = logger.Discard
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	runner, err := migrations.NewRunner(db)
+	if err != nil {
+		t.Fatalf("migrations : %v", err)
+	}
+	// Descente complète puis montée : exerce aussi les migrations down.
+	if _, err := runner.To(0); err != nil {
+		t.Fatalf("migration vers 0 : %v", err)
+	}
+	if _, err := runner.Up(); err != nil {
+		t.Fatalf("migration up : %v", err)
+	}
+	if err := runner.Check(); err != nil {
+		t.Fatalf("schéma : %v", err)
+	}
+	return db
+}
+
+func createTestLink(t *testing.T, store repository.Store, code, longURL string) *models.Link {
+	t.Helper()
+	link := &models.Link{ShortCode: code, LongURL: longURL, CreatedAt: time.Now()}
+	if err := store.Links().CreateLink(link); err != nil {
+		t.Fatalf("création du lien %s : %v", code, err)
+	}
+	return link
+}
+
+func testShortCodeCase(t *testing.T, store repository.Store) {
+	createTestLink(t, store, "CaseAb", "https://example.com/upper")
+	createTestLink(t, store, "caseab", "https://example.com/lower")
+
+	ctx := context.Background()
+	for code, want := range map[string]string{"CaseAb": "upper", "caseab": "lower"} {
+		link, err := store.Links().GetLinkByShortCode(ctx, code)
+		if err != nil {
+			t.Fatalf("lecture de %s : %v", code, err)
+		}
+		if !strings.HasSuffix(link.LongURL, want) {
+			t.Errorf("%s résolu vers %s, attendu …/%s", code, link.LongURL, want)
+		}
+	}
+	if _, err := store.Links().GetLinkByShortCode(ctx, "CASEAB"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("CASEAB : erreur %v, attendu ErrRecordNotFound", err)
+	}
+}
+
+func testDuplicateShortCode(t *testing.T, store repository.Store) {
+	createTestLink(t, store, "dup123", "https://example.com/a")
+
+	// Dans une transaction, l'échec ne doit pas empêcher l'insertion suivante.
+	err := store.Transaction(func(tx repository.Store) error {
+		err := tx.Links().CreateLink(&models.Link{ShortCode: "dup123", LongURL: "https://example.com/b", CreatedAt: time.Now()})
+		if !errors.Is(err, repository.ErrShortCodeTaken) {
+			t.Errorf("code en double : erreur %v, attendu ErrShortCodeTaken", err)
+		}
+		return tx.Links().CreateLink(&models.Link{ShortCode: "dup124", LongURL: "https://example.com/b", CreatedAt: time.Now()})
+	})
+	if err != nil {
+		t.Fatalf("transaction : %v", err)
+	}
+}
+
+func testSearchEscapes(t *testing.T, store repository.Store) {
+	createTestLink(t, store, "pct100", "https://example.com/100%_off")
+	createTestLink(t, store, "pct200", "https://example.com/100x_off")
+
+	for query, want := range map[string]int64{"100%_": 1, "100": 2, "!": 0} {
+		links, total, err := store.Links().SearchLinks(query, 0, 10)
+		if err != nil {
+			t.Fatalf("recherche %q : %v", query, err)
+		}
+		if total != want || int64(len(links)) != want {
+			t.Errorf("recherche %q : %d résultat(s) (total %d), attendu %d", query, len(links), total, want)
+		}
+	}
+}
+
+func testClickRollups(t *testing.T, store repository.Store) {
+	link := createTestLink(t, store, "roll01", "https://example.com/rollups")
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	userAgent := "Mozilla/5.0 (iPhone) " + strings.Repeat("é", 300)
+	clicks := []models.Click{
+		{LinkID: link.ID, Timestamp: day.Add(9*time.Hour + 15*time.Minute), Country: "FR", UserAgent: analytics.UserAgent(userAgent)},
+		{LinkID: link.ID, Timestamp: day.Add(9*time.Hour + 45*time.Minute), Country: "FR"},
+		{LinkID: link.ID, Timestamp: day.Add(23*time.Hour + 59*time.Minute), Referrer: "example.org"},
+	}
+	ctx := context.Background()
+	if err := store.Clicks().CreateClicks(ctx, clicks[:2]); err != nil {
+		t.Fatalf("enregistrement des clics : %v", err)
+	}
+	// Second lot sur les mêmes périodes : les agrégats existants sont incrémentés.
+	if err := store.Clicks().CreateClicks(ctx, clicks[2:]); err != nil {
+		t.Fatalf("enregistrement des clics : %v", err)
+	}
+
+	if count, err := store.Clicks().CountClicksByLinkID(link.ID); err != nil || count != 3 {
+		t.Errorf("total : %d, %v, attendu 3", count, err)
+	}
+
+	hourly, err := store.Rollups().Series(link.ID, repository.GranularityHour, day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("série horaire : %v", err)
+	}
+	if len(hourly) != 2 || !hourly[0].Bucket.Equal(day.Add(9*time.Hour)) || hourly[0].Clicks != 2 ||
+		!hourly[1].Bucket.Equal(day.Add(23*time.Hour)) || hourly[1].Clicks != 1 {
+		t.Errorf("série horaire = %+v", hourly)
+	}
+
+	daily, err := store.Rollups().Series(link.ID, repository.GranularityDay, day.Add(-24*time.Hour), day.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("série journalière : %v", err)
+	}
+	if len(daily) != 1 || !daily[0].Bucket.Equal(day) || daily[0].Clicks != 3 {
+		t.Errorf("série journalière = %+v", daily)
+	}
+
+	countries, err := store.Rollups().Breakdown(link.ID, repository.GranularityDay, models.DimensionCountry, day, day.Add(24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("répartition : %v", err)
+	}
+	if len(countries) != 2 || countries[0] != (repository.RollupValue{Value: "FR", Clicks: 2}) {
+		t.Errorf("répartition par pays = %+v", countries)
+	}
+
+	// Le recalcul depuis la table clicks retrouve les mêmes agrégats.
+	if _, err := store.Rollups().Rebuild(); err != nil {
+		t.Fatalf("recalcul : %v", err)
+	}
+	if count, err := store.Clicks().CountClicksByLinkID(link.ID); err != nil || count != 3 {
+		t.Errorf("total après recalcul : %d, %v, attendu 3", count, err)
+	}
+}
+
+func testDeleteLink(t *testing.T, store repository.Store) {
+	link := createTestLink(t, store, "del001", "https://example.com/delete")
+	if err := store.Clicks().CreateClicks(context.Background(), []models.Click{{LinkID: link.ID, Timestamp: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Links().DeleteLink(link.ID); err != nil {
+		t.Fatalf("suppression : %v", err)
+	}
+	if count, err := store.Clicks().CountClicksByLinkID(link.ID); err != nil || count != 0 {
+		t.Errorf("agrégats restants : %d, %v", count, err)
+	}
+	if err := store.Links().DeleteLink(link.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("double suppression : erreur %v, attendu ErrRecordNotFound", err)
+	}
+}
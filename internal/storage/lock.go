@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrDatabaseInUse signale une base SQLite déjà verrouillée par un autre processus.
+var ErrDatabaseInUse = errors.New("base utilisée par un autre processus")
+
+// FileLock est un verrou consultatif sur le fichier <base>.lock, libéré par
+// Close ou à la fin du processus, même brutale.
+type FileLock struct {
+	file *os.File
+}
+
+// LockSQLite verrouille la base SQLite dbFile sans attendre. Le serveur prend
+// un verrou partagé pour toute sa durée de vie, les commandes qui écrivent
+// (create, import, migrate, rollup) le temps de leur exécution ; la
+// restauration prend un verrou exclusif, refusé avec ErrDatabaseInUse tant
+// qu'un verrou partagé est tenu.
+func LockSQLite(dbFile string, exclusive bool) (*FileLock, error) {
+	file, err := os.OpenFile(dbFile+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file, exclusive); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileLock{file: file}, nil
+}
+
+// Close libère le verrou. Le fichier .lock est conservé : le supprimer
+// laisserait un autre processus verrouiller un fichier différent.
+func (l *FileLock) Close() error {
+	return l.file.Close()
+}
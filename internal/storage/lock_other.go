@@ -0,0 +1,11 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// Sans flock, le verrou n'est pas appliqué : la restauration ne peut pas
+// détecter un serveur en cours d'exécution.
+func lockFile(file *os.File, exclusive bool) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockSQLite(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "app.db")
+
+	// Serveur et commande d'écriture partagent la base.
+	server, err := LockSQLite(dbFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command, err := LockSQLite(dbFile, false)
+	if err != nil {
+		t.Fatalf("second verrou partagé : %v", err)
+	}
+
+	// La restauration attend qu'aucun ne la tienne.
+	if _, err := LockSQLite(dbFile, true); !errors.Is(err, ErrDatabaseInUse) {
+		t.Fatalf("verrou exclusif : erreur %v, attendu ErrDatabaseInUse", err)
+	}
+	server.Close()
+	if _, err := LockSQLite(dbFile, true); !errors.Is(err, ErrDatabaseInUse) {
+		t.Fatalf("verrou exclusif : erreur %v, attendu ErrDatabaseInUse", err)
+	}
+	command.Close()
+
+	restore, err := LockSQLite(dbFile, true)
+	if err != nil {
+		t.Fatalf("verrou exclusif : %v", err)
+	}
+	defer restore.Close()
+	if _, err := LockSQLite(dbFile, false); !errors.Is(err, ErrDatabaseInUse) {
+		t.Errorf("verrou partagé pendant la restauration : erreur %v, attendu ErrDatabaseInUse", err)
+	}
+}
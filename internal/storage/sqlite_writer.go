@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// serializedPool fait attendre les transactions d'un même processus leur tour
+// dans une file Go, sans solliciter SQLite. Avec _txlock=immediate, chaque
+// transaction prend le verrou d'écriture dès BEGIN : les laisser se disputer
+// ce verrou ferait patienter les perdantes par paliers de sommeil croissants
+// (jusqu'à 100 ms), au risque d'en faire attendre certaines plusieurs
+// secondes. Les lectures hors transaction restent concurrentes (mode WAL).
+type serializedPool struct {
+	*sql.DB
+	writer chan struct{} // Jeton de l'unique transaction en cours
+}
+
+func newSerializedPool(db *sql.DB) *serializedPool {
+	return &serializedPool{DB: db, writer: make(chan struct{}, 1)}
+}
+
+// BeginTx attend la fin de la transaction en cours, puis en ouvre une.
+func (p *serializedPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	select {
+	case p.writer <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	tx, err := p.DB.BeginTx(ctx, opts)
+	if err != nil {
+		<-p.writer
+		return nil, err
+	}
+	return &serializedTx{Tx: tx, release: sync.OnceFunc(func() { <-p.writer })}, nil
+}
+
+// GetDBConn expose la connexion sous-jacente à gorm.DB.DB().
+func (p *serializedPool) GetDBConn() (*sql.DB, error) {
+	return p.DB, nil
+}
+
+// serializedTx libère le jeton à la fin de la transaction.
+type serializedTx struct {
+	*sql.Tx
+	release func()
+}
+
+func (t *serializedTx) Commit() error {
+	defer t.release()
+	return t.Tx.Commit()
+}
+
+func (t *serializedTx) Rollback() error {
+	defer t.release()
+	return t.Tx.Rollback()
+}
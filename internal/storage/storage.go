@@ -0,0 +1,100 @@
+// Package storage assemble l'accès aux données de l'application : le Store
+// GORM et, selon la section cache de la configuration, les caches de liens
+// et les compteurs de clics partagés.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// Options choisit les caches à mettre en place.
+type Options struct {
+	// LocalCache active le cache LRU en mémoire, utile pour un processus
+	// durable comme le serveur, inutile pour une commande CLI.
+	LocalCache bool
+}
+
+// Storage regroupe le Store de l'application et ses caches.
+type Storage struct {
+	repository.Store
+	LocalCache *repository.CachedLinkRepository // nil sans cache local
+	Counter    repository.ClickCounter          // nil sans backend redis
+
+	redis  *redis.Client
+	cancel context.CancelFunc
+}
+
+// Open construit le Store sur db. Avec le backend redis, les écritures
+// invalident le cache partagé de toutes les instances, CLI comprise.
+func Open(cfg *config.Config, db *gorm.DB, opts Options) (*Storage, error) {
+	s := &Storage{Store: repository.NewGormStore(db), cancel: func() {}}
+
+	ttl := time.Duration(cfg.Cache.TTLSeconds) * time.Second
+	negativeTTL := time.Duration(cfg.Cache.NegativeTTLSeconds) * time.Second
+	links := s.Store.Links()
+
+	if cfg.Cache.Backend == "redis" {
+		s.redis = redis.NewClient(&redis.Options{
+			Addr:     cfg.Cache.RedisAddr,
+			Password: cfg.Cache.RedisPassword,
+			DB:       cfg.Cache.RedisDB,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := s.redis.Ping(ctx).Err()
+		cancel()
+		if err != nil {
+			// Le cache est facultatif : les lectures retombent sur la base et
+			// le client se reconnecte dès que le serveur redevient joignable.
+			slog.Warn("Serveur Redis injoignable", "addr", cfg.Cache.RedisAddr, "error", err)
+		}
+
+		links = repository.NewRedisLinkRepository(links, s.redis, cfg.Cache.RedisPrefix, ttl, negativeTTL)
+		s.Counter = repository.NewRedisClickCounter(s.redis, cfg.Cache.RedisPrefix)
+	}
+
+	if opts.LocalCache && cfg.Cache.Size > 0 {
+		s.LocalCache = repository.NewCachedLinkRepository(links, cfg.Cache.Size, ttl, negativeTTL)
+		links = s.LocalCache
+
+		if s.redis != nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			s.cancel = cancel
+			repository.SubscribeInvalidations(ctx, s.redis, cfg.Cache.RedisPrefix, s.LocalCache)
+		}
+	}
+
+	if caching, ok := links.(repository.CachingLinkRepository); ok {
+		s.Store = repository.NewCachedStore(s.Store, caching)
+	}
+	return s, nil
+}
+
+// Describe résume les caches actifs pour les journaux de démarrage.
+func (s *Storage) Describe() string {
+	switch {
+	case s.redis != nil && s.LocalCache != nil:
+		return fmt.Sprintf("redis (%s) + cache local", s.redis.Options().Addr)
+	case s.redis != nil:
+		return fmt.Sprintf("redis (%s)", s.redis.Options().Addr)
+	case s.LocalCache != nil:
+		return "cache local"
+	}
+	return "aucun cache"
+}
+
+// Close arrête l'abonnement aux invalidations et ferme la connexion Redis.
+func (s *Storage) Close() error {
+	s.cancel()
+	if s.redis != nil {
+		return s.redis.Close()
+	}
+	return nil
+}
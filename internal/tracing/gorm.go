@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// statementSpan est le span d'une requête et le contexte à rétablir ensuite :
+// une instance réutilisée (FindInBatches) ne doit pas imbriquer ses requêtes.
+type statementSpan struct {
+	span   trace.Span
+	parent context.Context
+}
+
+// GormPlugin ouvre un span par requête GORM, enfant du span du contexte de
+// la requête (db.WithContext).
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("*").Register("tracing:before_create", startSpan("create")),
+		cb.Create().After("*").Register("tracing:after_create", endSpan),
+		cb.Query().Before("*").Register("tracing:before_query", startSpan("query")),
+		cb.Query().After("*").Register("tracing:after_query", endSpan),
+		cb.Update().Before("*").Register("tracing:before_update", startSpan("update")),
+		cb.Update().After("*").Register("tracing:after_update", endSpan),
+		cb.Delete().Before("*").Register("tracing:before_delete", startSpan("delete")),
+		cb.Delete().After("*").Register("tracing:after_delete", endSpan),
+		cb.Row().Before("*").Register("tracing:before_row", startSpan("row")),
+		cb.Row().After("*").Register("tracing:after_row", endSpan),
+		cb.Raw().Before("*").Register("tracing:before_raw", startSpan("raw")),
+		cb.Raw().After("*").Register("tracing:after_raw", endSpan),
+	)
+}
+
+func startSpan(operation string) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		parent := tx.Statement.Context
+		ctx, span := Tracer().Start(parent, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemNameKey.String(tx.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			))
+		tx.Statement.Context = ctx
+		tx.InstanceSet(spanKey, statementSpan{span: span, parent: parent})
+	}
+}
+
+// endSpan complète le span avec la requête, sans ses paramètres, et son résultat.
+func endSpan(tx *gorm.DB) {
+	value, ok := tx.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	current := value.(statementSpan)
+	tx.Statement.Context = current.parent
+	span := current.span
+	defer span.End()
+
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		semconv.DBQueryText(tx.Statement.SQL.String()),
+		attribute.Int64("db.response.affected_rows", tx.Statement.RowsAffected),
+	)
+	if tx.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(tx.Statement.Table))
+	}
+	if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,113 @@
+// Package tracing configure OpenTelemetry : export des spans (OTLP, sortie
+// standard ou fichier) et traceur partagé par le serveur, les workers et le moniteur.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Julien-Somasundaram/urlshortener"
+
+// Exportateurs de spans acceptés par tracing.exporter.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Tracer retourne le traceur du service. Sans appel à Setup, les spans ne
+// sont ni échantillonnés ni exportés.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// SpanIDs retourne les identifiants hexadécimaux de trace et de span, vides
+// pour un span non valide (traçage désactivé).
+func SpanIDs(sc trace.SpanContext) (traceID, spanID string) {
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
+
+// RemoteSpanContext reconstruit le contexte d'un span à partir des
+// identifiants retournés par SpanIDs. Il n'est pas valide si l'un d'eux manque.
+func RemoteSpanContext(traceID, spanID string) trace.SpanContext {
+	tid, err := trace.TraceIDFromHex(traceID)
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	sid, err := trace.SpanIDFromHex(spanID)
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid, Remote: true})
+}
+
+// Setup installe le fournisseur de spans configuré et retourne sa fonction
+// d'arrêt, qui exporte les derniers spans.
+func Setup(ctx context.Context, cfg *config.Config) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+	if cfg.Tracing.Exporter == ExporterNone {
+		return shutdown, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Tracing.Exporter {
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Tracing.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Tracing.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterFile:
+		// Une ligne JSON par span, ajoutée au fichier.
+		file, openErr := os.OpenFile(cfg.Tracing.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if openErr != nil {
+			return shutdown, openErr
+		}
+		shutdown = func(context.Context) error { return file.Close() }
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return shutdown, fmt.Errorf("exportateur de traces inconnu : %q (none, otlp, stdout ou file)", cfg.Tracing.Exporter)
+	}
+	if err != nil {
+		return shutdown, err
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.Tracing.ServiceName)))
+	if err != nil {
+		return shutdown, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Une requête entrante déjà échantillonnée (traceparent) l'est aussi ici.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	closeFile := shutdown
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeFile(ctx))
+	}, nil
+}
@@ -1,37 +1,225 @@
 package workers
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
+	"github.com/Julien-Somasundaram/urlshortener/internal/metrics"
 	"github.com/Julien-Somasundaram/urlshortener/internal/models"
 	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+	"github.com/Julien-Somasundaram/urlshortener/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// StartClickWorkers démarre plusieurs workers en parallèle
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
-	for i := 0; i < workerCount; i++ {
-		go clickWorker(clickEventsChan, clickRepo)
+// ClickWriter est l'unique écrivain des clics : il les insère par lots, en une
+// requête par lot, au lieu de multiplier les transactions concurrentes qui se
+// disputeraient le verrou d'écriture de SQLite.
+type ClickWriter struct {
+	events        <-chan models.ClickEvent
+	clickRepo     repository.ClickRepository
+	counter       repository.ClickCounter // nil sans compteurs partagés
+	batchSize     int
+	flushInterval time.Duration
+	done          chan struct{}
+	written       atomic.Uint64
+	failed        atomic.Uint64
+	heartbeat     atomic.Int64 // Instant (UnixNano) du dernier tour de boucle, 0 une fois arrêté
+}
+
+// Nouveaux essais d'un lot après une erreur passagère (base verrouillée,
+// connexion perdue) : flushAttempts essais au total, l'attente doublant à
+// partir de flushBackoff.
+const (
+	flushAttempts = 4
+	flushBackoff  = 100 * time.Millisecond
+)
+
+// counterTimeout borne la mise à jour des compteurs partagés après un lot.
+const counterTimeout = 500 * time.Millisecond
+
+// StartClickWriter démarre l'écrivain des clics reçus sur clickEventsChan. Un
+// lot est écrit dès qu'il atteint batchSize clics, ou après flushInterval.
+// counter, s'il n'est pas nil, est informé des clics sortis de l'attente.
+func StartClickWriter(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository, counter repository.ClickCounter, batchSize int, flushInterval time.Duration) *ClickWriter {
+	w := &ClickWriter{
+		events:        clickEventsChan,
+		clickRepo:     clickRepo,
+		counter:       counter,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		done:          make(chan struct{}),
+	}
+	slog.Info("Démarrage de l'écrivain de clics", "batch_size", batchSize, "flush_interval", flushInterval)
+	go w.run()
+	return w
+}
+
+func (w *ClickWriter) run() {
+	defer close(w.done)
+	defer w.heartbeat.Store(0)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.ClickEvent, 0, w.batchSize)
+	for {
+		// Le ticker garantit un battement au moins toutes les flushInterval,
+		// sauf si une écriture reste bloquée.
+		w.heartbeat.Store(time.Now().UnixNano())
+		select {
+		case event, ok := <-w.events:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		}
 	}
 }
 
-// Un worker écoute indéfiniment le channel et traite les événements
-func clickWorker(clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	for event := range clickEventsChan {
-		click := &models.Click{
+func (w *ClickWriter) flush(batch []models.ClickEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	start := time.Now()
+	defer func() { metrics.ClickFlushDuration.Observe(time.Since(start).Seconds()) }()
+	// Écrits ou abandonnés, les clics du lot ne sont plus en attente.
+	defer w.releasePending(batch)
+
+	// Le lot regroupe des clics de requêtes différentes : son span est relié à
+	// chacune d'elles plutôt que rattaché à l'une.
+	links := make([]trace.Link, 0, len(batch))
+	for _, event := range batch {
+		if sc := tracing.RemoteSpanContext(event.TraceID, event.SpanID); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+	ctx, span := tracing.Tracer().Start(context.Background(), "clicks flush",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("clicks.batch_size", len(batch))))
+	defer span.End()
+
+	clicks := make([]models.Click, len(batch))
+	for i, event := range batch {
+		clicks[i] = models.Click{
 			LinkID:    event.LinkID,
 			UserAgent: event.UserAgent,
 			IPAddress: event.IPAddress,
 			Timestamp: event.Timestamp,
+			Referrer:  event.Referrer,
+			Country:   event.Country,
 		}
+	}
 
-		err := clickRepo.CreateClick(click)
-		if err != nil {
-			log.Printf("ERROR: Failed to save click for LinkID %d (UserAgent: %s, IP: %s): %v",
-				event.LinkID, event.UserAgent, event.IPAddress, err)
-		} else {
-			log.Printf("✅ Click recorded for LinkID %d at %v", event.LinkID, time.Now())
+	err := w.writeBatch(ctx, clicks)
+	if err == nil {
+		w.written.Add(uint64(len(clicks)))
+		metrics.ClickEventsPersisted.WithLabelValues("success").Add(float64(len(clicks)))
+		return
+	}
+	span.RecordError(err)
+	if len(clicks) == 1 || !repository.IsDataError(err) {
+		span.SetStatus(codes.Error, err.Error())
+		w.failBatch(batch, err)
+		return
+	}
+
+	// Un clic invalide (lien supprimé entre-temps) ne doit pas faire perdre tout le lot.
+	for i := range clicks {
+		clicks[i].ID = 0
+		if err := w.clickRepo.CreateClick(ctx, &clicks[i]); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			w.fail(batch[i], err)
+			continue
 		}
+		w.written.Add(1)
+		metrics.ClickEventsPersisted.WithLabelValues("success").Inc()
+	}
+}
+
+// writeBatch insère le lot en le réessayant tant que l'erreur est passagère.
+// Une erreur due au contenu d'un clic est retournée sans nouvel essai.
+func (w *ClickWriter) writeBatch(ctx context.Context, clicks []models.Click) error {
+	delay := flushBackoff
+	for attempt := 1; ; attempt++ {
+		err := w.clickRepo.CreateClicks(ctx, clicks)
+		if err == nil || repository.IsDataError(err) || attempt == flushAttempts {
+			return err
+		}
+		slog.Warn("Échec écriture du lot de clics, nouvel essai",
+			"clicks", len(clicks), "attempt", attempt, "retry_in", delay, "error", err)
+		time.Sleep(delay)
+		delay *= 2
+		// Identifiants éventuellement attribués par l'insertion annulée
+		for i := range clicks {
+			clicks[i].ID = 0
+		}
+	}
+}
+
+// releasePending retire les clics du lot des compteurs d'attente partagés.
+func (w *ClickWriter) releasePending(batch []models.ClickEvent) {
+	if w.counter == nil {
+		return
+	}
+	perLink := make(map[uint]int)
+	for _, event := range batch {
+		perLink[event.LinkID]++
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), counterTimeout)
+	defer cancel()
+	for linkID, count := range perLink {
+		if err := w.counter.Flushed(ctx, linkID, count); err != nil {
+			slog.Warn("Compteur de clics indisponible", "link_id", linkID, "error", err)
+			return
+		}
+	}
+}
+
+// failBatch abandonne tout le lot, après épuisement des nouveaux essais.
+func (w *ClickWriter) failBatch(batch []models.ClickEvent, err error) {
+	if len(batch) == 1 {
+		w.fail(batch[0], err)
+		return
+	}
+	w.failed.Add(uint64(len(batch)))
+	metrics.ClickEventsPersisted.WithLabelValues("failure").Add(float64(len(batch)))
+	slog.Error("Échec enregistrement du lot de clics", "clicks", len(batch), "error", err)
+}
+
+func (w *ClickWriter) fail(event models.ClickEvent, err error) {
+	w.failed.Add(1)
+	metrics.ClickEventsPersisted.WithLabelValues("failure").Inc()
+	slog.Error("Échec enregistrement du clic", "link_id", event.LinkID, "request_id", event.RequestID, "error", err)
+}
+
+// Wait attend, une fois le channel fermé, l'écriture des derniers clics.
+func (w *ClickWriter) Wait() {
+	<-w.done
+}
+
+// Stats retourne le nombre de clics enregistrés et en échec.
+func (w *ClickWriter) Stats() (written, failed uint64) {
+	return w.written.Load(), w.failed.Load()
+}
+
+// LastHeartbeat retourne l'instant du dernier tour de boucle de l'écrivain,
+// ou l'instant nul s'il est arrêté.
+func (w *ClickWriter) LastHeartbeat() time.Time {
+	if at := w.heartbeat.Load(); at != 0 {
+		return time.Unix(0, at)
 	}
+	return time.Time{}
 }
@@ -0,0 +1,109 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Julien-Somasundaram/urlshortener/internal/models"
+	"github.com/Julien-Somasundaram/urlshortener/internal/repository"
+)
+
+// fakeClickRepository échoue sur les premiers lots, puis sur les clics du lien badLink.
+type fakeClickRepository struct {
+	repository.ClickRepository
+	mu           sync.Mutex
+	batchErrors  []error // Erreurs retournées par les appels successifs à CreateClicks
+	badLink      uint
+	batchCalls   int
+	singleCalls  int
+	writtenLinks []uint
+}
+
+func (r *fakeClickRepository) CreateClicks(_ context.Context, clicks []models.Click) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batchCalls++
+	if len(r.batchErrors) > 0 {
+		err := r.batchErrors[0]
+		r.batchErrors = r.batchErrors[1:]
+		return err
+	}
+	for _, click := range clicks {
+		r.writtenLinks = append(r.writtenLinks, click.LinkID)
+	}
+	return nil
+}
+
+func (r *fakeClickRepository) CreateClick(_ context.Context, click *models.Click) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.singleCalls++
+	if click.LinkID == r.badLink {
+		return errors.New("FOREIGN KEY constraint failed")
+	}
+	r.writtenLinks = append(r.writtenLinks, click.LinkID)
+	return nil
+}
+
+// writeEvents fait écrire les clics des liens donnés en un seul lot.
+func writeEvents(repo *fakeClickRepository, linkIDs ...uint) *ClickWriter {
+	events := make(chan models.ClickEvent, len(linkIDs))
+	for _, id := range linkIDs {
+		events <- models.ClickEvent{LinkID: id, Timestamp: time.Now()}
+	}
+	close(events)
+	w := StartClickWriter(events, repo, nil, len(linkIDs)+1, time.Hour)
+	w.Wait()
+	return w
+}
+
+func TestClickWriterRetriesTransientErrors(t *testing.T) {
+	locked := errors.New("database is locked")
+	repo := &fakeClickRepository{batchErrors: []error{locked, locked}}
+
+	w := writeEvents(repo, 1, 2, 3)
+
+	written, failed := w.Stats()
+	if written != 3 || failed != 0 {
+		t.Errorf("écrits=%d, en échec=%d, attendu 3 et 0", written, failed)
+	}
+	if repo.batchCalls != 3 || repo.singleCalls != 0 {
+		t.Errorf("%d écriture(s) du lot et %d clic(s) isolé(s), attendu 3 et 0", repo.batchCalls, repo.singleCalls)
+	}
+}
+
+func TestClickWriterGivesUpAfterRetries(t *testing.T) {
+	locked := errors.New("database is locked")
+	repo := &fakeClickRepository{batchErrors: []error{locked, locked, locked, locked}}
+
+	w := writeEvents(repo, 1, 2)
+
+	written, failed := w.Stats()
+	if written != 0 || failed != 2 {
+		t.Errorf("écrits=%d, en échec=%d, attendu 0 et 2", written, failed)
+	}
+	// Une erreur passagère ne doit pas déclencher l'écriture clic par clic.
+	if repo.batchCalls != flushAttempts || repo.singleCalls != 0 {
+		t.Errorf("%d écriture(s) du lot et %d clic(s) isolé(s), attendu %d et 0", repo.batchCalls, repo.singleCalls, flushAttempts)
+	}
+}
+
+func TestClickWriterIsolatesInvalidClicks(t *testing.T) {
+	repo := &fakeClickRepository{
+		batchErrors: []error{errors.New("FOREIGN KEY constraint failed")},
+		badLink:     2,
+	}
+
+	w := writeEvents(repo, 1, 2, 3)
+
+	written, failed := w.Stats()
+	if written != 2 || failed != 1 {
+		t.Errorf("écrits=%d, en échec=%d, attendu 2 et 1", written, failed)
+	}
+	if repo.batchCalls != 1 || repo.singleCalls != 3 {
+		t.Errorf("%d écriture(s) du lot et %d clic(s) isolé(s), attendu 1 et 3", repo.batchCalls, repo.singleCalls)
+	}
+}
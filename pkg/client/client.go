@@ -0,0 +1,222 @@
+// Package client est un client Go typé de l'API /api/v1 du raccourcisseur
+// d'URLs, décrite par /api/v1/openapi.json.
+//
+//	c, err := client.New("https://sho.rt")
+//	link, err := c.CreateLink(ctx, client.CreateLinkRequest{LongURL: "https://exemple.com"})
+//	stats, err := c.GetLinkStats(ctx, link.ShortCode)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTimeout borne chaque requête du client HTTP par défaut.
+const defaultTimeout = 10 * time.Second
+
+// maxErrorBody borne la lecture du corps d'une réponse d'erreur.
+const maxErrorBody = 64 << 10
+
+// Client appelle l'API d'un serveur. Il peut être partagé entre goroutines.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	userAgent  string
+}
+
+// Option modifie la configuration d'un Client.
+type Option func(*Client)
+
+// WithHTTPClient remplace le client HTTP par défaut (délai de 10 secondes).
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent définit l'en-tête User-Agent des requêtes.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New crée un client pour le serveur d'URL de base baseURL (par exemple
+// "https://sho.rt").
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("URL de base invalide : %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("URL de base invalide : %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  "urlshortener-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// APIError est une réponse d'erreur du serveur.
+type APIError struct {
+	StatusCode int
+	Message    string // Champ error de la réponse, ou corps brut
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("urlshortener : %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound indique si err signale un lien ou une campagne inconnus.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// CreateLink crée un lien court. Avec ReuseExisting, Link.Reused indique qu'un
+// lien existant a été retourné.
+func (c *Client) CreateLink(ctx context.Context, req CreateLinkRequest) (*Link, error) {
+	var link Link
+	if err := c.do(ctx, http.MethodPost, "/api/v1/links", nil, req, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CreateLinksBulk crée plusieurs liens dans une même transaction. Les éléments
+// refusés sont signalés dans leur résultat, sans faire échouer l'appel.
+func (c *Client) CreateLinksBulk(ctx context.Context, links []CreateLinkRequest) (*BulkCreateLinksResponse, error) {
+	var resp BulkCreateLinksResponse
+	body := struct {
+		Links []CreateLinkRequest `json:"links"`
+	}{links}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/links/bulk", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetLinkStats retourne le nombre total de clics d'un lien.
+func (c *Client) GetLinkStats(ctx context.Context, shortCode string) (*LinkStats, error) {
+	var stats LinkStats
+	if err := c.do(ctx, http.MethodGet, "/api/v1/links/"+url.PathEscape(shortCode)+"/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetLinkTimeSeries retourne les clics d'un lien par heure ou par jour (UTC).
+func (c *Client) GetLinkTimeSeries(ctx context.Context, shortCode string, query StatsQuery) (*TimeSeries, error) {
+	var series TimeSeries
+	path := "/api/v1/links/" + url.PathEscape(shortCode) + "/stats/timeseries"
+	if err := c.do(ctx, http.MethodGet, path, query.values(), nil, &series); err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// GetLinkBreakdown retourne les limit valeurs de la dimension ayant reçu le
+// plus de clics sur la période (limit nul : valeur par défaut du serveur).
+func (c *Client) GetLinkBreakdown(ctx context.Context, shortCode string, dimension Dimension, query StatsQuery, limit int) (*Breakdown, error) {
+	var breakdown Breakdown
+	params := query.values()
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/api/v1/links/" + url.PathEscape(shortCode) + "/stats/breakdown/" + url.PathEscape(string(dimension))
+	if err := c.do(ctx, http.MethodGet, path, params, nil, &breakdown); err != nil {
+		return nil, err
+	}
+	return &breakdown, nil
+}
+
+// GetCampaignStats retourne les clics des liens d'une campagne UTM.
+func (c *Client) GetCampaignStats(ctx context.Context, campaignID uint) (*CampaignStats, error) {
+	var stats CampaignStats
+	path := "/api/v1/campaigns/" + strconv.FormatUint(uint64(campaignID), 10) + "/stats"
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (q StatsQuery) values() url.Values {
+	params := url.Values{}
+	if q.Granularity != "" {
+		params.Set("granularity", string(q.Granularity))
+	}
+	if !q.From.IsZero() {
+		params.Set("from", q.From.Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		params.Set("to", q.To.Format(time.RFC3339))
+	}
+	return params
+}
+
+// do envoie la requête, avec in encodé en JSON s'il est non nil, et décode
+// une réponse 2xx dans out. Les autres réponses deviennent des *APIError.
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, in, out any) error {
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = params.Encode()
+
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encodage de la requête : %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(raw))}
+		var payload struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(raw, &payload) == nil && payload.Error != "" {
+			apiErr.Message = payload.Error
+		}
+		return apiErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("décodage de la réponse : %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package client
+
+import "time"
+
+// Granularity est la période d'agrégation des statistiques.
+type Granularity string
+
+const (
+	GranularityHour Granularity = "hour"
+	GranularityDay  Granularity = "day"
+)
+
+// Dimension est le critère de répartition des clics.
+type Dimension string
+
+const (
+	DimensionReferrer Dimension = "referrer"
+	DimensionCountry  Dimension = "country"
+	DimensionDevice   Dimension = "device"
+)
+
+// Modes de transfert des paramètres de requête de l'URL courte.
+const (
+	ForwardQueryMerge    = "merge"    // Ajoutés à la destination, sans écraser ceux déjà présents
+	ForwardQueryOverride = "override" // Ajoutés à la destination, en remplaçant ceux déjà présents
+)
+
+// CreateLinkRequest décrit un lien à créer. Seul LongURL est obligatoire.
+type CreateLinkRequest struct {
+	LongURL string `json:"long_url"`
+	Alias   string `json:"alias,omitempty"` // Code court personnalisé
+
+	ForwardQuery string `json:"forward_query,omitempty"` // ForwardQueryMerge ou ForwardQueryOverride
+	ForwardPath  bool   `json:"forward_path,omitempty"`  // Transfert des segments de chemin
+
+	// Paramètres de campagne ajoutés à l'URL de destination
+	UTMSource   string `json:"utm_source,omitempty"`
+	UTMMedium   string `json:"utm_medium,omitempty"`
+	UTMCampaign string `json:"utm_campaign,omitempty"`
+	UTMTerm     string `json:"utm_term,omitempty"`
+	UTMContent  string `json:"utm_content,omitempty"`
+
+	// Réponse de redirection propre au lien (sinon configuration du serveur)
+	RedirectStatus int  `json:"redirect_status,omitempty"` // 301, 302, 307 ou 308
+	CacheMaxAge    *int `json:"cache_max_age,omitempty"`   // Durée de cache en secondes
+
+	Password string `json:"password,omitempty"` // Mot de passe demandé avant la redirection
+
+	ReuseExisting bool `json:"reuse_existing,omitempty"` // Retourne le lien existant vers la même destination
+
+	CodeStrategy string `json:"code_strategy,omitempty"` // random, sequential, words ou unambiguous
+}
+
+// Link est un lien court.
+type Link struct {
+	ShortCode      string `json:"short_code"`
+	CampaignID     *uint  `json:"campaign_id"`
+	LongURL        string `json:"long_url"`
+	ForwardQuery   string `json:"forward_query"`
+	ForwardPath    bool   `json:"forward_path"`
+	RedirectStatus int    `json:"redirect_status"` // 0 : configuration du serveur
+	CacheMaxAge    *int   `json:"cache_max_age"`
+	Protected      bool   `json:"protected"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	FaviconURL     string `json:"favicon_url"`
+	ImageURL       string `json:"image_url"`
+	FullShortURL   string `json:"full_short_url"`
+	Reused         bool   `json:"reused"` // Lien existant retourné au lieu d'un nouveau lien
+}
+
+// BulkLinkResult est le résultat d'un élément d'une création en lot : le lien
+// créé, ou Error si l'élément a été refusé.
+type BulkLinkResult struct {
+	Link
+	Index int    `json:"index"` // Position de l'élément dans la requête
+	Error string `json:"error"`
+}
+
+// BulkCreateLinksResponse regroupe les résultats d'une création en lot, dans
+// l'ordre de la requête.
+type BulkCreateLinksResponse struct {
+	Created int              `json:"created"`
+	Failed  int              `json:"failed"`
+	Results []BulkLinkResult `json:"results"`
+}
+
+// LinkStats est le nombre total de clics d'un lien.
+type LinkStats struct {
+	ShortCode   string `json:"short_code"`
+	LongURL     string `json:"long_url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	FaviconURL  string `json:"favicon_url"`
+	ImageURL    string `json:"image_url"`
+	Disabled    bool   `json:"disabled"`
+	TotalClicks int    `json:"total_clicks"`
+}
+
+// StatsQuery décrit la période interrogée. Les valeurs nulles prennent les
+// valeurs par défaut du serveur (30 derniers jours, ou 24 dernières heures).
+type StatsQuery struct {
+	Granularity Granularity
+	From        time.Time // Incluse
+	To          time.Time // La période qui la contient est incluse
+}
+
+// TimeSeriesPoint est le nombre de clics d'une période.
+type TimeSeriesPoint struct {
+	Bucket time.Time `json:"bucket"`
+	Clicks int64     `json:"clicks"`
+}
+
+// TimeSeries est le nombre de clics d'un lien par période, périodes sans clic comprises.
+type TimeSeries struct {
+	ShortCode   string            `json:"short_code"`
+	Granularity Granularity       `json:"granularity"`
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	Total       int64             `json:"total"`
+	Points      []TimeSeriesPoint `json:"points"`
+}
+
+// BreakdownValue est le nombre de clics d'une valeur de dimension (vide si inconnue).
+type BreakdownValue struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+// Breakdown est la répartition des clics d'un lien selon une dimension.
+type Breakdown struct {
+	ShortCode   string           `json:"short_code"`
+	Dimension   Dimension        `json:"dimension"`
+	Granularity Granularity      `json:"granularity"`
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Values      []BreakdownValue `json:"values"`
+}
+
+// CampaignLinkStats est le nombre de clics d'un lien d'une campagne.
+type CampaignLinkStats struct {
+	ShortCode   string `json:"short_code"`
+	LongURL     string `json:"long_url"`
+	TotalClicks int    `json:"total_clicks"`
+}
+
+// CampaignStats regroupe les clics des liens d'une campagne UTM.
+type CampaignStats struct {
+	CampaignID  uint                `json:"campaign_id"`
+	Name        string              `json:"name"`
+	TotalClicks int                 `json:"total_clicks"`
+	Links       []CampaignLinkStats `json:"links"`
+}